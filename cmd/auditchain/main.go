@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"polyagent-backend/configs"
+	"polyagent-backend/internal/auditchain"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const configPath = "configs/config.yaml"
+
+// 用法:
+//
+//	auditchain verify                  校验全部基金
+//	auditchain verify -fund <fund_uuid> 校验单个基金
+//	auditchain export                  导出当前链头
+//
+// 校验发现问题时以状态码 1 退出
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "用法: auditchain verify [-fund id] | export")
+		os.Exit(2)
+	}
+
+	cmd := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	fund := cmd.String("fund", "", "基金ID，为空时校验全部基金")
+	cmd.Parse(os.Args[2:])
+
+	log := logger.NewLogger()
+	defer log.Sync()
+
+	cfg, _ := configs.LoadConfig(configPath)
+
+	repo, err := repository.NewPostgresRepository(cfg.Database)
+	if err != nil {
+		log.Fatal("初始化数据库失败", zap.Error(err))
+	}
+
+	verifier := auditchain.NewVerifier(repo, log, cfg.AuditChain.ExportDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	switch os.Args[1] {
+	case "verify":
+		var reports []*auditchain.Report
+		if *fund != "" {
+			fundID, err := uuid.Parse(*fund)
+			if err != nil {
+				log.Fatal("无效的基金ID", zap.Error(err))
+			}
+			report, err := verifier.VerifyFund(ctx, fundID)
+			if err != nil {
+				log.Fatal("校验哈希链失败", zap.Error(err))
+			}
+			reports = append(reports, report)
+		} else if reports, err = verifier.VerifyAll(ctx); err != nil {
+			log.Fatal("校验哈希链失败", zap.Error(err))
+		}
+
+		failed := false
+		for _, report := range reports {
+			if report.OK() {
+				fmt.Printf("%s\tOK\t条目:%d\n", report.FundID, report.Entries)
+				continue
+			}
+			failed = true
+			fmt.Printf("%s\tFAILED\t条目:%d\t问题:%d\n", report.FundID, report.Entries, len(report.Issues))
+			for _, issue := range report.Issues {
+				fmt.Printf("  seq=%d\t%s\t%s %s\t%s\n", issue.Seq, issue.Kind,
+					issue.RecordType, issue.RecordID, issue.Detail)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+
+	case "export":
+		snapshots, err := verifier.ExportHeads(ctx)
+		if err != nil {
+			log.Fatal("导出链头失败", zap.Error(err))
+		}
+		for _, snapshot := range snapshots {
+			fmt.Printf("%s\tseq:%d\t%s\n", snapshot.FundID, snapshot.Seq, snapshot.Hash)
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n", os.Args[1])
+		os.Exit(2)
+	}
+}
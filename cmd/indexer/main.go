@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"polyagent-backend/configs"
+	"polyagent-backend/internal/indexer"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const configPath = "configs/config.yaml"
+
+// 用法:
+//
+//	indexer                 按配置的间隔持续同步
+//	indexer -once           同步一次后退出
+//	indexer -rpc <url>      指定 RPC 地址（如本地开发链或录制回放服务），覆盖配置
+func main() {
+	once := flag.Bool("once", false, "同步一次后退出")
+	rpcURL := flag.String("rpc", "", "以太坊 JSON-RPC 地址，为空时使用配置")
+	flag.Parse()
+
+	log := logger.NewLogger()
+	defer log.Sync()
+
+	cfg, _ := configs.LoadConfig(configPath)
+
+	repo, err := repository.NewPostgresRepository(cfg.Database)
+	if err != nil {
+		log.Fatal("初始化数据库失败", zap.Error(err))
+	}
+
+	if *rpcURL == "" {
+		*rpcURL = cfg.Ethereum.RPCURL
+	}
+	ix := indexer.NewIndexer(repo, indexer.NewClient(*rpcURL), log, indexer.Config{
+		ChainID:       int64(cfg.Ethereum.ChainID),
+		Confirmations: cfg.Indexer.Confirmations,
+		BatchBlocks:   cfg.Indexer.BatchBlocks,
+		StartBlock:    cfg.Indexer.StartBlock,
+		ReorgDepth:    cfg.Indexer.ReorgDepth,
+		AssetDecimals: cfg.Indexer.AssetDecimals,
+		ShareDecimals: cfg.Indexer.ShareDecimals,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *once {
+		results, err := ix.Sync(ctx)
+		if err != nil {
+			log.Fatal("同步Vault事件失败", zap.Error(err))
+		}
+		for _, result := range results {
+			fmt.Printf("%s\t区块:%d-%d\t事件:%d\t孤块:%d\n",
+				result.FundID, result.FromBlock, result.ToBlock, result.Events, result.Orphaned)
+		}
+		return
+	}
+
+	interval := cfg.Indexer.PollInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	log.Info("Vault事件索引已启动",
+		zap.String("rpc", *rpcURL),
+		zap.Duration("interval", interval))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := ix.Sync(ctx); err != nil {
+			log.Error("同步Vault事件失败", zap.Error(err))
+		}
+
+		select {
+		case <-sigCh:
+			log.Info("Vault事件索引已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
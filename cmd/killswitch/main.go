@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"polyagent-backend/configs"
+	"polyagent-backend/internal/killswitch"
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const configPath = "configs/config.yaml"
+
+// 用法:
+//
+//	killswitch -scope global -on -reason "交易所异常"
+//	killswitch -scope fund -id <fund_uuid> -on -allow-stop-loss=false
+//	killswitch -scope market -id <market_id> -off
+//	killswitch -list
+func main() {
+	scope := flag.String("scope", "global", "熔断范围: global / fund / market")
+	scopeID := flag.String("id", "", "基金ID或市场ID，全局熔断时忽略")
+	on := flag.Bool("on", false, "开启熔断")
+	off := flag.Bool("off", false, "解除熔断")
+	allowStopLoss := flag.Bool("allow-stop-loss", true, "熔断期间是否允许止损平仓")
+	reason := flag.String("reason", "", "切换原因")
+	operator := flag.String("operator", "cli", "操作人标识")
+	list := flag.Bool("list", false, "列出当前生效的熔断开关")
+	flag.Parse()
+
+	log := logger.NewLogger()
+	defer log.Sync()
+
+	cfg, _ := configs.LoadConfig(configPath)
+
+	repo, err := repository.NewPostgresRepository(cfg.Database)
+	if err != nil {
+		log.Fatal("初始化数据库失败", zap.Error(err))
+	}
+
+	var cache repository.RedisRepository
+	if cfg.Redis.Address != "" {
+		if cache, err = repository.NewRedisRepository(cfg.Redis); err != nil {
+			log.Fatal("初始化Redis失败", zap.Error(err))
+		}
+	}
+
+	ks := killswitch.NewService(repo, cache, log)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if *list {
+		switches, err := ks.Active(ctx)
+		if err != nil {
+			log.Fatal("获取熔断开关失败", zap.Error(err))
+		}
+		if len(switches) == 0 {
+			fmt.Println("当前无生效的熔断开关")
+			return
+		}
+		for i := range switches {
+			fmt.Printf("%s\t止损平仓:%v\t操作人:%s\t%s\n",
+				killswitch.Describe(&switches[i]), switches[i].AllowStopLoss,
+				switches[i].Operator, switches[i].UpdatedAt.Format(time.RFC3339))
+		}
+		return
+	}
+
+	if *on == *off {
+		fmt.Fprintln(os.Stderr, "必须且只能指定 -on 或 -off 之一")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	result, err := ks.Set(ctx, models.KillSwitchScope(strings.ToUpper(*scope)), *scopeID,
+		*on, *allowStopLoss, *reason, *operator)
+	if err != nil {
+		log.Fatal("切换熔断开关失败", zap.Error(err))
+	}
+
+	fmt.Println(killswitch.Describe(result))
+}
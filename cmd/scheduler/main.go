@@ -8,9 +8,21 @@ import (
 	"time"
 
 	"polyagent-backend/configs"
+	"polyagent-backend/internal/analytics"
+	"polyagent-backend/internal/cash"
+	"polyagent-backend/internal/catalog"
+	"polyagent-backend/internal/dashboard"
 	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/indexer"
+	"polyagent-backend/internal/killswitch"
+	"polyagent-backend/internal/leaderboard"
+	"polyagent-backend/internal/marketdata"
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/nav"
 	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/pricehistory"
 	"polyagent-backend/internal/repository"
+	"polyagent-backend/internal/resolution"
 	"polyagent-backend/internal/risk"
 	"polyagent-backend/internal/scheduler"
 
@@ -45,10 +57,63 @@ func main() {
 		log.Fatal("初始化Polymarket客户端失败", zap.Error(err))
 	}
 
+	// 初始化熔断开关，未配置Redis时直接查询数据库
+	var cache repository.RedisRepository
+	if cfg.Redis.Address != "" {
+		if cache, err = repository.NewRedisRepository(cfg.Redis); err != nil {
+			log.Fatal("初始化Redis失败", zap.Error(err))
+		}
+	} else {
+		log.Warn("未配置Redis，熔断开关将直接查询数据库")
+	}
+	killSwitch := killswitch.NewService(repo, cache, log)
+	if err := killSwitch.Sync(context.Background()); err != nil {
+		log.Error("同步熔断开关缓存失败", zap.Error(err))
+	}
+
+	// 初始化实时行情，未配置时直接查询 REST 接口
+	var marketData risk.MarketDataProvider = pmClient
+	var feed *marketdata.Feed
+	if cfg.MarketData.WSURL != "" {
+		feed = marketdata.NewFeed(pmClient, repo, log, marketdata.Config{
+			URL:             cfg.MarketData.WSURL,
+			PingInterval:    cfg.MarketData.PingInterval,
+			RefreshInterval: cfg.MarketData.RefreshInterval,
+			MarketTTL:       cfg.MarketData.MarketTTL,
+		})
+		marketData = feed
+	}
+
 	// 初始化组件
-	auditor := risk.NewAuditor(repo, log)
+	auditor := risk.NewAuditor(repo, marketData, log)
+	auditor.SetKillSwitch(killSwitch)
+	prices := pricehistory.NewService(repo, log, pricehistory.Config{
+		SampleRetention: cfg.PriceHistory.SampleRetention,
+		CandleRetention: map[models.CandleInterval]time.Duration{
+			models.CandleInterval1m: cfg.PriceHistory.Candle1mRetention,
+			models.CandleInterval1h: cfg.PriceHistory.Candle1hRetention,
+			models.CandleInterval1d: cfg.PriceHistory.Candle1dRetention,
+		},
+	})
+	auditor.SetVolatilitySource(prices)
 	exec := executor.NewExecutor(repo, pmClient, log, cfg.WorkerCount)
+	exec.SetKillSwitch(killSwitch)
 	rtEngine := risk.NewRealtimeRiskEngine(repo, auditor, log, cfg.RealtimeCheckInterval)
+	if cfg.CloseOutCooldown > 0 {
+		rtEngine.SetCloseOutCooldown(cfg.CloseOutCooldown)
+	}
+	if feed != nil {
+		exec.SetQuoteSource(feed)
+		auditor.SetQuoteSource(feed)
+		rtEngine.SetPriceFeed(feed)
+		prices.SetQuoteSource(feed)
+	}
+
+	mark, err := nav.ParseMark(cfg.NAV.Mark)
+	if err != nil {
+		log.Fatal("净值估值方式配置错误", zap.Error(err))
+	}
+	navEngine := nav.NewEngine(repo, marketData, mark, log)
 
 	// 初始化调度器
 	schedConfig := scheduler.Config{
@@ -59,17 +124,56 @@ func main() {
 		SettlementTime:        "0 0 * * *", // 每天UTC 00:00
 		AggregationInterval:   10 * time.Second,
 		RealtimeCheckInterval: cfg.RealtimeCheckInterval,
+		ReviewCheckInterval:   1 * time.Minute,
+		NAVEstimateInterval:   cfg.NAV.EstimateInterval,
+		ChainExportInterval:   cfg.AuditChain.ExportInterval,
+		ChainExportDir:        cfg.AuditChain.ExportDir,
+		CashSnapshotInterval:  cfg.Cash.SnapshotInterval,
+		CatalogSyncInterval:   cfg.Catalog.SyncInterval,
+
+		PriceHistoryPruneInterval: cfg.PriceHistory.PruneInterval,
+		LeaderboardInterval:       cfg.Leaderboard.RefreshInterval,
+		DashboardInterval:         cfg.Dashboard.RefreshInterval,
 	}
 
-	sched, err := scheduler.NewScheduler(repo, auditor, exec, rtEngine, log, schedConfig)
+	sched, err := scheduler.NewScheduler(repo, auditor, exec, rtEngine, navEngine, log, schedConfig)
 	if err != nil {
 		log.Fatal("初始化调度器失败", zap.Error(err))
 	}
+	sched.SetMarketResolver(resolution.NewService(repo, pmClient, log))
+	sched.SetPriceHistory(prices)
+	sched.SetAnalytics(analytics.NewService(repo, log, analytics.Config{
+		RiskFreeRate: cfg.Analytics.RiskFreeRate,
+	}))
+	sched.SetDashboard(dashboard.NewService(repo, auditor, log, dashboard.Config{
+		IntentWindow: cfg.Dashboard.IntentWindow,
+		EventWindow:  cfg.Dashboard.EventWindow,
+		RecentEvents: cfg.Dashboard.RecentEvents,
+	}))
+	if cache != nil {
+		sched.SetLeaderboard(leaderboard.NewService(repo, cache, log, leaderboard.Config{
+			TruncateAddress: cfg.Leaderboard.TruncateAddress,
+		}))
+	}
+	if cfg.Catalog.BaseURL != "" {
+		sched.SetCatalog(catalog.NewSyncer(repo, catalog.NewGammaClient(cfg.Catalog.BaseURL), log, catalog.Config{
+			PageSize:     cfg.Catalog.PageSize,
+			WatchMarkets: cfg.Catalog.WatchMarkets,
+		}))
+	}
+
+	if cfg.Cash.USDCAddress != "" && cfg.Ethereum.RPCURL != "" {
+		sched.SetCashTracker(cash.NewTracker(repo, indexer.NewClient(cfg.Ethereum.RPCURL),
+			cfg.Cash.USDCAddress, cfg.Cash.Decimals, log))
+	}
 
 	// 启动所有组件
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if feed != nil {
+		go feed.Run(ctx)
+	}
 	exec.Start(ctx)
 	if err := sched.Start(ctx); err != nil {
 		log.Fatal("启动调度器失败", zap.Error(err))
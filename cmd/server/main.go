@@ -22,10 +22,22 @@ func main() {
 	// r := router.SetupRouter(
 	//     logger,
 	//     cfg.JWTSecret,
+	//     middleware.NewRoleGrants(cfg.Auth.Admins, cfg.Auth.Compliance), // 管理员与合规角色由配置授予
+	//     repo, // 基金查询，用于校验基金经理权限
 	//     authCtrl,
 	//     fundCtrl,
 	//     intentCtrl,
 	//     investorCtrl,
+	//     reviewCtrl,
+	//     killSwitchCtrl,
+	//     riskRuleCtrl,
+	//     transparencyCtrl,
+	//     investmentCtrl,
+	//     feeCtrl,
+	//     cashCtrl,
+	//     marketCtrl,
+	//     performanceCtrl,
+	//     dashboardCtrl,
 	// )
 
 	// // 5. 启动服务
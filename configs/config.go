@@ -13,9 +13,23 @@ type Config struct {
 	Redis    RedisConfig    `mapstructure:"redis"`
 	Ethereum EthereumConfig `mapstructure:"ethereum"`
 	AI       AIConfig       `mapstructure:"ai"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+
+	AuditChain   AuditChainConfig   `mapstructure:"audit_chain"`
+	Transparency TransparencyConfig `mapstructure:"transparency"`
+	NAV          NAVConfig          `mapstructure:"nav"`
+	Indexer      IndexerConfig      `mapstructure:"indexer"`
+	Cash         CashConfig         `mapstructure:"cash"`
+	Catalog      CatalogConfig      `mapstructure:"catalog"`
+	MarketData   MarketDataConfig   `mapstructure:"market_data"`
+	PriceHistory PriceHistoryConfig `mapstructure:"price_history"`
+	Analytics    AnalyticsConfig    `mapstructure:"analytics"`
+	Leaderboard  LeaderboardConfig  `mapstructure:"leaderboard"`
+	Dashboard    DashboardConfig    `mapstructure:"dashboard"`
 
 	WorkerCount           int
 	RealtimeCheckInterval time.Duration
+	CloseOutCooldown      time.Duration // 止损平仓冷却期
 	Polymarket            PolymarketConfig
 }
 
@@ -49,6 +63,91 @@ type AIConfig struct {
 	Model        string `mapstructure:"models"`         // 使用的模型名称
 }
 
+// AuthConfig 特权角色配置，按钱包地址授予，修改后重启生效
+type AuthConfig struct {
+	Admins     []string `mapstructure:"admins"`     // 管理员地址，可访问复核队列与交易熔断开关
+	Compliance []string `mapstructure:"compliance"` // 合规人员地址，可访问复核队列
+}
+
+// AuditChainConfig 审计哈希链配置
+type AuditChainConfig struct {
+	ExportInterval time.Duration `mapstructure:"export_interval"` // 链头导出间隔，0表示不导出
+	ExportDir      string        `mapstructure:"export_dir"`      // 链头导出目录，为空时仅写入数据库
+}
+
+// NAVConfig 净值计算配置
+type NAVConfig struct {
+	Mark             string        `mapstructure:"mark"`              // 持仓估值方式：mid / bid / last
+	EstimateInterval time.Duration `mapstructure:"estimate_interval"` // 盘中估算净值间隔，0表示不估算
+}
+
+// IndexerConfig 链上 Vault 事件索引配置
+type IndexerConfig struct {
+	PollInterval  time.Duration `mapstructure:"poll_interval"`  // 同步间隔
+	Confirmations uint64        `mapstructure:"confirmations"`  // 确认深度
+	BatchBlocks   uint64        `mapstructure:"batch_blocks"`   // 单次查询区块数
+	StartBlock    uint64        `mapstructure:"start_block"`    // 无检查点时的起始区块
+	ReorgDepth    uint64        `mapstructure:"reorg_depth"`    // 检测到重组时回退的区块数
+	AssetDecimals int32         `mapstructure:"asset_decimals"` // 底层资产精度
+	ShareDecimals int32         `mapstructure:"share_decimals"` // Vault 份额精度
+}
+
+// CashConfig 基金现金余额配置
+type CashConfig struct {
+	USDCAddress      string        `mapstructure:"usdc_address"`      // USDC 合约地址
+	Decimals         int32         `mapstructure:"decimals"`          // USDC 精度
+	SnapshotInterval time.Duration `mapstructure:"snapshot_interval"` // 链上余额快照间隔，0表示不快照
+}
+
+// CatalogConfig 市场目录同步配置
+type CatalogConfig struct {
+	BaseURL      string        `mapstructure:"base_url"`      // Gamma 目录接口地址
+	PageSize     int           `mapstructure:"page_size"`     // 目录分页大小
+	SyncInterval time.Duration `mapstructure:"sync_interval"` // 完整目录同步间隔，0表示不同步
+	WatchMarkets []string      `mapstructure:"watch_markets"` // 关注的市场条件ID，与持仓市场一并刷新行情
+}
+
+// MarketDataConfig 实时行情配置
+type MarketDataConfig struct {
+	WSURL           string        `mapstructure:"ws_url"`           // CLOB market 频道地址，为空时不订阅实时行情
+	PingInterval    time.Duration `mapstructure:"ping_interval"`    // 心跳间隔
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // 订阅代币刷新间隔
+	MarketTTL       time.Duration `mapstructure:"market_ttl"`       // 市场信息缓存时长
+}
+
+// PriceHistoryConfig 价格历史保留配置，保留期为0表示永久保留
+type PriceHistoryConfig struct {
+	SampleRetention   time.Duration `mapstructure:"sample_retention"`    // 价格采样保留期
+	Candle1mRetention time.Duration `mapstructure:"candle_1m_retention"` // 1分钟K线保留期
+	Candle1hRetention time.Duration `mapstructure:"candle_1h_retention"` // 1小时K线保留期
+	Candle1dRetention time.Duration `mapstructure:"candle_1d_retention"` // 日K线保留期
+	PruneInterval     time.Duration `mapstructure:"prune_interval"`      // 清理间隔，0表示不清理
+}
+
+// AnalyticsConfig 基金业绩分析配置
+type AnalyticsConfig struct {
+	RiskFreeRate float64 `mapstructure:"risk_free_rate"` // 年化无风险利率（小数），用于 Sharpe/Sortino
+}
+
+// LeaderboardConfig 投资人收益榜单配置，需配置Redis
+type LeaderboardConfig struct {
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // 榜单刷新间隔，0表示不刷新
+	TruncateAddress bool          `mapstructure:"truncate_address"` // 对外展示时截断投资人地址
+}
+
+// DashboardConfig 基金经理看板配置
+type DashboardConfig struct {
+	RefreshInterval time.Duration `mapstructure:"refresh_interval"` // 健康度刷新间隔，0表示不刷新
+	IntentWindow    time.Duration `mapstructure:"intent_window"`    // 交易意图漏斗统计窗口
+	EventWindow     time.Duration `mapstructure:"event_window"`     // 风控事件统计窗口
+	RecentEvents    int           `mapstructure:"recent_events"`    // 展示的最近风控事件数
+}
+
+// TransparencyConfig 交易审计公开配置
+type TransparencyConfig struct {
+	PublishDelay time.Duration `mapstructure:"publish_delay"` // 意图终结后延迟公开的时长，防止策略被抢跑
+}
+
 // PolymarketConfig Polymarket配置
 type PolymarketConfig struct {
 	BaseURL    string
@@ -0,0 +1,410 @@
+// Package analytics 基金业绩分析。
+// 基于正式净值历史计算区间收益率、最大回撤、波动率与 Sharpe/Sortino，
+// 基于成交记录与市场结算计算逐笔胜率与平均盈亏。结果按基金缓存，每次净值结算后重新计算。
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	precision   = 8
+	daysPerYear = 365 // 预测市场全年交易，按自然日年化
+	dateLayout  = "2006-01-02"
+)
+
+// ErrFundNotFound 基金不存在
+var ErrFundNotFound = errors.New("基金不存在")
+
+// Config 业绩分析配置
+type Config struct {
+	RiskFreeRate float64 // 年化无风险利率（小数），用于 Sharpe/Sortino
+}
+
+// Service 业绩分析服务
+type Service struct {
+	repo   repository.Repository
+	logger *logger.Logger
+	config Config
+}
+
+// NewService 创建业绩分析服务
+func NewService(repo repository.Repository, logger *logger.Logger, config Config) *Service {
+	return &Service{
+		repo:   repo,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Metrics 基金业绩指标，比例均为原始小数
+type Metrics struct {
+	FundID              uuid.UUID       `json:"fundId"`
+	CurrentNav          decimal.Decimal `json:"currentNav"`
+	AsOf                *time.Time      `json:"asOf,omitempty"`
+	Return1DPct         decimal.Decimal `json:"return1dPct"`
+	Return7DPct         decimal.Decimal `json:"return7dPct"`
+	Return30DPct        decimal.Decimal `json:"return30dPct"`
+	ReturnYTDPct        decimal.Decimal `json:"returnYtdPct"`
+	CumulativeReturnPct decimal.Decimal `json:"cumulativeReturnPct"`
+	MaxDrawdownPct      decimal.Decimal `json:"maxDrawdownPct"`
+	MaxDrawdownDays     int             `json:"maxDrawdownDays"`
+	CurrentDrawdownPct  decimal.Decimal `json:"currentDrawdownPct"`
+	VolatilityPct       decimal.Decimal `json:"volatilityPct"`
+	SharpeRatio         decimal.Decimal `json:"sharpeRatio"`
+	SortinoRatio        decimal.Decimal `json:"sortinoRatio"`
+	TradeCount          int             `json:"tradeCount"`
+	HitRatePct          decimal.Decimal `json:"hitRatePct"`
+	AvgWin              decimal.Decimal `json:"avgWin"`
+	AvgLoss             decimal.Decimal `json:"avgLoss"`
+	ComputedAt          time.Time       `json:"computedAt"`
+}
+
+// CurvePoint 业绩曲线数据点（当日最后一次正式净值）
+type CurvePoint struct {
+	Date  string          `json:"date"`
+	Value decimal.Decimal `json:"value"`
+}
+
+// navPoint 按日归并后的正式净值
+type navPoint struct {
+	day time.Time // UTC 日期
+	at  time.Time
+	nav decimal.Decimal
+}
+
+// Get 查询基金业绩指标，尚未计算时即时计算并缓存
+func (s *Service) Get(ctx context.Context, fundID uuid.UUID) (*Metrics, error) {
+	perf, err := s.repo.GetFundPerformance(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取业绩指标失败: %w", err)
+	}
+	if perf == nil {
+		if perf, err = s.Recompute(ctx, fundID); err != nil {
+			return nil, err
+		}
+	}
+	return metricsOf(perf), nil
+}
+
+// Curve 业绩曲线，from/to 为零值时不限制
+func (s *Service) Curve(ctx context.Context, fundID uuid.UUID, from, to time.Time) ([]CurvePoint, error) {
+	fund, err := s.repo.GetFund(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金失败: %w", err)
+	}
+	if fund == nil {
+		return nil, ErrFundNotFound
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	}
+	points, err := s.dailyNAV(ctx, fundID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := make([]CurvePoint, 0, len(points))
+	for _, p := range points {
+		curve = append(curve, CurvePoint{Date: p.day.Format(dateLayout), Value: p.nav})
+	}
+	return curve, nil
+}
+
+// Recompute 重新计算并缓存基金业绩指标
+func (s *Service) Recompute(ctx context.Context, fundID uuid.UUID) (*models.FundPerformance, error) {
+	fund, err := s.repo.GetFund(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金失败: %w", err)
+	}
+	if fund == nil {
+		return nil, ErrFundNotFound
+	}
+
+	points, err := s.dailyNAV(ctx, fundID, time.Time{}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	perf := &models.FundPerformance{
+		FundID:     fundID,
+		ComputedAt: time.Now(),
+	}
+	if len(points) > 0 {
+		s.navMetrics(perf, points)
+	}
+
+	pnls, err := s.tradePnLs(ctx, fundID)
+	if err != nil {
+		return nil, err
+	}
+	tradeMetrics(perf, pnls)
+
+	if err := s.repo.SaveFundPerformance(ctx, perf); err != nil {
+		return nil, fmt.Errorf("保存业绩指标失败: %w", err)
+	}
+
+	s.logger.Info("基金业绩指标已更新",
+		zap.String("fund_id", fundID.String()),
+		zap.String("return_inception", perf.ReturnInception.String()),
+		zap.String("max_drawdown", perf.MaxDrawdown.String()),
+		zap.Int("trades", perf.TradeCount))
+
+	return perf, nil
+}
+
+// dailyNAV 读取正式净值并按 UTC 日期归并，每日取最后一条
+func (s *Service) dailyNAV(ctx context.Context, fundID uuid.UUID, from, to time.Time) ([]navPoint, error) {
+	history, err := s.repo.GetNavHistory(ctx, fundID, models.NavKindOfficial, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("获取净值历史失败: %w", err)
+	}
+
+	var points []navPoint
+	for _, h := range history {
+		day := h.RecordedAt.UTC().Truncate(24 * time.Hour)
+		p := navPoint{day: day, at: h.RecordedAt, nav: h.NavPerShare}
+		if n := len(points); n > 0 && points[n-1].day.Equal(day) {
+			points[n-1] = p
+			continue
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// navMetrics 计算区间收益率、回撤与风险调整收益
+func (s *Service) navMetrics(perf *models.FundPerformance, points []navPoint) {
+	last := points[len(points)-1]
+	asOf := last.at
+	perf.AsOf = &asOf
+	perf.NavPerShare = last.nav
+
+	perf.Return1D = periodReturn(points, last.day.AddDate(0, 0, -1))
+	perf.Return7D = periodReturn(points, last.day.AddDate(0, 0, -7))
+	perf.Return30D = periodReturn(points, last.day.AddDate(0, 0, -30))
+	perf.ReturnYTD = periodReturn(points, time.Date(last.day.Year(), 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1))
+	perf.ReturnInception = periodReturn(points, time.Time{})
+
+	drawdown(perf, points)
+
+	// 日收益率
+	returns := make([]float64, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		prev := points[i-1].nav.InexactFloat64()
+		if prev <= 0 {
+			continue
+		}
+		returns = append(returns, points[i].nav.InexactFloat64()/prev-1)
+	}
+	if len(returns) < 2 {
+		return
+	}
+
+	riskFree := s.config.RiskFreeRate / daysPerYear
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance, downside float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+		if excess := r - riskFree; excess < 0 {
+			downside += excess * excess
+		}
+	}
+	stdev := math.Sqrt(variance / float64(len(returns)-1))
+	downsideDev := math.Sqrt(downside / float64(len(returns)))
+	annualize := math.Sqrt(daysPerYear)
+
+	perf.Volatility = roundFloat(stdev * annualize)
+	if stdev > 0 {
+		perf.Sharpe = roundFloat((mean - riskFree) / stdev * annualize)
+	}
+	if downsideDev > 0 {
+		perf.Sortino = roundFloat((mean - riskFree) / downsideDev * annualize)
+	}
+}
+
+// periodReturn 最新净值相对 base 日（含）之前最后一个净值的收益率；
+// base 之前没有净值（基金成立不足该区间）时按初始净值 1 计算
+func periodReturn(points []navPoint, base time.Time) decimal.Decimal {
+	start := decimal.NewFromInt(1)
+	for _, p := range points {
+		if p.day.After(base) {
+			break
+		}
+		start = p.nav
+	}
+	if !start.IsPositive() {
+		return decimal.Zero
+	}
+	return points[len(points)-1].nav.Div(start).Sub(decimal.NewFromInt(1)).Round(precision)
+}
+
+// drawdown 计算最大回撤及其持续天数（前高至恢复前高，未恢复时截至最新净值）与当前回撤
+func drawdown(perf *models.FundPerformance, points []navPoint) {
+	one := decimal.NewFromInt(1)
+	peakIdx, maxPeak, maxTrough := 0, 0, 0
+	maxDD := decimal.Zero
+
+	for i, p := range points {
+		if p.nav.GreaterThanOrEqual(points[peakIdx].nav) {
+			peakIdx = i
+			continue
+		}
+		if !points[peakIdx].nav.IsPositive() {
+			continue
+		}
+		dd := p.nav.Div(points[peakIdx].nav).Sub(one)
+		if dd.LessThan(maxDD) {
+			maxDD, maxPeak, maxTrough = dd, peakIdx, i
+		}
+	}
+	if peak := points[peakIdx].nav; peak.IsPositive() {
+		perf.CurrentDrawdown = points[len(points)-1].nav.Div(peak).Sub(one).Round(precision)
+	}
+	if maxDD.IsZero() {
+		return
+	}
+
+	end := len(points) - 1
+	for i := maxTrough + 1; i < len(points); i++ {
+		if points[i].nav.GreaterThanOrEqual(points[maxPeak].nav) {
+			end = i
+			break
+		}
+	}
+
+	peakAt, troughAt := points[maxPeak].at, points[maxTrough].at
+	perf.MaxDrawdown = maxDD.Round(precision)
+	perf.MaxDrawdownPeakAt = &peakAt
+	perf.MaxDrawdownTroughAt = &troughAt
+	perf.MaxDrawdownDays = int(points[end].day.Sub(points[maxPeak].day).Hours() / 24)
+}
+
+// tradePnLs 逐笔平仓盈亏：按成交记录回放各结果代币的平均成本，每笔卖出成交计一笔；
+// 已结算持仓按结算价相对持仓成本计一笔
+func (s *Service) tradePnLs(ctx context.Context, fundID uuid.UUID) ([]decimal.Decimal, error) {
+	fills, err := s.repo.GetFundFills(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取成交记录失败: %w", err)
+	}
+
+	type lot struct {
+		size decimal.Decimal
+		cost decimal.Decimal
+	}
+	lots := make(map[string]*lot)
+
+	var pnls []decimal.Decimal
+	for _, f := range fills {
+		key := f.MarketID + "/" + f.OutcomeID
+		l := lots[key]
+		if l == nil {
+			l = &lot{}
+			lots[key] = l
+		}
+
+		if f.Side == models.TradeSideBuy {
+			l.size = l.size.Add(f.FilledSize)
+			l.cost = l.cost.Add(f.FilledSize.Mul(f.AvgPrice))
+			continue
+		}
+
+		// 无买入记录的卖出无法确定成本，不计入
+		if !l.size.IsPositive() {
+			continue
+		}
+		size := decimal.Min(f.FilledSize, l.size)
+		avgCost := l.cost.Div(l.size)
+		pnls = append(pnls, f.AvgPrice.Sub(avgCost).Mul(size))
+		l.cost = l.cost.Sub(avgCost.Mul(size))
+		l.size = l.size.Sub(size)
+	}
+
+	positions, err := s.repo.GetFundPositions(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	for _, pos := range positions {
+		if pos.ResolvedAt == nil || pos.Size.IsZero() {
+			continue
+		}
+		pnls = append(pnls, pos.SettlementPrice.Sub(pos.EntryPrice).Mul(pos.Size))
+	}
+	return pnls, nil
+}
+
+// tradeMetrics 计算胜率与平均盈亏，盈亏为0的交易只计入总笔数
+func tradeMetrics(perf *models.FundPerformance, pnls []decimal.Decimal) {
+	var totalWin, totalLoss decimal.Decimal
+	lossCount := 0
+	for _, pnl := range pnls {
+		switch {
+		case pnl.IsPositive():
+			perf.WinCount++
+			totalWin = totalWin.Add(pnl)
+		case pnl.IsNegative():
+			lossCount++
+			totalLoss = totalLoss.Add(pnl)
+		}
+	}
+
+	perf.TradeCount = len(pnls)
+	if perf.TradeCount > 0 {
+		perf.HitRate = decimal.NewFromInt(int64(perf.WinCount)).Div(decimal.NewFromInt(int64(perf.TradeCount))).Round(6)
+	}
+	if perf.WinCount > 0 {
+		perf.AvgWin = totalWin.Div(decimal.NewFromInt(int64(perf.WinCount))).Round(precision)
+	}
+	if lossCount > 0 {
+		perf.AvgLoss = totalLoss.Div(decimal.NewFromInt(int64(lossCount))).Round(precision)
+	}
+}
+
+func metricsOf(perf *models.FundPerformance) *Metrics {
+	return &Metrics{
+		FundID:              perf.FundID,
+		CurrentNav:          perf.NavPerShare,
+		AsOf:                perf.AsOf,
+		Return1DPct:         perf.Return1D,
+		Return7DPct:         perf.Return7D,
+		Return30DPct:        perf.Return30D,
+		ReturnYTDPct:        perf.ReturnYTD,
+		CumulativeReturnPct: perf.ReturnInception,
+		MaxDrawdownPct:      perf.MaxDrawdown,
+		MaxDrawdownDays:     perf.MaxDrawdownDays,
+		CurrentDrawdownPct:  perf.CurrentDrawdown,
+		VolatilityPct:       perf.Volatility,
+		SharpeRatio:         perf.Sharpe,
+		SortinoRatio:        perf.Sortino,
+		TradeCount:          perf.TradeCount,
+		HitRatePct:          perf.HitRate,
+		AvgWin:              perf.AvgWin,
+		AvgLoss:             perf.AvgLoss,
+		ComputedAt:          perf.ComputedAt,
+	}
+}
+
+func roundFloat(f float64) decimal.Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return decimal.Zero
+	}
+	return decimal.NewFromFloat(f).Round(precision)
+}
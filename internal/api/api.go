@@ -12,10 +12,22 @@ import (
 func SetupRouter(
 	logger *zap.Logger,
 	jwtSecret string,
+	roleGrants middleware.RoleGrants,
+	fundLookup middleware.FundLookup,
 	authCtrl *controller.AuthController,
 	fundCtrl *controller.FundController,
 	intentCtrl *controller.IntentController,
 	investorCtrl *controller.InvestorController,
+	reviewCtrl *controller.ReviewController,
+	killSwitchCtrl *controller.KillSwitchController,
+	riskRuleCtrl *controller.RiskRuleController,
+	transparencyCtrl *controller.TransparencyController,
+	investmentCtrl *controller.InvestmentController,
+	feeCtrl *controller.FeeController,
+	cashCtrl *controller.CashController,
+	marketCtrl *controller.MarketController,
+	performanceCtrl *controller.PerformanceController,
+	dashboardCtrl *controller.DashboardController,
 ) *gin.Engine {
 	r := gin.New()
 
@@ -36,9 +48,30 @@ func SetupRouter(
 			auth.POST("/login", authCtrl.Login)   // 提交签名登录
 		}
 
+		// 基金现金储备
+		v1.GET("/market/funds/:fundId/cash-reserve", cashCtrl.Reserve)
+
+		// 基金业绩曲线与指标
+		v1.GET("/market/funds/:fundId/performance", performanceCtrl.Curve)
+		v1.GET("/market/funds/:fundId/performance/metrics", performanceCtrl.Metrics)
+
+		// 基金投资人排行（地址截断，已退出榜单的投资人不展示）
+		v1.GET("/market/funds/:fundId/investor-rankings", investorCtrl.FundRankings)
+
+		// 市场结果代币K线
+		v1.GET("/market/markets/:id/candles", marketCtrl.Candles)
+
+		// 基金交易审计公开信息（延迟公开，敏感字段脱敏）
+		audit := v1.Group("/market/funds/:fundId/audit")
+		{
+			audit.GET("", transparencyCtrl.ListAudit)             // 已公开意图审计记录
+			audit.GET("/chain-head", transparencyCtrl.ChainHead)  // 审计哈希链头
+			audit.GET("/:intentId", transparencyCtrl.AuditDetail) // 意图审计明细
+		}
+
 		// --- 受保护接口 (需要 JWT 校验) ---
 		authorized := v1.Group("/")
-		authorized.Use(middleware.JWTMiddleware(jwtSecret))
+		authorized.Use(middleware.JWTMiddleware(jwtSecret), middleware.GrantRoles(roleGrants))
 		{
 			// 用户个人资料
 			authorized.GET("/user/profile", authCtrl.GetProfile)
@@ -55,9 +88,30 @@ func SetupRouter(
 			investor := authorized.Group("/investor")
 			investor.Use(middleware.RoleGuard("INVESTOR"))
 			{
-				investor.GET("/portfolio", investorCtrl.GetPortfolio) // 个人投资组合
-				investor.GET("/history", investorCtrl.GetHistory)     // 申赎历史
-				investor.GET("/rankings", investorCtrl.GetRankings)   // 收益榜单
+				investor.GET("/portfolio", investorCtrl.GetPortfolio)             // 个人投资组合
+				investor.GET("/history", investorCtrl.GetHistory)                 // 申赎历史
+				investor.GET("/rankings", investorCtrl.GetRankings)               // 收益榜单
+				investor.GET("/rankings/privacy", investorCtrl.GetRankingPrivacy) // 榜单隐私设置
+				investor.PUT("/rankings/privacy", investorCtrl.SetRankingPrivacy) // 退出/加入收益榜单
+			}
+
+			// 投资人持仓总览
+			investments := authorized.Group("/investment")
+			investments.Use(middleware.RoleGuard("INVESTOR"))
+			{
+				investments.GET("/funds", investorCtrl.Funds)     // 已投资基金列表
+				investments.GET("/summary", investorCtrl.Summary) // 投资总览
+			}
+
+			// 投资人申赎 (于下一次净值结算时确认)
+			investment := authorized.Group("/investment/funds/:fundId")
+			investment.Use(middleware.RoleGuard("INVESTOR"))
+			{
+				investment.GET("/detail", investorCtrl.Detail)                                // 投资详情
+				investment.GET("/value-trend", investorCtrl.ValueTrend)                       // 投资价值走势
+				investment.POST("/transactions", investmentCtrl.Create)                       // 提交申购/赎回
+				investment.GET("/transactions", investmentCtrl.List)                          // 申赎记录
+				investment.POST("/transactions/:transactionId/cancel", investmentCtrl.Cancel) // 撤销待结算申赎
 			}
 
 			// 基金经理私有接口 (核心非裁量执行模块)
@@ -65,7 +119,8 @@ func SetupRouter(
 			manager.Use(middleware.RoleGuard("MANAGER"))
 			{
 				manager.POST("/funds", fundCtrl.Create)            // 创建基金
-				manager.GET("/my-funds", fundCtrl.ListManaged)     // 管理的基金列表
+				manager.GET("/summary", dashboardCtrl.Summary)     // 基金经理概览
+				manager.GET("/my-funds", dashboardCtrl.Funds)      // 管理的基金列表（含健康度）
 				manager.GET("/ai-pick", fundCtrl.GetAISuggestions) // AI 选品建议
 
 				// 交易意图操作
@@ -74,6 +129,53 @@ func SetupRouter(
 					intents.POST("", intentCtrl.Submit) // 提交交易意图
 					intents.GET("", intentCtrl.List)    // 意图执行追踪
 				}
+
+				// 基金维度的交易意图操作（仅该基金经理）
+				fundIntents := manager.Group("/funds/:fundId/intents")
+				fundIntents.Use(middleware.FundOwnerGuard(fundLookup))
+				{
+					fundIntents.POST("/preview", intentCtrl.Preview) // 预审交易意图（不落库）
+				}
+
+				// 基金费用（应计与已支付的管理费、业绩报酬，仅该基金经理）
+				manager.GET("/funds/:fundId/fees", middleware.FundOwnerGuard(fundLookup), feeCtrl.Summary)
+			}
+
+			// 支持的风控规则类型
+			authorized.GET("/manager/risk-rule-types", middleware.RoleGuard("MANAGER", "ADMIN"), riskRuleCtrl.Types)
+
+			// 基金风控规则 (仅该基金经理与管理员，管理员规则仅管理员可修改)
+			riskRules := authorized.Group("/manager/funds/:fundId/risk-rules")
+			riskRules.Use(middleware.RoleGuard("MANAGER", "ADMIN"), middleware.FundOwnerGuard(fundLookup))
+			{
+				riskRules.GET("", riskRuleCtrl.List)                      // 指定时间点生效的规则
+				riskRules.POST("", riskRuleCtrl.Create)                   // 创建规则
+				riskRules.GET("/:ruleId/versions", riskRuleCtrl.Versions) // 规则版本历史
+				riskRules.PUT("/:ruleId", riskRuleCtrl.Update)            // 修改规则（生成新版本）
+				riskRules.DELETE("/:ruleId", riskRuleCtrl.Delete)         // 停用规则
+			}
+
+			// 管理员 / 合规人工复核接口
+			admin := authorized.Group("/admin")
+			admin.Use(middleware.RoleGuard("ADMIN", "COMPLIANCE"))
+			{
+				reviews := admin.Group("/reviews")
+				{
+					reviews.GET("", reviewCtrl.List)                 // 待复核意图队列
+					reviews.POST("/:id/approve", reviewCtrl.Approve) // 复核通过
+					reviews.POST("/:id/reject", reviewCtrl.Reject)   // 复核拒绝
+				}
+
+				// 解除基金清仓模式（仅管理员），同时以当前单位净值重置回撤高点
+				admin.POST("/funds/:fundId/resume", middleware.RoleGuard("ADMIN"), reviewCtrl.ResumeFund)
+
+				// 交易熔断开关（仅管理员）
+				killSwitches := admin.Group("/kill-switches")
+				killSwitches.Use(middleware.RoleGuard("ADMIN"))
+				{
+					killSwitches.GET("", killSwitchCtrl.List)    // 生效开关及切换记录
+					killSwitches.POST("", killSwitchCtrl.Toggle) // 切换开关
+				}
 			}
 		}
 	}
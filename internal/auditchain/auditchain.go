@@ -0,0 +1,258 @@
+// Package auditchain 审计哈希链校验与链头导出。
+// 审计日志、风控事件、执行记录写入时按基金追加到哈希链（见 repository.createChained），
+// 本包重新计算每条记录的内容哈希与链接哈希，检测被修改或删除的记录。
+package auditchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 校验分页大小
+const pageSize = 1000
+
+// 问题类型
+const (
+	IssueSeqGap           = "SEQ_GAP"           // 序号不连续，链条目被删除
+	IssueBrokenLink       = "BROKEN_LINK"       // prev_hash 与上一条目不一致
+	IssueHashMismatch     = "HASH_MISMATCH"     // 条目哈希与重新计算结果不一致
+	IssueRecordMissing    = "RECORD_MISSING"    // 原始记录被删除
+	IssueContentModified  = "CONTENT_MODIFIED"  // 原始记录内容被修改
+	IssueSnapshotMismatch = "SNAPSHOT_MISMATCH" // 与已导出的链头不一致，链被截断或重写
+)
+
+// Issue 校验发现的问题
+type Issue struct {
+	Kind       string                 `json:"kind"`
+	Seq        int64                  `json:"seq"`
+	RecordType models.ChainRecordType `json:"record_type,omitempty"`
+	RecordID   uuid.UUID              `json:"record_id"`
+	Detail     string                 `json:"detail"`
+}
+
+// Report 单个基金的校验报告
+type Report struct {
+	FundID  uuid.UUID          `json:"fund_id"`
+	Entries int64              `json:"entries"`
+	Head    *models.ChainEntry `json:"head,omitempty"`
+	Issues  []Issue            `json:"issues"`
+}
+
+// OK 是否未发现问题
+func (r *Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Verifier 哈希链校验器
+type Verifier struct {
+	repo      repository.Repository
+	logger    *logger.Logger
+	exportDir string
+}
+
+// NewVerifier 创建校验器，exportDir 为空时链头仅写入数据库
+func NewVerifier(repo repository.Repository, logger *logger.Logger, exportDir string) *Verifier {
+	return &Verifier{
+		repo:      repo,
+		logger:    logger,
+		exportDir: exportDir,
+	}
+}
+
+// VerifyAll 校验全部基金的哈希链
+func (v *Verifier) VerifyAll(ctx context.Context) ([]*Report, error) {
+	fundIDs, err := v.repo.GetChainFunds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取哈希链基金失败: %w", err)
+	}
+
+	reports := make([]*Report, 0, len(fundIDs))
+	for _, fundID := range fundIDs {
+		report, err := v.VerifyFund(ctx, fundID)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// VerifyFund 校验单个基金的哈希链
+func (v *Verifier) VerifyFund(ctx context.Context, fundID uuid.UUID) (*Report, error) {
+	report := &Report{FundID: fundID, Issues: make([]Issue, 0)}
+	hashes := make(map[int64]string)
+
+	var prev *models.ChainEntry
+	var afterSeq int64
+	for {
+		entries, err := v.repo.GetChainEntries(ctx, fundID, afterSeq, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取哈希链失败: %w", err)
+		}
+
+		for i := range entries {
+			entry := entries[i]
+			if err := v.verifyEntry(ctx, report, prev, &entry); err != nil {
+				return nil, err
+			}
+			hashes[entry.Seq] = entry.Hash
+			report.Entries++
+			prev = &entry
+		}
+
+		if len(entries) < pageSize {
+			break
+		}
+		afterSeq = entries[len(entries)-1].Seq
+	}
+	report.Head = prev
+
+	// 与最近导出的链头比对，检测整段截断或重写
+	snapshot, err := v.repo.GetLatestChainHeadSnapshot(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取链头快照失败: %w", err)
+	}
+	if snapshot != nil {
+		hash, ok := hashes[snapshot.Seq]
+		switch {
+		case !ok:
+			report.Issues = append(report.Issues, Issue{
+				Kind:   IssueSnapshotMismatch,
+				Seq:    snapshot.Seq,
+				Detail: fmt.Sprintf("已导出链头序号 %d 不存在", snapshot.Seq),
+			})
+		case hash != snapshot.Hash:
+			report.Issues = append(report.Issues, Issue{
+				Kind:   IssueSnapshotMismatch,
+				Seq:    snapshot.Seq,
+				Detail: fmt.Sprintf("已导出链头哈希 %s，当前为 %s", snapshot.Hash, hash),
+			})
+		}
+	}
+
+	if !report.OK() {
+		v.logger.Error("审计哈希链校验失败",
+			zap.String("fund_id", fundID.String()),
+			zap.Int("issues", len(report.Issues)))
+	}
+
+	return report, nil
+}
+
+// verifyEntry 校验单个条目：序号连续、链接正确、原始记录存在且未修改
+func (v *Verifier) verifyEntry(ctx context.Context, report *Report, prev, entry *models.ChainEntry) error {
+	issue := func(kind, detail string) {
+		report.Issues = append(report.Issues, Issue{
+			Kind:       kind,
+			Seq:        entry.Seq,
+			RecordType: entry.RecordType,
+			RecordID:   entry.RecordID,
+			Detail:     detail,
+		})
+	}
+
+	expectedSeq := int64(1)
+	prevHash := ""
+	if prev != nil {
+		expectedSeq = prev.Seq + 1
+		prevHash = prev.Hash
+	}
+	if entry.Seq != expectedSeq {
+		issue(IssueSeqGap, fmt.Sprintf("期望序号 %d，实际 %d", expectedSeq, entry.Seq))
+	}
+	if entry.PrevHash != prevHash {
+		issue(IssueBrokenLink, fmt.Sprintf("prev_hash %s 与上一条目哈希 %s 不一致", entry.PrevHash, prevHash))
+	}
+
+	linkHash := models.ChainLinkHash(entry.PrevHash, entry.FundID, entry.Seq,
+		entry.RecordType, entry.RecordID, entry.ContentHash)
+	if linkHash != entry.Hash {
+		issue(IssueHashMismatch, fmt.Sprintf("条目哈希 %s，重新计算为 %s", entry.Hash, linkHash))
+	}
+
+	record, err := v.repo.GetChainRecord(ctx, *entry)
+	if err != nil {
+		return fmt.Errorf("获取链记录失败: %w", err)
+	}
+	if record == nil {
+		issue(IssueRecordMissing, "原始记录已被删除")
+		return nil
+	}
+	if contentHash := models.ChainContentHash(record); contentHash != entry.ContentHash {
+		issue(IssueContentModified, fmt.Sprintf("内容哈希 %s，重新计算为 %s", entry.ContentHash, contentHash))
+	}
+	return nil
+}
+
+// ExportHeads 导出全部基金的链头，写入快照表并可选追加到导出文件
+func (v *Verifier) ExportHeads(ctx context.Context) ([]models.ChainHeadSnapshot, error) {
+	fundIDs, err := v.repo.GetChainFunds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取哈希链基金失败: %w", err)
+	}
+
+	now := time.Now()
+	snapshots := make([]models.ChainHeadSnapshot, 0, len(fundIDs))
+	for _, fundID := range fundIDs {
+		head, err := v.repo.GetChainHead(ctx, fundID)
+		if err != nil {
+			return nil, fmt.Errorf("获取链头失败: %w", err)
+		}
+		if head == nil {
+			continue
+		}
+
+		snapshot := models.ChainHeadSnapshot{
+			FundID:     fundID,
+			Seq:        head.Seq,
+			Hash:       head.Hash,
+			ExportedAt: now,
+		}
+		if err := v.repo.CreateChainHeadSnapshot(ctx, &snapshot); err != nil {
+			return nil, fmt.Errorf("保存链头快照失败: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if v.exportDir != "" && len(snapshots) > 0 {
+		if err := v.writeExport(now, snapshots); err != nil {
+			return snapshots, err
+		}
+	}
+
+	v.logger.Info("审计链头导出完成", zap.Int("funds", len(snapshots)))
+	return snapshots, nil
+}
+
+// writeExport 按日追加 JSON Lines 导出文件
+func (v *Verifier) writeExport(now time.Time, snapshots []models.ChainHeadSnapshot) error {
+	if err := os.MkdirAll(v.exportDir, 0o755); err != nil {
+		return fmt.Errorf("创建导出目录失败: %w", err)
+	}
+
+	path := filepath.Join(v.exportDir, fmt.Sprintf("chain-heads-%s.jsonl", now.UTC().Format("20060102")))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("打开导出文件失败: %w", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, snapshot := range snapshots {
+		if err := enc.Encode(snapshot); err != nil {
+			return fmt.Errorf("写入导出文件失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Package cash 基金现金储备。
+// 账本现金由成交、费用及申赎结算的现金流水维护；Tracker 定期通过 balanceOf 读取
+// Vault 与执行钱包的链上 USDC 余额并与账本对账，Service 提供现金储备占比查询。
+package cash
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// ERC-20 balanceOf(address) 函数选择器
+const balanceOfSelector = "0x70a08231"
+
+// 账本与链上余额差异超过该值时告警（USDC）
+var driftTolerance = decimal.NewFromFloat(0.01)
+
+// ErrFundNotFound 基金不存在
+var ErrFundNotFound = errors.New("基金不存在")
+
+// ChainReader 余额读取所需的链上接口（由 indexer.Client 实现）
+type ChainReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	CallContract(ctx context.Context, to, data string, block uint64) (string, error)
+}
+
+// Tracker 链上 USDC 余额快照
+type Tracker struct {
+	repo     repository.Repository
+	chain    ChainReader
+	usdc     string
+	decimals int32
+	logger   *logger.Logger
+}
+
+// NewTracker 创建余额快照器，decimals 为0时按 USDC 的6位精度
+func NewTracker(repo repository.Repository, chain ChainReader, usdcAddress string, decimals int32, logger *logger.Logger) *Tracker {
+	if decimals == 0 {
+		decimals = 6
+	}
+	return &Tracker{
+		repo:     repo,
+		chain:    chain,
+		usdc:     strings.ToLower(usdcAddress),
+		decimals: decimals,
+		logger:   logger,
+	}
+}
+
+// Snapshot 记录所有配置了链上地址的基金的 Vault 与执行钱包余额
+func (t *Tracker) Snapshot(ctx context.Context) error {
+	block, err := t.chain.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("获取最新区块失败: %w", err)
+	}
+
+	funds, err := t.repo.GetVaultFunds(ctx)
+	if err != nil {
+		return fmt.Errorf("获取基金列表失败: %w", err)
+	}
+
+	for i := range funds {
+		if _, err := t.SnapshotFund(ctx, &funds[i], block); err != nil {
+			t.logger.Error("记录链上余额失败",
+				zap.String("fund_id", funds[i].ID.String()),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// SnapshotFund 读取基金在指定区块的链上余额并与账本对账
+func (t *Tracker) SnapshotFund(ctx context.Context, fund *models.Fund, block uint64) ([]models.CashSnapshot, error) {
+	accounts := []struct {
+		account models.CashAccount
+		address string
+		book    decimal.Decimal
+	}{
+		{models.CashAccountVault, fund.VaultAddress, fund.VaultCash},
+		{models.CashAccountExecution, fund.ExecutionAddress, fund.ExecutionCash},
+	}
+
+	now := time.Now()
+	snapshots := make([]models.CashSnapshot, 0, len(accounts))
+	for _, acc := range accounts {
+		if acc.address == "" {
+			continue
+		}
+		balance, err := t.balanceOf(ctx, acc.address, block)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 余额失败: %w", acc.account, err)
+		}
+
+		drift := balance.Sub(acc.book)
+		if drift.Abs().GreaterThan(driftTolerance) {
+			t.logger.Warn("链上余额与账本不一致",
+				zap.String("fund_id", fund.ID.String()),
+				zap.String("account", string(acc.account)),
+				zap.String("chain", balance.String()),
+				zap.String("book", acc.book.String()),
+				zap.String("drift", drift.String()))
+		}
+
+		snapshots = append(snapshots, models.CashSnapshot{
+			FundID:      fund.ID,
+			Account:     acc.account,
+			Address:     strings.ToLower(acc.address),
+			Balance:     balance,
+			BookBalance: acc.book,
+			Drift:       drift,
+			BlockNumber: block,
+			RecordedAt:  now,
+		})
+	}
+
+	if err := t.repo.CreateCashSnapshots(ctx, snapshots); err != nil {
+		return nil, fmt.Errorf("保存余额快照失败: %w", err)
+	}
+	return snapshots, nil
+}
+
+// balanceOf 调用 USDC 合约 balanceOf 读取地址余额
+func (t *Tracker) balanceOf(ctx context.Context, owner string, block uint64) (decimal.Decimal, error) {
+	address := strings.TrimPrefix(strings.ToLower(owner), "0x")
+	if len(address) != 40 {
+		return decimal.Zero, fmt.Errorf("无效的地址: %s", owner)
+	}
+
+	result, err := t.chain.CallContract(ctx, t.usdc, balanceOfSelector+strings.Repeat("0", 24)+address, block)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil || len(raw) < 32 {
+		return decimal.Zero, fmt.Errorf("无效的 balanceOf 返回值: %s", result)
+	}
+	return decimal.NewFromBigInt(new(big.Int).SetBytes(raw[:32]), -t.decimals), nil
+}
+
+// Reserve 基金现金储备
+type Reserve struct {
+	AllocationPct decimal.Decimal `json:"allocationPct"` // 现金占总资产比例
+	CurrentValue  decimal.Decimal `json:"currentValue"`  // Vault 与执行钱包现金合计（USDC）
+}
+
+// Service 现金储备查询
+type Service struct {
+	repo repository.Repository
+}
+
+// NewService 创建现金储备查询服务
+func NewService(repo repository.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Reserve 按账本现金与最近一次估值的持仓市值计算现金储备占比
+func (s *Service) Reserve(ctx context.Context, fundID uuid.UUID) (*Reserve, error) {
+	fund, err := s.repo.GetFund(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金失败: %w", err)
+	}
+	if fund == nil {
+		return nil, ErrFundNotFound
+	}
+
+	positionValue, err := s.latestPositionValue(ctx, fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	reserve := &Reserve{CurrentValue: fund.VaultCash.Add(fund.ExecutionCash)}
+	if total := reserve.CurrentValue.Add(positionValue); total.IsPositive() {
+		reserve.AllocationPct = reserve.CurrentValue.Div(total).Round(4)
+	}
+	return reserve, nil
+}
+
+// latestPositionValue 最近一次净值记录（估算或正式，取较新者）中的持仓市值
+func (s *Service) latestPositionValue(ctx context.Context, fundID uuid.UUID) (decimal.Decimal, error) {
+	official, err := s.repo.GetLatestNavHistory(ctx, fundID, models.NavKindOfficial)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("获取净值记录失败: %w", err)
+	}
+	estimate, err := s.repo.GetLatestNavHistory(ctx, fundID, models.NavKindEstimate)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("获取净值记录失败: %w", err)
+	}
+
+	latest := official
+	if estimate != nil && (latest == nil || estimate.RecordedAt.After(latest.RecordedAt)) {
+		latest = estimate
+	}
+	if latest == nil {
+		return decimal.Zero, nil
+	}
+	return latest.PositionValue, nil
+}
@@ -0,0 +1,193 @@
+// Package catalog Polymarket 市场目录同步。
+// Syncer 定期分页拉取完整目录写入 MarketData 缓存，未再出现在目录中的市场标记为已关闭；
+// 持仓与关注市场的行情（最优买卖价、最新价、成交量、流动性）随数据聚合任务高频刷新。
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPageSize = 500
+	refreshBatch    = 50 // 单次按条件ID查询的市场数
+)
+
+// Config 目录同步配置
+type Config struct {
+	PageSize     int      // 目录分页大小
+	WatchMarkets []string // 关注的市场条件ID，与持仓市场一并刷新行情
+}
+
+// Syncer 市场目录同步器
+type Syncer struct {
+	repo   repository.Repository
+	client Client
+	logger *logger.Logger
+	config Config
+}
+
+// NewSyncer 创建目录同步器
+func NewSyncer(repo repository.Repository, client Client, logger *logger.Logger, config Config) *Syncer {
+	if config.PageSize <= 0 {
+		config.PageSize = defaultPageSize
+	}
+	return &Syncer{
+		repo:   repo,
+		client: client,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Sync 完整同步市场目录。全部分页成功写入后，才将本轮未出现的市场标记为已关闭，
+// 中途失败时不关闭任何市场，避免把未拉取到的分页误判为下架
+func (s *Syncer) Sync(ctx context.Context) error {
+	startedAt := time.Now()
+
+	total := 0
+	for offset := 0; ; offset += s.config.PageSize {
+		markets, err := s.client.ListMarkets(ctx, offset, s.config.PageSize)
+		if err != nil {
+			return fmt.Errorf("获取市场目录失败(offset=%d): %w", offset, err)
+		}
+		if err := s.save(ctx, markets, startedAt); err != nil {
+			return err
+		}
+		total += len(markets)
+		if len(markets) < s.config.PageSize {
+			break
+		}
+	}
+
+	closed, err := s.repo.CloseStaleMarkets(ctx, startedAt)
+	if err != nil {
+		return fmt.Errorf("关闭下架市场失败: %w", err)
+	}
+
+	s.logger.Info("市场目录同步完成",
+		zap.Int("markets", total),
+		zap.Int64("closed", closed),
+		zap.Duration("elapsed", time.Since(startedAt)))
+	return nil
+}
+
+// Refresh 刷新持仓与关注市场的行情
+func (s *Syncer) Refresh(ctx context.Context) error {
+	ids, err := s.trackedMarkets(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for start := 0; start < len(ids); start += refreshBatch {
+		end := start + refreshBatch
+		if end > len(ids) {
+			end = len(ids)
+		}
+		markets, err := s.client.GetMarkets(ctx, ids[start:end])
+		if err != nil {
+			return fmt.Errorf("获取市场行情失败: %w", err)
+		}
+		if err := s.save(ctx, markets, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trackedMarkets 未结算持仓所在市场与配置的关注市场，去重
+func (s *Syncer) trackedMarkets(ctx context.Context) ([]string, error) {
+	positions, err := s.repo.GetUnresolvedPositions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, pos := range positions {
+		add(pos.MarketID)
+	}
+	for _, id := range s.config.WatchMarkets {
+		add(id)
+	}
+	return ids, nil
+}
+
+// save 转换并写入市场缓存
+func (s *Syncer) save(ctx context.Context, markets []Market, syncedAt time.Time) error {
+	data := make([]models.MarketData, 0, len(markets))
+	for _, m := range markets {
+		if m.ConditionID == "" {
+			continue
+		}
+		record, err := toMarketData(m, syncedAt)
+		if err != nil {
+			return err
+		}
+		data = append(data, record)
+	}
+	if err := s.repo.UpsertMarkets(ctx, data); err != nil {
+		return fmt.Errorf("保存市场数据失败: %w", err)
+	}
+	return nil
+}
+
+// toMarketData 目录市场转换为缓存记录
+func toMarketData(m Market, syncedAt time.Time) (models.MarketData, error) {
+	tags := m.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		return models.MarketData{}, err
+	}
+
+	record := models.MarketData{
+		ID:           m.ConditionID,
+		Question:     m.Question,
+		Description:  m.Description,
+		Slug:         m.Slug,
+		EventID:      m.EventID,
+		Category:     m.Category,
+		Tags:         string(tagsJSON),
+		EndDate:      m.EndDate,
+		Active:       m.Active,
+		Closed:       m.Closed,
+		NegRisk:      m.NegRisk,
+		TickSize:     m.TickSize,
+		MinOrderSize: m.MinOrderSize,
+		BestBid:      m.BestBid,
+		BestAsk:      m.BestAsk,
+		LastPrice:    m.LastPrice,
+		Volume:       m.Volume,
+		Liquidity:    m.Liquidity,
+		SyncedAt:     &syncedAt,
+	}
+	for i, o := range m.Outcomes {
+		record.Outcomes = append(record.Outcomes, models.MarketOutcome{
+			TokenID:   o.TokenID,
+			MarketID:  m.ConditionID,
+			Name:      o.Name,
+			Index:     i,
+			Price:     o.Price,
+			UpdatedAt: syncedAt,
+		})
+	}
+	return record, nil
+}
@@ -0,0 +1,230 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// stubClient 按 offset 分页返回固定目录，failAt 中的 offset 返回错误
+type stubClient struct {
+	markets []Market
+	failAt  map[int]bool
+	offsets []int
+}
+
+func (c *stubClient) ListMarkets(ctx context.Context, offset, limit int) ([]Market, error) {
+	c.offsets = append(c.offsets, offset)
+	if c.failAt[offset] {
+		return nil, errors.New("gamma unavailable")
+	}
+	if offset >= len(c.markets) {
+		return nil, nil
+	}
+	end := offset + limit
+	if end > len(c.markets) {
+		end = len(c.markets)
+	}
+	return c.markets[offset:end], nil
+}
+
+func (c *stubClient) GetMarkets(ctx context.Context, conditionIDs []string) ([]Market, error) {
+	return nil, errors.New("not implemented")
+}
+
+// fakeRepo 内存中的市场缓存，未实现的方法调用时 panic
+type fakeRepo struct {
+	repository.Repository
+	markets    map[string]models.MarketData
+	upsertErr  error
+	closeCalls int
+}
+
+func (r *fakeRepo) UpsertMarkets(ctx context.Context, markets []models.MarketData) error {
+	if r.upsertErr != nil {
+		return r.upsertErr
+	}
+	for _, m := range markets {
+		r.markets[m.ID] = m
+	}
+	return nil
+}
+
+func (r *fakeRepo) CloseStaleMarkets(ctx context.Context, syncedBefore time.Time) (int64, error) {
+	r.closeCalls++
+	var closed int64
+	for id, m := range r.markets {
+		if !m.Closed && m.SyncedAt != nil && m.SyncedAt.Before(syncedBefore) {
+			m.Closed = true
+			r.markets[id] = m
+			closed++
+		}
+	}
+	return closed, nil
+}
+
+func catalogOf(n int) []Market {
+	markets := make([]Market, n)
+	for i := range markets {
+		id := fmt.Sprintf("0xcond%d", i)
+		markets[i] = Market{
+			ConditionID: id,
+			Question:    fmt.Sprintf("market %d?", i),
+			Tags:        []string{"politics"},
+			Active:      true,
+			BestBid:     decimal.RequireFromString("0.45"),
+			BestAsk:     decimal.RequireFromString("0.47"),
+			Outcomes: []Outcome{
+				{TokenID: id + "-yes", Name: "Yes", Price: decimal.RequireFromString("0.46")},
+				{TokenID: id + "-no", Name: "No", Price: decimal.RequireFromString("0.54")},
+			},
+		}
+	}
+	return markets
+}
+
+func TestSyncerSync(t *testing.T) {
+	stale := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		markets     []Market
+		pageSize    int
+		failAt      map[int]bool
+		upsertErr   error
+		wantErr     bool
+		wantOffsets []int
+		wantSaved   int  // 写入缓存的目录市场数（不含预置的下架市场）
+		wantClose   bool // 是否关闭未出现在本轮目录中的市场
+	}{
+		{
+			name:        "按页拉取直到不足一页",
+			markets:     catalogOf(5),
+			pageSize:    2,
+			wantOffsets: []int{0, 2, 4},
+			wantSaved:   5,
+			wantClose:   true,
+		},
+		{
+			name:        "目录恰为整页时以空页结束",
+			markets:     catalogOf(4),
+			pageSize:    2,
+			wantOffsets: []int{0, 2, 4},
+			wantSaved:   4,
+			wantClose:   true,
+		},
+		{
+			name:        "忽略缺少条件ID的市场",
+			markets:     append(catalogOf(2), Market{Question: "no condition id"}),
+			pageSize:    10,
+			wantOffsets: []int{0},
+			wantSaved:   2,
+			wantClose:   true,
+		},
+		{
+			name:        "分页失败时不关闭任何市场",
+			markets:     catalogOf(5),
+			pageSize:    2,
+			failAt:      map[int]bool{2: true},
+			wantErr:     true,
+			wantOffsets: []int{0, 2},
+			wantSaved:   2,
+		},
+		{
+			name:        "写入失败时不关闭任何市场",
+			markets:     catalogOf(3),
+			pageSize:    2,
+			upsertErr:   errors.New("db down"),
+			wantErr:     true,
+			wantOffsets: []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &stubClient{markets: tt.markets, failAt: tt.failAt}
+			repo := &fakeRepo{
+				markets: map[string]models.MarketData{
+					"0xdelisted": {ID: "0xdelisted", Active: true, SyncedAt: &stale},
+				},
+				upsertErr: tt.upsertErr,
+			}
+			syncer := NewSyncer(repo, client, &logger.Logger{Logger: zap.NewNop()}, Config{PageSize: tt.pageSize})
+
+			err := syncer.Sync(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Sync() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(client.offsets, tt.wantOffsets) {
+				t.Errorf("offsets = %v, want %v", client.offsets, tt.wantOffsets)
+			}
+			if saved := len(repo.markets) - 1; saved != tt.wantSaved {
+				t.Errorf("saved %d markets, want %d", saved, tt.wantSaved)
+			}
+
+			wantCalls := 0
+			if tt.wantClose {
+				wantCalls = 1
+			}
+			if repo.closeCalls != wantCalls {
+				t.Errorf("CloseStaleMarkets called %d times, want %d", repo.closeCalls, wantCalls)
+			}
+			if delisted := repo.markets["0xdelisted"]; delisted.Closed != tt.wantClose {
+				t.Errorf("delisted market closed = %v, want %v", delisted.Closed, tt.wantClose)
+			}
+			for id, m := range repo.markets {
+				if id != "0xdelisted" && m.Closed {
+					t.Errorf("market %s synced in this pass was closed", id)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncerSyncUpsertsMarketData(t *testing.T) {
+	endDate := time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)
+	market := catalogOf(1)[0]
+	market.EndDate = endDate
+
+	client := &stubClient{markets: []Market{market}}
+	repo := &fakeRepo{markets: make(map[string]models.MarketData)}
+	syncer := NewSyncer(repo, client, &logger.Logger{Logger: zap.NewNop()}, Config{PageSize: 10})
+	if err := syncer.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	got, ok := repo.markets[market.ConditionID]
+	if !ok {
+		t.Fatalf("market %s not upserted", market.ConditionID)
+	}
+	if got.Question != market.Question || !got.EndDate.Equal(endDate) || !got.Active ||
+		!got.BestBid.Equal(market.BestBid) || !got.BestAsk.Equal(market.BestAsk) {
+		t.Errorf("upserted market = %+v, want fields from %+v", got, market)
+	}
+	if got.Tags != `["politics"]` {
+		t.Errorf("Tags = %s, want [\"politics\"]", got.Tags)
+	}
+	if got.SyncedAt == nil {
+		t.Errorf("SyncedAt not set")
+	}
+	if len(got.Outcomes) != 2 {
+		t.Fatalf("got %d outcomes, want 2", len(got.Outcomes))
+	}
+	for i, o := range got.Outcomes {
+		want := market.Outcomes[i]
+		if o.TokenID != want.TokenID || o.Name != want.Name || o.Index != i ||
+			o.MarketID != market.ConditionID || !o.Price.Equal(want.Price) {
+			t.Errorf("outcome %d = %+v, want %+v", i, o, want)
+		}
+	}
+}
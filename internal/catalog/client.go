@@ -0,0 +1,256 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Client 市场目录数据源
+type Client interface {
+	// ListMarkets 分页获取未关闭的市场
+	ListMarkets(ctx context.Context, offset, limit int) ([]Market, error)
+	// GetMarkets 按条件ID批量获取市场（含已关闭市场）
+	GetMarkets(ctx context.Context, conditionIDs []string) ([]Market, error)
+}
+
+// Market 目录中的市场
+type Market struct {
+	ConditionID  string
+	Question     string
+	Description  string
+	Slug         string
+	EventID      string
+	Category     string
+	Tags         []string
+	EndDate      time.Time
+	Active       bool
+	Closed       bool
+	NegRisk      bool
+	TickSize     decimal.Decimal
+	MinOrderSize decimal.Decimal
+	BestBid      decimal.Decimal
+	BestAsk      decimal.Decimal
+	LastPrice    decimal.Decimal
+	Volume       decimal.Decimal
+	Liquidity    decimal.Decimal
+	Outcomes     []Outcome
+}
+
+// Outcome 市场结果及其条件代币
+type Outcome struct {
+	TokenID string
+	Name    string
+	Price   decimal.Decimal
+}
+
+// GammaClient Polymarket Gamma 目录接口客户端
+type GammaClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewGammaClient 创建 Gamma 目录客户端
+func NewGammaClient(baseURL string) *GammaClient {
+	return &GammaClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// gammaMarket Gamma 接口返回的市场，结果名称、价格与代币ID为 JSON 字符串编码的数组
+type gammaMarket struct {
+	ConditionID   string          `json:"conditionId"`
+	Question      string          `json:"question"`
+	Description   string          `json:"description"`
+	Slug          string          `json:"slug"`
+	Category      string          `json:"category"`
+	EndDate       string          `json:"endDate"`
+	Active        bool            `json:"active"`
+	Closed        bool            `json:"closed"`
+	NegRisk       bool            `json:"negRisk"`
+	TickSize      decimal.Decimal `json:"orderPriceMinTickSize"`
+	MinOrderSize  decimal.Decimal `json:"orderMinSize"`
+	BestBid       decimal.Decimal `json:"bestBid"`
+	BestAsk       decimal.Decimal `json:"bestAsk"`
+	LastPrice     decimal.Decimal `json:"lastTradePrice"`
+	Volume        decimal.Decimal `json:"volumeNum"`
+	Liquidity     decimal.Decimal `json:"liquidityNum"`
+	Outcomes      string          `json:"outcomes"`
+	OutcomePrices string          `json:"outcomePrices"`
+	ClobTokenIDs  string          `json:"clobTokenIds"`
+	Tags          []gammaTag      `json:"tags"`
+	Events        []struct {
+		ID   string     `json:"id"`
+		Tags []gammaTag `json:"tags"`
+	} `json:"events"`
+}
+
+type gammaTag struct {
+	Label string `json:"label"`
+}
+
+// ListMarkets 分页获取未关闭的市场
+func (c *GammaClient) ListMarkets(ctx context.Context, offset, limit int) ([]Market, error) {
+	query := url.Values{}
+	query.Set("closed", "false")
+	query.Set("include_tag", "true")
+	query.Set("offset", strconv.Itoa(offset))
+	query.Set("limit", strconv.Itoa(limit))
+	return c.fetch(ctx, query)
+}
+
+// GetMarkets 按条件ID批量获取市场
+func (c *GammaClient) GetMarkets(ctx context.Context, conditionIDs []string) ([]Market, error) {
+	if len(conditionIDs) == 0 {
+		return nil, nil
+	}
+	query := url.Values{}
+	query.Set("include_tag", "true")
+	query.Set("limit", strconv.Itoa(len(conditionIDs)))
+	for _, id := range conditionIDs {
+		query.Add("condition_ids", id)
+	}
+	return c.fetch(ctx, query)
+}
+
+// fetch 请求 /markets 并转换为目录市场
+func (c *GammaClient) fetch(ctx context.Context, query url.Values) ([]Market, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/markets?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取市场目录失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API错误: %s", string(body))
+	}
+
+	var raw []gammaMarket
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	markets := make([]Market, 0, len(raw))
+	for _, gm := range raw {
+		market, err := gm.toMarket()
+		if err != nil {
+			return nil, fmt.Errorf("市场 %s: %w", gm.ConditionID, err)
+		}
+		markets = append(markets, market)
+	}
+	return markets, nil
+}
+
+// toMarket 转换 Gamma 市场，分类缺失时取首个标签
+func (gm gammaMarket) toMarket() (Market, error) {
+	market := Market{
+		ConditionID:  gm.ConditionID,
+		Question:     gm.Question,
+		Description:  gm.Description,
+		Slug:         gm.Slug,
+		Category:     gm.Category,
+		Active:       gm.Active,
+		Closed:       gm.Closed,
+		NegRisk:      gm.NegRisk,
+		TickSize:     gm.TickSize,
+		MinOrderSize: gm.MinOrderSize,
+		BestBid:      gm.BestBid,
+		BestAsk:      gm.BestAsk,
+		LastPrice:    gm.LastPrice,
+		Volume:       gm.Volume,
+		Liquidity:    gm.Liquidity,
+	}
+
+	if gm.EndDate != "" {
+		endDate, err := parseDate(gm.EndDate)
+		if err != nil {
+			return Market{}, err
+		}
+		market.EndDate = endDate
+	}
+
+	tags := gm.Tags
+	if len(gm.Events) > 0 {
+		market.EventID = gm.Events[0].ID
+		for _, event := range gm.Events {
+			tags = append(tags, event.Tags...)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		if tag.Label != "" && !seen[tag.Label] {
+			seen[tag.Label] = true
+			market.Tags = append(market.Tags, tag.Label)
+		}
+	}
+	if market.Category == "" && len(market.Tags) > 0 {
+		market.Category = market.Tags[0]
+	}
+
+	names, err := decodeList(gm.Outcomes)
+	if err != nil {
+		return Market{}, fmt.Errorf("解析 outcomes 失败: %w", err)
+	}
+	tokenIDs, err := decodeList(gm.ClobTokenIDs)
+	if err != nil {
+		return Market{}, fmt.Errorf("解析 clobTokenIds 失败: %w", err)
+	}
+	prices, err := decodeList(gm.OutcomePrices)
+	if err != nil {
+		return Market{}, fmt.Errorf("解析 outcomePrices 失败: %w", err)
+	}
+
+	// 未开放交易的市场可能尚无代币ID
+	for i, tokenID := range tokenIDs {
+		outcome := Outcome{TokenID: tokenID}
+		if i < len(names) {
+			outcome.Name = names[i]
+		}
+		if i < len(prices) {
+			if price, err := decimal.NewFromString(prices[i]); err == nil {
+				outcome.Price = price
+			}
+		}
+		market.Outcomes = append(market.Outcomes, outcome)
+	}
+	return market, nil
+}
+
+// decodeList 解析 JSON 字符串编码的字符串数组
+func decodeList(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var list []string
+	if err := json.Unmarshal([]byte(s), &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// parseDate 解析结束时间，兼容仅含日期的格式
+func parseDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("无效的结束时间 %q", s)
+	}
+	return t, nil
+}
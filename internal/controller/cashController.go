@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"polyagent-backend/internal/cash"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CashController struct {
+	BaseController
+	cash *cash.Service
+}
+
+// NewCashController 创建基金现金储备控制器
+func NewCashController(svc *cash.Service) *CashController {
+	return &CashController{cash: svc}
+}
+
+// Reserve 基金现金储备占比与金额
+func (cc *CashController) Reserve(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	reserve, err := cc.cash.Reserve(c.Request.Context(), fundID)
+	if err != nil {
+		if errors.Is(err, cash.ErrFundNotFound) {
+			Error(c, http.StatusNotFound, 404, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "获取现金储备失败")
+		return
+	}
+
+	Success(c, reserve)
+}
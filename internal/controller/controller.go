@@ -2,6 +2,7 @@ package controller
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,3 +46,53 @@ func (base *BaseController) GetUserAddress(c *gin.Context) string {
 	}
 	return addr.(string)
 }
+
+// GetUserRole 从 Context 获取中间件注入的角色
+func (base *BaseController) GetUserRole(c *gin.Context) string {
+	role, exists := c.Get("user_role")
+	if !exists {
+		return ""
+	}
+	return role.(string)
+}
+
+// --- 3. 分页 (Pagination) ---
+
+// 分页默认值与上限
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+)
+
+// Pagination 分页信息
+type Pagination struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"pageSize"`
+	TotalItems int64 `json:"totalItems"`
+	TotalPages int   `json:"totalPages"`
+}
+
+// GetPage 解析 page / pageSize 查询参数，参数非法时返回 false 并已写入错误响应
+func (base *BaseController) GetPage(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		Error(c, http.StatusBadRequest, 400, "page 参数必须为正整数")
+		return 0, 0, false
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("pageSize", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 || pageSize > maxPageSize {
+		Error(c, http.StatusBadRequest, 400, "pageSize 参数必须为 1-100")
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
+
+// NewPagination 构造分页信息
+func NewPagination(page, pageSize int, total int64) Pagination {
+	return Pagination{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalItems: total,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}
+}
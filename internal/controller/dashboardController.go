@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"net/http"
+
+	"polyagent-backend/internal/dashboard"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DashboardController struct {
+	BaseController
+	dashboard *dashboard.Service
+}
+
+// NewDashboardController 创建基金经理看板控制器
+func NewDashboardController(svc *dashboard.Service) *DashboardController {
+	return &DashboardController{dashboard: svc}
+}
+
+// Summary 基金经理概览：规模、投资人、健康度与交易意图漏斗汇总
+func (dc *DashboardController) Summary(c *gin.Context) {
+	summary, err := dc.dashboard.Summary(c.Request.Context(), dc.GetUserAddress(c))
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取基金经理概览失败")
+		return
+	}
+
+	Success(c, summary)
+}
+
+// Funds 管理的基金列表及健康度（?keyword 按基金名称或基金ID检索）
+func (dc *DashboardController) Funds(c *gin.Context) {
+	page, pageSize, ok := dc.GetPage(c)
+	if !ok {
+		return
+	}
+
+	items, total, err := dc.dashboard.Funds(c.Request.Context(), dc.GetUserAddress(c), c.Query("keyword"), page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取管理的基金失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"items":      items,
+		"pagination": NewPagination(page, pageSize, total),
+	})
+}
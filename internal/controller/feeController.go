@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"polyagent-backend/internal/fee"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type FeeController struct {
+	BaseController
+	fees *fee.Service
+}
+
+// NewFeeController 创建基金费用控制器
+func NewFeeController(svc *fee.Service) *FeeController {
+	return &FeeController{fees: svc}
+}
+
+// Summary 基金应计与已支付的管理费、业绩报酬及费用流水
+func (fc *FeeController) Summary(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+	page, pageSize, ok := fc.GetPage(c)
+	if !ok {
+		return
+	}
+
+	summary, err := fc.fees.Summary(c.Request.Context(), fundID, page, pageSize)
+	if err != nil {
+		if errors.Is(err, fee.ErrFundNotFound) {
+			Error(c, http.StatusNotFound, 404, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "获取基金费用失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"summary":    summary,
+		"pagination": NewPagination(page, pageSize, summary.TotalEntries),
+	})
+}
@@ -26,12 +26,6 @@ func (f *FundController) Create(c *gin.Context) {
 	Success(c, "Fund Create Success")
 }
 
-//实现管理的基金列表逻辑
-func (f *FundController) ListManaged(c *gin.Context) {
-	//TODO:
-	Success(c, "Fund ListManaged Success")
-}
-
 //实现获取 AI 投资建议逻辑
 func (f *FundController) GetAISuggestions(c *gin.Context) {
 	//TODO:
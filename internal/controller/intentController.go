@@ -1,9 +1,35 @@
 package controller
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+	"net/http"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/risk"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
 
 type IntentController struct {
 	BaseController
+	auditor *risk.Auditor
+}
+
+// NewIntentController 创建交易意图控制器
+func NewIntentController(auditor *risk.Auditor) *IntentController {
+	return &IntentController{auditor: auditor}
+}
+
+// IntentRequest 交易意图请求
+type IntentRequest struct {
+	MarketID  string           `json:"market_id" binding:"required"`
+	OutcomeID string           `json:"outcome_id" binding:"required"`
+	Side      models.TradeSide `json:"side" binding:"required,oneof=BUY SELL"`
+	Size      decimal.Decimal  `json:"size"`
+	Price     decimal.Decimal  `json:"price"` // 市价单可为 0
+	OrderType string           `json:"order_type"`
 }
 
 // Submit
@@ -13,3 +39,50 @@ func (ic *IntentController) Submit(c *gin.Context) {
 // List
 func (ic *IntentController) List(c *gin.Context) {
 }
+
+// Preview 预审交易意图：执行全部风控规则但不落库，返回各规则结果与预估成交
+func (ic *IntentController) Preview(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	var req IntentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+	if !req.Size.IsPositive() || req.Price.IsNegative() {
+		Error(c, http.StatusBadRequest, 400, "交易数量必须大于0，价格不能为负")
+		return
+	}
+
+	orderType := req.OrderType
+	if orderType == "" {
+		orderType = "MARKET"
+	}
+
+	intent := &models.TradeIntent{
+		FundID:    fundID,
+		MarketID:  req.MarketID,
+		OutcomeID: req.OutcomeID,
+		Side:      req.Side,
+		Size:      req.Size,
+		Price:     req.Price,
+		OrderType: orderType,
+		Status:    models.IntentStatusPending,
+	}
+
+	preview, err := ic.auditor.PreviewIntent(c.Request.Context(), intent)
+	if err != nil {
+		if errors.Is(err, risk.ErrFundNotFound) {
+			Error(c, http.StatusNotFound, 404, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "预审失败")
+		return
+	}
+
+	Success(c, preview)
+}
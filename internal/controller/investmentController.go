@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"polyagent-backend/internal/ledger"
+	"polyagent-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type InvestmentController struct {
+	BaseController
+	ledger *ledger.Service
+}
+
+// NewInvestmentController 创建申赎控制器
+func NewInvestmentController(ledger *ledger.Service) *InvestmentController {
+	return &InvestmentController{ledger: ledger}
+}
+
+// InvestmentTxRequest 申赎请求：申购填写 amount 与入金交易哈希 txHash，赎回填写 shares
+type InvestmentTxRequest struct {
+	Type   models.InvestmentTxType `json:"type" binding:"required,oneof=DEPOSIT REDEEM"`
+	Amount decimal.Decimal         `json:"amount"` // 申购金额（USDC），结算时以链上入金金额为准
+	TxHash string                  `json:"txHash"` // 申购入金的链上交易哈希
+	Shares decimal.Decimal         `json:"shares"` // 赎回份额
+}
+
+// InvestmentTxResponse 申赎记录，未结算或已取消时 amount / shares / executedNav 可能为空
+type InvestmentTxResponse struct {
+	ID          uuid.UUID                 `json:"id"`
+	Timestamp   time.Time                 `json:"timestamp"`
+	Type        models.InvestmentTxType   `json:"type"`
+	Amount      *decimal.Decimal          `json:"amount"`
+	Shares      *decimal.Decimal          `json:"shares"`
+	ExecutedNav *decimal.Decimal          `json:"executedNav"`
+	Status      models.InvestmentTxStatus `json:"status"`
+	TxHash      string                    `json:"txHash,omitempty"`
+}
+
+// Create 提交申购或赎回请求，于下一次净值结算时确认
+func (ic *InvestmentController) Create(c *gin.Context) {
+	fundID, ok := ic.fundID(c)
+	if !ok {
+		return
+	}
+
+	var req InvestmentTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	var (
+		txn *models.InvestmentTransaction
+		err error
+	)
+	investor := ic.GetUserAddress(c)
+	if req.Type == models.InvestmentTxDeposit {
+		txn, err = ic.ledger.Deposit(c.Request.Context(), fundID, investor, req.Amount, req.TxHash)
+	} else {
+		txn, err = ic.ledger.Redeem(c.Request.Context(), fundID, investor, req.Shares)
+	}
+	if err != nil {
+		ic.handleError(c, err)
+		return
+	}
+
+	Success(c, newInvestmentTxResponse(txn))
+}
+
+// List 本人在该基金的申赎记录（?status=PENDING,SETTLED,CANCELED）
+func (ic *InvestmentController) List(c *gin.Context) {
+	fundID, ok := ic.fundID(c)
+	if !ok {
+		return
+	}
+	page, pageSize, ok := ic.GetPage(c)
+	if !ok {
+		return
+	}
+
+	var statuses []models.InvestmentTxStatus
+	if raw := c.Query("status"); raw != "" {
+		for _, s := range strings.Split(raw, ",") {
+			status := models.InvestmentTxStatus(strings.ToUpper(strings.TrimSpace(s)))
+			switch status {
+			case models.InvestmentTxPending, models.InvestmentTxSettled, models.InvestmentTxCanceled:
+				statuses = append(statuses, status)
+			default:
+				Error(c, http.StatusBadRequest, 400, "无效的状态: "+s)
+				return
+			}
+		}
+	}
+
+	txns, total, err := ic.ledger.Transactions(c.Request.Context(), fundID, ic.GetUserAddress(c), statuses, page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取申赎记录失败")
+		return
+	}
+
+	items := make([]InvestmentTxResponse, 0, len(txns))
+	for i := range txns {
+		items = append(items, newInvestmentTxResponse(&txns[i]))
+	}
+
+	Success(c, gin.H{
+		"items":      items,
+		"pagination": NewPagination(page, pageSize, total),
+	})
+}
+
+// Cancel 撤销待结算的申赎请求
+func (ic *InvestmentController) Cancel(c *gin.Context) {
+	fundID, ok := ic.fundID(c)
+	if !ok {
+		return
+	}
+	txnID, err := uuid.Parse(c.Param("transactionId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的交易ID")
+		return
+	}
+
+	txn, err := ic.ledger.Cancel(c.Request.Context(), fundID, txnID, ic.GetUserAddress(c))
+	if err != nil {
+		ic.handleError(c, err)
+		return
+	}
+
+	Success(c, newInvestmentTxResponse(txn))
+}
+
+// newInvestmentTxResponse 未确定的金额、份额及净值返回空
+func newInvestmentTxResponse(txn *models.InvestmentTransaction) InvestmentTxResponse {
+	resp := InvestmentTxResponse{
+		ID:        txn.ID,
+		Timestamp: txn.CreatedAt,
+		Type:      txn.Type,
+		Status:    txn.Status,
+		TxHash:    txn.TxHash,
+	}
+	if txn.Type == models.InvestmentTxDeposit || txn.Status == models.InvestmentTxSettled {
+		resp.Amount = &txn.Amount
+	}
+	if txn.Type == models.InvestmentTxRedeem || txn.Status == models.InvestmentTxSettled {
+		resp.Shares = &txn.Shares
+	}
+	if txn.Status == models.InvestmentTxSettled {
+		resp.ExecutedNav = &txn.ExecutedNAV
+	}
+	return resp
+}
+
+func (ic *InvestmentController) fundID(c *gin.Context) (uuid.UUID, bool) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return uuid.Nil, false
+	}
+	return fundID, true
+}
+
+// handleError 申赎操作错误映射
+func (ic *InvestmentController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ledger.ErrFundNotFound), errors.Is(err, ledger.ErrTransactionNotFound):
+		Error(c, http.StatusNotFound, 404, err.Error())
+	case errors.Is(err, ledger.ErrTransactionNotPending), errors.Is(err, ledger.ErrFundNotOpen),
+		errors.Is(err, ledger.ErrDepositTxUsed):
+		Error(c, http.StatusConflict, 409, err.Error())
+	case errors.Is(err, ledger.ErrInvalidAmount),
+		errors.Is(err, ledger.ErrInvalidTxHash),
+		errors.Is(err, ledger.ErrBelowMinimumDeposit),
+		errors.Is(err, ledger.ErrBelowMinimumRedeem),
+		errors.Is(err, ledger.ErrInsufficientShares):
+		Error(c, http.StatusBadRequest, 400, err.Error())
+	default:
+		Error(c, http.StatusInternalServerError, 500, "申赎操作失败")
+	}
+}
@@ -1,24 +1,229 @@
 package controller
 
-import "github.com/gin-gonic/gin"
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"polyagent-backend/internal/leaderboard"
+	"polyagent-backend/internal/portfolio"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
 
 type InvestorController struct {
 	BaseController
-	// 这里通常会注入 Service 接口
-	// InvestorService service.InvestorService
+	portfolio *portfolio.Service
+	rankings  *leaderboard.Service
 }
 
-// 个人投资组合
+// NewInvestorController 创建投资人控制器，rankings 为空（未配置Redis）时榜单接口不可用
+func NewInvestorController(svc *portfolio.Service, rankings *leaderboard.Service) *InvestorController {
+	return &InvestorController{portfolio: svc, rankings: rankings}
+}
+
+// 个人投资组合：投资总览与持有基金
 func (ic *InvestorController) GetPortfolio(c *gin.Context) {
-	Success(c, "TODO.. GetPortfolio Success")
+	page, pageSize, ok := ic.GetPage(c)
+	if !ok {
+		return
+	}
+
+	investor := ic.GetUserAddress(c)
+	summary, err := ic.portfolio.Summary(c.Request.Context(), investor)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取投资组合失败")
+		return
+	}
+	items, total, err := ic.portfolio.Funds(c.Request.Context(), investor, "", page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取投资组合失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"summary":    summary,
+		"items":      items,
+		"pagination": NewPagination(page, pageSize, total),
+	})
 }
 
-// 申赎历史
+// 申赎历史：各基金已结算的申购、赎回及业绩报酬份额扣减
 func (ic *InvestorController) GetHistory(c *gin.Context) {
-	Success(c, "TODO.. GetHistory Success")
+	page, pageSize, ok := ic.GetPage(c)
+	if !ok {
+		return
+	}
+
+	items, total, err := ic.portfolio.History(c.Request.Context(), ic.GetUserAddress(c), page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取申赎历史失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"items":      items,
+		"pagination": NewPagination(page, pageSize, total),
+	})
 }
 
-// 收益榜单
+// 收益榜单（全局，?metric=PROFIT|RETURN|HOLDING&window=7D|30D|ALL）
 func (ic *InvestorController) GetRankings(c *gin.Context) {
-	Success(c, "TODO.. GetRankings Success")
+	ic.rankingsOf(c, uuid.Nil, leaderboard.MetricProfit)
+}
+
+// FundRankings 基金投资人排行（默认按持有份额）
+func (ic *InvestorController) FundRankings(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+	ic.rankingsOf(c, fundID, leaderboard.MetricHolding)
+}
+
+// rankingsOf 分页查询榜单，fundID 为 uuid.Nil 时为全局榜单
+func (ic *InvestorController) rankingsOf(c *gin.Context, fundID uuid.UUID, defaultMetric leaderboard.Metric) {
+	if ic.rankings == nil {
+		Error(c, http.StatusServiceUnavailable, 503, "收益榜单未启用")
+		return
+	}
+	page, pageSize, ok := ic.GetPage(c)
+	if !ok {
+		return
+	}
+
+	metric := leaderboard.Metric(strings.ToUpper(c.DefaultQuery("metric", string(defaultMetric))))
+	window := leaderboard.Window(strings.ToUpper(c.DefaultQuery("window", string(leaderboard.WindowAll))))
+	items, total, err := ic.rankings.Rankings(c.Request.Context(), fundID, metric, window, ic.GetUserAddress(c), page, pageSize)
+	if err != nil {
+		switch {
+		case errors.Is(err, leaderboard.ErrInvalidMetric), errors.Is(err, leaderboard.ErrInvalidWindow):
+			Error(c, http.StatusBadRequest, 400, err.Error())
+		case errors.Is(err, leaderboard.ErrFundNotFound):
+			Error(c, http.StatusNotFound, 404, err.Error())
+		default:
+			Error(c, http.StatusInternalServerError, 500, "获取收益榜单失败")
+		}
+		return
+	}
+
+	Success(c, gin.H{
+		"items":      items,
+		"pagination": NewPagination(page, pageSize, total),
+	})
+}
+
+// GetRankingPrivacy 查询榜单隐私设置
+func (ic *InvestorController) GetRankingPrivacy(c *gin.Context) {
+	if ic.rankings == nil {
+		Error(c, http.StatusServiceUnavailable, 503, "收益榜单未启用")
+		return
+	}
+
+	hidden, err := ic.rankings.OptedOut(c.Request.Context(), ic.GetUserAddress(c))
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取榜单隐私设置失败")
+		return
+	}
+
+	Success(c, gin.H{"hidden": hidden})
+}
+
+// SetRankingPrivacy 设置是否退出收益榜单
+func (ic *InvestorController) SetRankingPrivacy(c *gin.Context) {
+	if ic.rankings == nil {
+		Error(c, http.StatusServiceUnavailable, 503, "收益榜单未启用")
+		return
+	}
+
+	var req struct {
+		Hidden *bool `json:"hidden" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	if err := ic.rankings.SetOptOut(c.Request.Context(), ic.GetUserAddress(c), *req.Hidden); err != nil {
+		Error(c, http.StatusInternalServerError, 500, "保存榜单隐私设置失败")
+		return
+	}
+
+	Success(c, gin.H{"hidden": *req.Hidden})
+}
+
+// Funds 已投资基金列表（?keyword 按基金名称或 Vault 地址检索）
+func (ic *InvestorController) Funds(c *gin.Context) {
+	page, pageSize, ok := ic.GetPage(c)
+	if !ok {
+		return
+	}
+
+	items, total, err := ic.portfolio.Funds(c.Request.Context(), ic.GetUserAddress(c), c.Query("keyword"), page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取已投资基金失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"items":      items,
+		"pagination": NewPagination(page, pageSize, total),
+	})
+}
+
+// Summary 投资总览
+func (ic *InvestorController) Summary(c *gin.Context) {
+	summary, err := ic.portfolio.Summary(c.Request.Context(), ic.GetUserAddress(c))
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取投资总览失败")
+		return
+	}
+
+	Success(c, summary)
+}
+
+// Detail 投资详情：份额、成本、已实现与未实现盈亏及时间/资金加权收益率
+func (ic *InvestorController) Detail(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	detail, err := ic.portfolio.Detail(c.Request.Context(), fundID, ic.GetUserAddress(c))
+	if err != nil {
+		ic.handleError(c, err, "获取投资详情失败")
+		return
+	}
+
+	Success(c, detail)
+}
+
+// ValueTrend 投资价值走势（每日）
+func (ic *InvestorController) ValueTrend(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	trend, err := ic.portfolio.ValueTrend(c.Request.Context(), fundID, ic.GetUserAddress(c))
+	if err != nil {
+		ic.handleError(c, err, "获取投资价值走势失败")
+		return
+	}
+
+	Success(c, trend)
+}
+
+// handleError 投资人持仓查询错误映射
+func (ic *InvestorController) handleError(c *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, portfolio.ErrFundNotFound), errors.Is(err, portfolio.ErrNotInvested):
+		Error(c, http.StatusNotFound, 404, err.Error())
+	default:
+		Error(c, http.StatusInternalServerError, 500, msg)
+	}
 }
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"polyagent-backend/internal/killswitch"
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 切换记录单次返回上限
+const killSwitchToggleLimit = 50
+
+type KillSwitchController struct {
+	BaseController
+	repo       repository.Repository
+	killSwitch *killswitch.Service
+}
+
+// NewKillSwitchController 创建熔断开关控制器
+func NewKillSwitchController(repo repository.Repository, ks *killswitch.Service) *KillSwitchController {
+	return &KillSwitchController{repo: repo, killSwitch: ks}
+}
+
+// KillSwitchRequest 熔断开关切换请求
+type KillSwitchRequest struct {
+	Scope         models.KillSwitchScope `json:"scope" binding:"required,oneof=GLOBAL FUND MARKET"`
+	ScopeID       string                 `json:"scope_id"` // 基金ID或市场ID，全局可省略
+	Active        *bool                  `json:"active" binding:"required"`
+	AllowStopLoss *bool                  `json:"allow_stop_loss"` // 熔断期间是否允许止损平仓，默认允许
+	Reason        string                 `json:"reason" binding:"max=500"`
+}
+
+// List 当前生效的熔断开关及最近切换记录
+func (kc *KillSwitchController) List(c *gin.Context) {
+	active, err := kc.killSwitch.Active(c.Request.Context())
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取熔断开关失败")
+		return
+	}
+
+	toggles, err := kc.repo.GetKillSwitchToggles(c.Request.Context(), killSwitchToggleLimit)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取熔断切换记录失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"active":  active,
+		"toggles": toggles,
+	})
+}
+
+// Toggle 切换熔断开关，操作人取自登录地址
+func (kc *KillSwitchController) Toggle(c *gin.Context) {
+	var req KillSwitchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	allowStopLoss := true
+	if req.AllowStopLoss != nil {
+		allowStopLoss = *req.AllowStopLoss
+	}
+
+	ks, err := kc.killSwitch.Set(c.Request.Context(), req.Scope, req.ScopeID,
+		*req.Active, allowStopLoss, req.Reason, kc.GetUserAddress(c))
+	if err != nil {
+		if errors.Is(err, killswitch.ErrInvalidScope) {
+			Error(c, http.StatusBadRequest, 400, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "切换熔断开关失败")
+		return
+	}
+
+	Success(c, ks)
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pricehistory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// K线查询数量
+const (
+	candleLimitDefault = 500
+	candleLimitMax     = 2000
+)
+
+type MarketController struct {
+	BaseController
+	prices *pricehistory.Service
+}
+
+// NewMarketController 创建市场行情控制器
+func NewMarketController(svc *pricehistory.Service) *MarketController {
+	return &MarketController{prices: svc}
+}
+
+// Candles 市场结果代币K线（?interval=1m|1h|1d 默认1h，?outcome 结果代币ID 默认第一个结果，
+// ?from&to RFC3339 时间范围，?limit 默认500）
+func (mc *MarketController) Candles(c *gin.Context) {
+	marketID := c.Param("id")
+
+	interval := models.CandleInterval(c.DefaultQuery("interval", string(models.CandleInterval1h)))
+	if interval.Duration() == 0 {
+		Error(c, http.StatusBadRequest, 400, "interval 参数必须为 1m、1h 或 1d")
+		return
+	}
+
+	limit := candleLimitDefault
+	if raw := c.Query("limit"); raw != "" {
+		var err error
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > candleLimitMax {
+			Error(c, http.StatusBadRequest, 400, "limit 参数必须为 1-2000")
+			return
+		}
+	}
+
+	var from, to time.Time
+	for name, target := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := c.Query(name); raw != "" {
+			parsed, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				Error(c, http.StatusBadRequest, 400, name+" 参数必须为 RFC3339 时间")
+				return
+			}
+			*target = parsed
+		}
+	}
+
+	series, err := mc.prices.Candles(c.Request.Context(), marketID, c.Query("outcome"), interval, from, to, limit)
+	if err != nil {
+		if errors.Is(err, pricehistory.ErrMarketNotFound) || errors.Is(err, pricehistory.ErrOutcomeNotFound) {
+			Error(c, http.StatusNotFound, 404, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "获取K线失败")
+		return
+	}
+
+	Success(c, series)
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"polyagent-backend/internal/analytics"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PerformanceController struct {
+	BaseController
+	analytics *analytics.Service
+}
+
+// NewPerformanceController 创建基金业绩控制器
+func NewPerformanceController(svc *analytics.Service) *PerformanceController {
+	return &PerformanceController{analytics: svc}
+}
+
+// Curve 基金业绩曲线（每日正式净值，?from&to 为 YYYY-MM-DD 日期，默认成立以来）
+func (pc *PerformanceController) Curve(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	var from, to time.Time
+	if raw := c.Query("from"); raw != "" {
+		if from, err = time.Parse("2006-01-02", raw); err != nil {
+			Error(c, http.StatusBadRequest, 400, "from 参数必须为 YYYY-MM-DD 日期")
+			return
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if to, err = time.Parse("2006-01-02", raw); err != nil {
+			Error(c, http.StatusBadRequest, 400, "to 参数必须为 YYYY-MM-DD 日期")
+			return
+		}
+		to = to.Add(24*time.Hour - time.Nanosecond) // 包含当日
+	}
+
+	curve, err := pc.analytics.Curve(c.Request.Context(), fundID, from, to)
+	if err != nil {
+		if errors.Is(err, analytics.ErrFundNotFound) {
+			Error(c, http.StatusNotFound, 404, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "获取业绩曲线失败")
+		return
+	}
+
+	Success(c, curve)
+}
+
+// Metrics 基金业绩指标：区间收益率、最大回撤、波动率、Sharpe/Sortino 与交易胜率
+func (pc *PerformanceController) Metrics(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	metrics, err := pc.analytics.Get(c.Request.Context(), fundID)
+	if err != nil {
+		if errors.Is(err, analytics.ErrFundNotFound) {
+			Error(c, http.StatusNotFound, 404, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "获取业绩指标失败")
+		return
+	}
+
+	Success(c, metrics)
+}
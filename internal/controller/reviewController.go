@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/repository"
+	"polyagent-backend/internal/risk"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// 复核队列单次返回上限
+const reviewQueueLimit = 200
+
+type ReviewController struct {
+	BaseController
+	repo     repository.Repository
+	auditor  *risk.Auditor
+	executor *executor.Executor
+}
+
+// NewReviewController 创建人工复核控制器，复核通过的意图提交至 executor 执行
+func NewReviewController(repo repository.Repository, auditor *risk.Auditor, executor *executor.Executor) *ReviewController {
+	return &ReviewController{repo: repo, auditor: auditor, executor: executor}
+}
+
+// ReviewItem 复核队列条目
+type ReviewItem struct {
+	models.TradeIntent
+	SLARemainingSeconds int64 `json:"sla_remaining_seconds"` // 距复核截止剩余秒数，超时为负
+	Overdue             bool  `json:"overdue"`
+}
+
+// ReviewRequest 复核请求
+type ReviewRequest struct {
+	Note string `json:"note" binding:"max=500"` // 复核意见
+}
+
+// List 待人工复核意图列表（按提交时间升序）
+func (rc *ReviewController) List(c *gin.Context) {
+	intents, err := rc.repo.GetIntentsByStatus(c.Request.Context(), models.IntentStatusManualReview, reviewQueueLimit)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取复核队列失败")
+		return
+	}
+
+	now := time.Now()
+	items := make([]ReviewItem, 0, len(intents))
+	for _, intent := range intents {
+		item := ReviewItem{TradeIntent: intent}
+		if intent.ReviewDueAt != nil {
+			remaining := intent.ReviewDueAt.Sub(now)
+			item.SLARemainingSeconds = int64(remaining.Seconds())
+			item.Overdue = remaining <= 0
+		}
+		items = append(items, item)
+	}
+
+	Success(c, items)
+}
+
+// Approve 复核通过
+func (rc *ReviewController) Approve(c *gin.Context) {
+	rc.review(c, true)
+}
+
+// Reject 复核拒绝
+func (rc *ReviewController) Reject(c *gin.Context) {
+	rc.review(c, false)
+}
+
+// review 记录复核结论，复核人取自登录地址；复核通过的意图随即提交执行（仅提交一次）
+func (rc *ReviewController) review(c *gin.Context, approve bool) {
+	intentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的意图ID")
+		return
+	}
+
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	intent, err := rc.auditor.ReviewIntent(c.Request.Context(), intentID, rc.GetUserAddress(c), approve, req.Note)
+	if err != nil {
+		if errors.Is(err, risk.ErrIntentNotInReview) {
+			Error(c, http.StatusConflict, 409, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "复核失败")
+		return
+	}
+	if intent.Status == models.IntentStatusApproved {
+		rc.executor.SubmitTask(intent.ID)
+	}
+
+	Success(c, intent)
+}
+
+// ResumeFundRequest 解除清仓模式请求
+type ResumeFundRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"` // 解除原因，记入风控事件
+}
+
+// ResumeFund 解除基金清仓模式（仅管理员），操作人取自登录地址
+func (rc *ReviewController) ResumeFund(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	var req ResumeFundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	fund, err := rc.auditor.ResumeFund(c.Request.Context(), fundID, rc.GetUserAddress(c), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, risk.ErrFundNotFound):
+			Error(c, http.StatusNotFound, 404, err.Error())
+		case errors.Is(err, risk.ErrFundNotLiquidating):
+			Error(c, http.StatusConflict, 409, err.Error())
+		default:
+			Error(c, http.StatusInternalServerError, 500, "解除清仓模式失败")
+		}
+		return
+	}
+
+	Success(c, fund)
+}
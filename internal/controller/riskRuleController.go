@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/risk"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RiskRuleController struct {
+	BaseController
+	rules *risk.RuleStore
+}
+
+// NewRiskRuleController 创建风控规则控制器
+func NewRiskRuleController(rules *risk.RuleStore) *RiskRuleController {
+	return &RiskRuleController{rules: rules}
+}
+
+// RiskRuleRequest 创建/修改风控规则请求
+type RiskRuleRequest struct {
+	RuleType      models.RiskRuleType `json:"rule_type"` // 创建时必填，修改时不可变更
+	Params        json.RawMessage     `json:"params" binding:"required"`
+	Description   string              `json:"description" binding:"max=500"`
+	AdminOnly     bool                `json:"admin_only"`     // 仅管理员可设置
+	EffectiveFrom *time.Time          `json:"effective_from"` // 为空时立即生效
+}
+
+// RetireRiskRuleRequest 停用风控规则请求
+type RetireRiskRuleRequest struct {
+	EffectiveAt *time.Time `json:"effective_at"` // 为空时立即停用
+}
+
+// Types 支持的规则类型
+func (rc *RiskRuleController) Types(c *gin.Context) {
+	Success(c, risk.RegisteredRuleTypes())
+}
+
+// List 指定时间点（?at=RFC3339，默认当前）生效的规则
+func (rc *RiskRuleController) List(c *gin.Context) {
+	fundID, ok := rc.fundID(c)
+	if !ok {
+		return
+	}
+
+	at := time.Now()
+	if raw := c.Query("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			Error(c, http.StatusBadRequest, 400, "at 参数必须为 RFC3339 时间")
+			return
+		}
+		at = parsed
+	}
+
+	rules, err := rc.rules.RulesAt(c.Request.Context(), fundID, at)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取风控规则失败")
+		return
+	}
+
+	Success(c, rules)
+}
+
+// Versions 规则全部版本
+func (rc *RiskRuleController) Versions(c *gin.Context) {
+	fundID, ok := rc.fundID(c)
+	if !ok {
+		return
+	}
+	ruleID, ok := rc.ruleID(c)
+	if !ok {
+		return
+	}
+
+	versions, err := rc.rules.Versions(c.Request.Context(), fundID, ruleID)
+	if err != nil {
+		rc.handleError(c, err)
+		return
+	}
+
+	Success(c, versions)
+}
+
+// Create 创建规则
+func (rc *RiskRuleController) Create(c *gin.Context) {
+	fundID, ok := rc.fundID(c)
+	if !ok {
+		return
+	}
+
+	var req RiskRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RuleType == "" {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	rule, err := rc.rules.Create(c.Request.Context(), fundID, req.input(),
+		rc.GetUserAddress(c), rc.isAdmin(c))
+	if err != nil {
+		rc.handleError(c, err)
+		return
+	}
+
+	Success(c, rule)
+}
+
+// Update 修改规则，生成新版本
+func (rc *RiskRuleController) Update(c *gin.Context) {
+	fundID, ok := rc.fundID(c)
+	if !ok {
+		return
+	}
+	ruleID, ok := rc.ruleID(c)
+	if !ok {
+		return
+	}
+
+	var req RiskRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	rule, err := rc.rules.Update(c.Request.Context(), fundID, ruleID, req.input(),
+		rc.GetUserAddress(c), rc.isAdmin(c))
+	if err != nil {
+		rc.handleError(c, err)
+		return
+	}
+
+	Success(c, rule)
+}
+
+// Delete 停用规则，保留历史版本
+func (rc *RiskRuleController) Delete(c *gin.Context) {
+	fundID, ok := rc.fundID(c)
+	if !ok {
+		return
+	}
+	ruleID, ok := rc.ruleID(c)
+	if !ok {
+		return
+	}
+
+	var req RetireRiskRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		Error(c, http.StatusBadRequest, 400, "请求参数错误")
+		return
+	}
+
+	rule, err := rc.rules.Retire(c.Request.Context(), fundID, ruleID, req.EffectiveAt,
+		rc.GetUserAddress(c), rc.isAdmin(c))
+	if err != nil {
+		rc.handleError(c, err)
+		return
+	}
+
+	Success(c, rule)
+}
+
+// input 转换为规则内容
+func (req *RiskRuleRequest) input() risk.RuleInput {
+	return risk.RuleInput{
+		RuleType:      req.RuleType,
+		Params:        string(req.Params),
+		Description:   req.Description,
+		AdminOnly:     req.AdminOnly,
+		EffectiveFrom: req.EffectiveFrom,
+	}
+}
+
+func (rc *RiskRuleController) isAdmin(c *gin.Context) bool {
+	return rc.GetUserRole(c) == "ADMIN"
+}
+
+func (rc *RiskRuleController) fundID(c *gin.Context) (uuid.UUID, bool) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return uuid.Nil, false
+	}
+	return fundID, true
+}
+
+func (rc *RiskRuleController) ruleID(c *gin.Context) (uuid.UUID, bool) {
+	ruleID, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的规则ID")
+		return uuid.Nil, false
+	}
+	return ruleID, true
+}
+
+// handleError 规则操作错误映射
+func (rc *RiskRuleController) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, risk.ErrRuleNotFound):
+		Error(c, http.StatusNotFound, 404, err.Error())
+	case errors.Is(err, risk.ErrRuleAdminOnly):
+		Error(c, http.StatusForbidden, 403, err.Error())
+	case errors.Is(err, risk.ErrRuleRetired), errors.Is(err, risk.ErrRuleConflict):
+		Error(c, http.StatusConflict, 409, err.Error())
+	case errors.Is(err, risk.ErrInvalidRule):
+		Error(c, http.StatusBadRequest, 400, err.Error())
+	default:
+		Error(c, http.StatusInternalServerError, 500, "风控规则操作失败")
+	}
+}
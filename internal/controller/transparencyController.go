@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"polyagent-backend/internal/transparency"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// 公开审计列表分页大小
+const (
+	auditPageDefault = 50
+	auditPageMax     = 200
+)
+
+type TransparencyController struct {
+	BaseController
+	transparency *transparency.Service
+}
+
+// NewTransparencyController 创建交易审计公开控制器
+func NewTransparencyController(svc *transparency.Service) *TransparencyController {
+	return &TransparencyController{transparency: svc}
+}
+
+// ListAudit 已公开的意图审计记录（?before=RFC3339&before_id=UUID 游标，取自上一页 next_before / next_before_id；?limit 默认50）
+func (tc *TransparencyController) ListAudit(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	limit := auditPageDefault
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > auditPageMax {
+			Error(c, http.StatusBadRequest, 400, "limit 参数必须为 1-200")
+			return
+		}
+	}
+
+	var cursor *transparency.Cursor
+	if raw := c.Query("before"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			Error(c, http.StatusBadRequest, 400, "before 参数必须为 RFC3339 时间")
+			return
+		}
+		cursor = &transparency.Cursor{Before: parsed}
+		if rawID := c.Query("before_id"); rawID != "" {
+			cursor.BeforeID, err = uuid.Parse(rawID)
+			if err != nil {
+				Error(c, http.StatusBadRequest, 400, "无效的 before_id")
+				return
+			}
+		}
+	}
+
+	page, err := tc.transparency.ListIntents(c.Request.Context(), fundID, cursor, limit)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取审计记录失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"items":                 page.Items,
+		"next_before":           page.NextBefore,
+		"next_before_id":        page.NextBeforeID,
+		"publish_delay_seconds": int64(tc.transparency.PublishDelay().Seconds()),
+	})
+}
+
+// AuditDetail 单个意图的审计明细
+func (tc *TransparencyController) AuditDetail(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+	intentID, err := uuid.Parse(c.Param("intentId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的意图ID")
+		return
+	}
+
+	audit, err := tc.transparency.GetIntent(c.Request.Context(), fundID, intentID)
+	if err != nil {
+		if errors.Is(err, transparency.ErrNotPublished) {
+			Error(c, http.StatusNotFound, 404, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, 500, "获取审计记录失败")
+		return
+	}
+
+	Success(c, audit)
+}
+
+// ChainHead 最近导出的审计哈希链头
+func (tc *TransparencyController) ChainHead(c *gin.Context) {
+	fundID, err := uuid.Parse(c.Param("fundId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, 400, "无效的基金ID")
+		return
+	}
+
+	snapshot, err := tc.transparency.ChainHead(c.Request.Context(), fundID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, 500, "获取链头失败")
+		return
+	}
+	if snapshot == nil {
+		Error(c, http.StatusNotFound, 404, "暂无已导出的链头")
+		return
+	}
+
+	Success(c, snapshot)
+}
@@ -0,0 +1,482 @@
+// Package dashboard 基金经理看板。
+// 调度任务定时汇总每只基金的规模、净值变动、持仓与现金、风控规则使用率、近期风控事件及交易意图漏斗，
+// 计算综合健康分并写入 FundHealth 缓存；看板接口只读缓存，按经理登录地址聚合。
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+	"polyagent-backend/internal/risk"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	precision = 8
+
+	// 读取风控事件的上限，用于统计未处理事件数
+	maxRiskEvents = 200
+)
+
+// Config 看板配置
+type Config struct {
+	IntentWindow time.Duration // 交易意图漏斗统计窗口
+	EventWindow  time.Duration // 风控事件统计窗口
+	RecentEvents int           // 展示的最近风控事件数
+}
+
+// Service 基金经理看板服务
+type Service struct {
+	repo    repository.Repository
+	auditor *risk.Auditor
+	logger  *logger.Logger
+	config  Config
+}
+
+// NewService 创建看板服务，auditor 用于计算风控规则使用率
+func NewService(repo repository.Repository, auditor *risk.Auditor, logger *logger.Logger, config Config) *Service {
+	if config.IntentWindow <= 0 {
+		config.IntentWindow = 30 * 24 * time.Hour
+	}
+	if config.EventWindow <= 0 {
+		config.EventWindow = 7 * 24 * time.Hour
+	}
+	if config.RecentEvents <= 0 {
+		config.RecentEvents = 5
+	}
+	return &Service{
+		repo:    repo,
+		auditor: auditor,
+		logger:  logger,
+		config:  config,
+	}
+}
+
+// IntentFunnel 交易意图漏斗
+type IntentFunnel struct {
+	Pending   int64 `json:"pending"` // 审计、复核与执行中
+	Rejected  int64 `json:"rejected"`
+	Failed    int64 `json:"failed"`
+	Completed int64 `json:"completed"`
+}
+
+// RiskEventItem 风控事件
+type RiskEventItem struct {
+	ID          uuid.UUID           `json:"id"`
+	RuleType    models.RiskRuleType `json:"ruleType"`
+	Severity    string              `json:"severity"`
+	MarketID    string              `json:"marketId,omitempty"`
+	Description string              `json:"description"`
+	TriggeredAt time.Time           `json:"triggeredAt"`
+	IsHandled   bool                `json:"isHandled"`
+}
+
+// Health 基金健康度
+type Health struct {
+	Score              int                    `json:"score"`
+	Level              string                 `json:"level"`
+	NavChange1DPct     decimal.Decimal        `json:"navChange1dPct"`
+	OpenPositions      int                    `json:"openPositions"`
+	PositionValue      decimal.Decimal        `json:"positionValue"`
+	VaultCash          decimal.Decimal        `json:"vaultCash"`
+	ExecutionCash      decimal.Decimal        `json:"executionCash"`
+	CashRatio          decimal.Decimal        `json:"cashRatio"`
+	MaxRuleUtilization decimal.Decimal        `json:"maxRuleUtilization"`
+	RuleUtilization    []risk.RuleUtilization `json:"ruleUtilization"`
+	OpenRiskEvents     int                    `json:"openRiskEvents"`
+	CriticalRiskEvents int                    `json:"criticalRiskEvents"`
+	RecentRiskEvents   []RiskEventItem        `json:"recentRiskEvents"`
+	Intents            IntentFunnel           `json:"intents"`
+	ComputedAt         time.Time              `json:"computedAt"`
+}
+
+// FundItem 经理管理的基金列表项，Health 在首次刷新前为空
+type FundItem struct {
+	FundID              uuid.UUID       `json:"fundId"`
+	FundName            string          `json:"fundName"`
+	Manager             string          `json:"manager"`
+	VaultAddress        string          `json:"vaultAddress"`
+	CreatedAt           time.Time       `json:"createdAt"`
+	Status              string          `json:"status"`
+	MinimumDeposit      decimal.Decimal `json:"minimumDeposit"`
+	MinimumRedeem       decimal.Decimal `json:"minimumRedeem"`
+	ManagementFeeRate   decimal.Decimal `json:"managementFeeRate"`
+	PerformanceFeeRate  decimal.Decimal `json:"performanceFeeRate"`
+	CurrentNav          decimal.Decimal `json:"currentNav"`
+	CumulativeReturnPct decimal.Decimal `json:"cumulativeReturnPct"`
+	MaxDrawdownPct      decimal.Decimal `json:"maxDrawdownPct"`
+	Aum                 decimal.Decimal `json:"aum"`
+	InvestorCount       int             `json:"investorCount"`
+	Health              *Health         `json:"health,omitempty"`
+}
+
+// Summary 基金经理概览
+type Summary struct {
+	FundCount          int             `json:"fundCount"`
+	TotalInvestorCount int64           `json:"totalInvestorCount"` // 同一投资人只计一次
+	TotalInvested      decimal.Decimal `json:"totalInvested"`      // 已结算申购 - 已结算赎回
+	TotalValue         decimal.Decimal `json:"totalValue"`         // 各基金 AUM 合计
+	AvgHealthScore     int             `json:"avgHealthScore"`     // 已计算健康度的基金平均分
+	FundsAtRisk        int             `json:"fundsAtRisk"`        // 健康等级为 WARNING 或 CRITICAL 的基金数
+	OpenRiskEvents     int             `json:"openRiskEvents"`
+	Intents            IntentFunnel    `json:"intents"`
+}
+
+// Summary 汇总基金经理管理的全部基金
+func (s *Service) Summary(ctx context.Context, manager string) (*Summary, error) {
+	funds, _, err := s.repo.GetManagerFunds(ctx, manager, "", 0, -1)
+	if err != nil {
+		return nil, fmt.Errorf("获取管理的基金失败: %w", err)
+	}
+	summary := &Summary{FundCount: len(funds)}
+	if len(funds) == 0 {
+		return summary, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(funds))
+	for _, fund := range funds {
+		ids = append(ids, fund.ID)
+		summary.TotalValue = summary.TotalValue.Add(fund.TotalAUM)
+	}
+	if summary.TotalInvestorCount, err = s.repo.CountInvestors(ctx, ids); err != nil {
+		return nil, fmt.Errorf("统计投资人数失败: %w", err)
+	}
+	healths, err := s.repo.GetFundHealths(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金健康度失败: %w", err)
+	}
+
+	totalScore := 0
+	for _, h := range healths {
+		summary.TotalInvested = summary.TotalInvested.Add(h.NetInvested)
+		summary.OpenRiskEvents += h.OpenRiskEvents
+		summary.Intents.Pending += h.PendingIntents
+		summary.Intents.Rejected += h.RejectedIntents
+		summary.Intents.Failed += h.FailedIntents
+		summary.Intents.Completed += h.CompletedIntents
+		if h.Level != models.HealthLevelHealthy {
+			summary.FundsAtRisk++
+		}
+		totalScore += h.Score
+	}
+	if len(healths) > 0 {
+		summary.AvgHealthScore = totalScore / len(healths)
+	}
+	return summary, nil
+}
+
+// Funds 分页查询基金经理管理的基金及健康度
+func (s *Service) Funds(ctx context.Context, manager, keyword string, page, pageSize int) ([]FundItem, int64, error) {
+	funds, total, err := s.repo.GetManagerFunds(ctx, manager, keyword, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取管理的基金失败: %w", err)
+	}
+
+	ids := make([]uuid.UUID, 0, len(funds))
+	for _, fund := range funds {
+		ids = append(ids, fund.ID)
+	}
+	healths, err := s.repo.GetFundHealths(ctx, ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取基金健康度失败: %w", err)
+	}
+	byFund := make(map[uuid.UUID]*models.FundHealth, len(healths))
+	for i := range healths {
+		byFund[healths[i].FundID] = &healths[i]
+	}
+
+	items := make([]FundItem, 0, len(funds))
+	for _, fund := range funds {
+		perf, err := s.repo.GetFundPerformance(ctx, fund.ID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("获取业绩指标失败: %w", err)
+		}
+
+		item := FundItem{
+			FundID:             fund.ID,
+			FundName:           fund.Name,
+			Manager:            fund.ManagerAddress,
+			VaultAddress:       fund.VaultAddress,
+			CreatedAt:          fund.CreatedAt,
+			Status:             fundStatus(fund.Status),
+			MinimumDeposit:     fund.MinimumDeposit,
+			MinimumRedeem:      fund.MinimumRedeem,
+			ManagementFeeRate:  fund.ManagementFeeRate,
+			PerformanceFeeRate: fund.PerformanceFeeRate,
+			CurrentNav:         fund.CurrentNAV,
+			Aum:                fund.TotalAUM,
+		}
+		if perf != nil {
+			item.CumulativeReturnPct = perf.ReturnInception
+			item.MaxDrawdownPct = perf.MaxDrawdown
+		}
+		if h := byFund[fund.ID]; h != nil {
+			item.InvestorCount = h.InvestorCount
+			item.Health = s.healthOf(h)
+		}
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// Refresh 重新计算全部已关联经理的基金健康度
+func (s *Service) Refresh(ctx context.Context, now time.Time) error {
+	funds, err := s.repo.GetManagedFunds(ctx)
+	if err != nil {
+		return fmt.Errorf("获取基金列表失败: %w", err)
+	}
+
+	var errs []error
+	for _, fund := range funds {
+		health, err := s.compute(ctx, fund, now)
+		if err == nil {
+			err = s.repo.SaveFundHealth(ctx, health)
+		}
+		if err != nil {
+			s.logger.Error("刷新基金健康度失败", zap.String("fund_id", fund.ID.String()), zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compute 计算单只基金的健康度
+func (s *Service) compute(ctx context.Context, fund models.Fund, now time.Time) (*models.FundHealth, error) {
+	h := &models.FundHealth{
+		FundID:         fund.ID,
+		ManagerAddress: fund.ManagerAddress,
+		AUM:            fund.TotalAUM,
+		NavPerShare:    fund.CurrentNAV,
+		VaultCash:      fund.VaultCash,
+		ExecutionCash:  fund.ExecutionCash,
+		ComputedAt:     now,
+	}
+	if fund.TotalAUM.IsPositive() {
+		h.CashRatio = fund.VaultCash.Add(fund.ExecutionCash).Div(fund.TotalAUM).Round(6)
+	}
+
+	// 持仓
+	positions, err := s.repo.GetFundPositions(ctx, fund.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	for _, pos := range positions {
+		if pos.ResolvedAt != nil || pos.Size.IsZero() {
+			continue
+		}
+		h.OpenPositions++
+		h.PositionValue = h.PositionValue.Add(pos.Size.Mul(pos.CurrentPrice))
+	}
+	h.PositionValue = h.PositionValue.Round(precision)
+
+	// 风控规则使用率
+	utilizations := []risk.RuleUtilization{}
+	if s.auditor != nil {
+		if utilizations, err = s.auditor.RuleUtilizations(ctx, &fund, positions); err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range utilizations {
+		if u.Utilization.GreaterThan(h.MaxRuleUtilization) {
+			h.MaxRuleUtilization = u.Utilization
+		}
+	}
+	if h.RuleUtilization, err = marshal(utilizations); err != nil {
+		return nil, err
+	}
+
+	// 净值变动：最近两次正式净值
+	navs, err := s.repo.GetNavHistory(ctx, fund.ID, models.NavKindOfficial, now.AddDate(0, 0, -7), now)
+	if err != nil {
+		return nil, fmt.Errorf("获取净值历史失败: %w", err)
+	}
+	if n := len(navs); n >= 2 && navs[n-2].NavPerShare.IsPositive() {
+		h.NavChange1D = navs[n-1].NavPerShare.Div(navs[n-2].NavPerShare).Sub(decimal.NewFromInt(1)).Round(precision)
+	}
+
+	// 投资人与净申购
+	holdings, err := s.repo.GetFundShareHoldings(ctx, fund.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取份额记录失败: %w", err)
+	}
+	h.InvestorCount = len(holdings)
+	txns, err := s.repo.GetFundSettledTransactions(ctx, fund.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取申赎记录失败: %w", err)
+	}
+	for _, txn := range txns {
+		if txn.Type == models.InvestmentTxDeposit {
+			h.NetInvested = h.NetInvested.Add(txn.Amount)
+		} else {
+			h.NetInvested = h.NetInvested.Sub(txn.Amount)
+		}
+	}
+
+	// 交易意图漏斗
+	counts, err := s.repo.CountFundIntentsByStatus(ctx, fund.ID, now.Add(-s.config.IntentWindow))
+	if err != nil {
+		return nil, fmt.Errorf("统计交易意图失败: %w", err)
+	}
+	for status, count := range counts {
+		switch status {
+		case models.IntentStatusRejected:
+			h.RejectedIntents += count
+		case models.IntentStatusFailed:
+			h.FailedIntents += count
+		case models.IntentStatusCompleted:
+			h.CompletedIntents += count
+		case models.IntentStatusCancelled:
+		default:
+			h.PendingIntents += count
+		}
+	}
+
+	// 风控事件
+	events, err := s.repo.GetFundRiskEvents(ctx, fund.ID, now.Add(-s.config.EventWindow), maxRiskEvents)
+	if err != nil {
+		return nil, fmt.Errorf("获取风控事件失败: %w", err)
+	}
+	recent := make([]RiskEventItem, 0, s.config.RecentEvents)
+	for _, e := range events {
+		if !e.IsHandled {
+			h.OpenRiskEvents++
+			if e.Severity == "CRITICAL" {
+				h.CriticalRiskEvents++
+			}
+		}
+		if len(recent) < s.config.RecentEvents {
+			recent = append(recent, RiskEventItem{
+				ID:          e.ID,
+				RuleType:    e.RuleType,
+				Severity:    e.Severity,
+				MarketID:    e.MarketID,
+				Description: e.Description,
+				TriggeredAt: e.TriggeredAt,
+				IsHandled:   e.IsHandled,
+			})
+		}
+	}
+	if h.RecentRiskEvents, err = marshal(recent); err != nil {
+		return nil, err
+	}
+
+	h.Score = score(h, &fund)
+	h.Level = level(h.Score)
+	return h, nil
+}
+
+// score 综合健康分：满分100，按规则使用率、未处理风控事件、意图失败率、净值回撤、现金比例与基金状态扣分
+func score(h *models.FundHealth, fund *models.Fund) int {
+	penalty := 0.0
+
+	// 规则使用率：触及上限扣40，超过80%扣20，超过50%扣5
+	switch utilization := h.MaxRuleUtilization.InexactFloat64(); {
+	case utilization >= 1:
+		penalty += 40
+	case utilization >= 0.8:
+		penalty += 20
+	case utilization >= 0.5:
+		penalty += 5
+	}
+
+	// 未处理风控事件：CRITICAL 每个扣15（最多30），其余每个扣5（最多15）
+	warnings := h.OpenRiskEvents - h.CriticalRiskEvents
+	penalty += min(float64(h.CriticalRiskEvents)*15, 30) + min(float64(warnings)*5, 15)
+
+	// 意图拒绝与失败率，最多扣20
+	if settled := h.RejectedIntents + h.FailedIntents + h.CompletedIntents; settled > 0 {
+		penalty += 20 * float64(h.RejectedIntents+h.FailedIntents) / float64(settled)
+	}
+
+	// 净值相对历史高点回撤，每1%扣1分，最多20
+	if fund.HighWaterMark.IsPositive() && fund.CurrentNAV.LessThan(fund.HighWaterMark) {
+		drawdown := fund.HighWaterMark.Sub(fund.CurrentNAV).Div(fund.HighWaterMark).InexactFloat64() * 100
+		penalty += min(drawdown, 20)
+	}
+
+	// 有持仓时现金不足 AUM 的5%，难以应对赎回
+	if h.OpenPositions > 0 && h.AUM.IsPositive() && h.CashRatio.LessThan(decimal.NewFromFloat(0.05)) {
+		penalty += 10
+	}
+
+	// 非正常运营（清盘中）
+	if fund.Status != models.FundStatusActive {
+		penalty += 30
+	}
+
+	return max(0, 100-int(penalty+0.5))
+}
+
+// level 健康等级：80分以上健康，50分以上需关注
+func level(score int) string {
+	switch {
+	case score >= 80:
+		return models.HealthLevelHealthy
+	case score >= 50:
+		return models.HealthLevelWarning
+	default:
+		return models.HealthLevelCritical
+	}
+}
+
+// healthOf 将缓存转换为接口返回结构
+func (s *Service) healthOf(h *models.FundHealth) *Health {
+	health := &Health{
+		Score:              h.Score,
+		Level:              h.Level,
+		NavChange1DPct:     h.NavChange1D,
+		OpenPositions:      h.OpenPositions,
+		PositionValue:      h.PositionValue,
+		VaultCash:          h.VaultCash,
+		ExecutionCash:      h.ExecutionCash,
+		CashRatio:          h.CashRatio,
+		MaxRuleUtilization: h.MaxRuleUtilization,
+		RuleUtilization:    []risk.RuleUtilization{},
+		OpenRiskEvents:     h.OpenRiskEvents,
+		CriticalRiskEvents: h.CriticalRiskEvents,
+		RecentRiskEvents:   []RiskEventItem{},
+		Intents: IntentFunnel{
+			Pending:   h.PendingIntents,
+			Rejected:  h.RejectedIntents,
+			Failed:    h.FailedIntents,
+			Completed: h.CompletedIntents,
+		},
+		ComputedAt: h.ComputedAt,
+	}
+	if h.RuleUtilization != "" {
+		if err := json.Unmarshal([]byte(h.RuleUtilization), &health.RuleUtilization); err != nil {
+			s.logger.Warn("解析规则使用率缓存失败", zap.String("fund_id", h.FundID.String()), zap.Error(err))
+		}
+	}
+	if h.RecentRiskEvents != "" {
+		if err := json.Unmarshal([]byte(h.RecentRiskEvents), &health.RecentRiskEvents); err != nil {
+			s.logger.Warn("解析风控事件缓存失败", zap.String("fund_id", h.FundID.String()), zap.Error(err))
+		}
+	}
+	return health
+}
+
+// fundStatus 对外展示的基金状态：正常运营为 RUNNING，其余为 STOPPED
+func fundStatus(status string) string {
+	if status == models.FundStatusActive {
+		return "RUNNING"
+	}
+	return "STOPPED"
+}
+
+func marshal(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("序列化失败: %w", err)
+	}
+	return string(data), nil
+}
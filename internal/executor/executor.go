@@ -2,10 +2,12 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
+	"polyagent-backend/internal/killswitch"
 	"polyagent-backend/internal/models"
 	"polyagent-backend/internal/pkg/logger"
 	"polyagent-backend/internal/repository"
@@ -17,9 +19,11 @@ import (
 
 // Executor 交易执行器
 type Executor struct {
-	repo     repository.Repository
-	pmClient *PolymarketClient
-	logger   *logger.Logger
+	repo       repository.Repository
+	pmClient   *PolymarketClient
+	killSwitch *killswitch.Service
+	quotes     QuoteSource
+	logger     *logger.Logger
 
 	// 执行配置
 	maxRetries    int
@@ -32,10 +36,17 @@ type Executor struct {
 	stopCh    chan struct{}
 }
 
+// QuoteSource 实时盘口（由 marketdata.Feed 实现）
+type QuoteSource interface {
+	// TopOfBook 结果代币的最优买卖价，盘口未就绪时 ok 为 false
+	TopOfBook(tokenID string) (bid, ask decimal.Decimal, ok bool)
+}
+
 // ExecutionTask 执行任务
 type ExecutionTask struct {
 	IntentID uuid.UUID
 	Retries  int
+	StopLoss bool // 止损平仓任务，熔断开关允许时可继续执行
 }
 
 // NewExecutor 创建执行器
@@ -53,6 +64,16 @@ func NewExecutor(repo repository.Repository, pmClient *PolymarketClient,
 	}
 }
 
+// SetKillSwitch 设置熔断开关，未设置时不检查熔断
+func (e *Executor) SetKillSwitch(ks *killswitch.Service) {
+	e.killSwitch = ks
+}
+
+// SetQuoteSource 设置实时盘口，未设置或盘口未就绪时市价单按 GetMarket 的最优价下单
+func (e *Executor) SetQuoteSource(quotes QuoteSource) {
+	e.quotes = quotes
+}
+
 // Start 启动执行器
 func (e *Executor) Start(ctx context.Context) {
 	e.logger.Info("启动交易执行器", zap.Int("workers", e.workers))
@@ -97,6 +118,11 @@ func (e *Executor) worker(ctx context.Context, id int) {
 					zap.String("intent_id", task.IntentID.String()),
 					zap.Error(err))
 
+				// 熔断拒绝不重试，意图已标记失败
+				if errors.Is(err, killswitch.ErrTradingHalted) {
+					continue
+				}
+
 				// 重试逻辑
 				if task.Retries < e.maxRetries {
 					task.Retries++
@@ -117,32 +143,36 @@ func (e *Executor) executeTask(ctx context.Context, task *ExecutionTask) error {
 	if err != nil {
 		return fmt.Errorf("获取交易意图失败: %w", err)
 	}
+	if intent == nil {
+		return fmt.Errorf("交易意图不存在: %s", task.IntentID)
+	}
 
 	// 检查状态
 	if intent.Status != models.IntentStatusApproved {
 		return fmt.Errorf("意图状态不正确: %s", intent.Status)
 	}
 
+	// 检查熔断开关
+	if e.killSwitch != nil {
+		if err := e.killSwitch.Halted(ctx, intent.FundID, intent.MarketID, task.StopLoss); err != nil {
+			e.failIntent(ctx, intent.ID, err.Error())
+			return err
+		}
+	}
+
 	// 更新为执行中
 	intent.Status = models.IntentStatusExecuting
 	if err := e.repo.UpdateTradeIntent(ctx, intent); err != nil {
 		return fmt.Errorf("更新状态失败: %w", err)
 	}
 
-	// 获取当前市场价格
-	market, err := e.pmClient.GetMarket(ctx, intent.MarketID)
-	if err != nil {
-		return fmt.Errorf("获取市场信息失败: %w", err)
-	}
-
 	// 确定执行价格
 	executionPrice := intent.Price
 	if executionPrice.IsZero() {
 		// 市价单使用当前最优价格
-		if intent.Side == models.TradeSideBuy {
-			executionPrice = market.BestAsk
-		} else {
-			executionPrice = market.BestBid
+		executionPrice, err = e.marketPrice(ctx, intent)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -186,12 +216,28 @@ func (e *Executor) executeTask(ctx context.Context, task *ExecutionTask) error {
 	if err := e.repo.UpdateTradeIntent(ctx, intent); err != nil {
 		e.logger.Error("更新意图完成状态失败", zap.Error(err))
 	}
+	e.recordExecution(ctx, intent, orderResp, "")
 
 	// 更新持仓
 	if err := e.updatePosition(ctx, intent, orderResp); err != nil {
 		e.logger.Error("更新持仓失败", zap.Error(err))
 	}
 
+	// 更新执行钱包现金：买入支出，卖出收回
+	cashDelta := orderResp.FilledSize.Mul(orderResp.AvgFillPrice)
+	if intent.Side == models.TradeSideBuy {
+		cashDelta = cashDelta.Neg()
+	}
+	if err := e.repo.RecordCashEntries(ctx, []models.CashEntry{{
+		FundID:    intent.FundID,
+		Account:   models.CashAccountExecution,
+		Kind:      models.CashEntryTrade,
+		Amount:    cashDelta,
+		Reference: &intent.ID,
+	}}); err != nil {
+		e.logger.Error("更新执行钱包现金失败", zap.Error(err))
+	}
+
 	e.logger.Info("交易执行完成",
 		zap.String("intent_id", intent.ID.String()),
 		zap.String("tx_id", orderResp.TransactionID),
@@ -200,11 +246,39 @@ func (e *Executor) executeTask(ctx context.Context, task *ExecutionTask) error {
 	return nil
 }
 
+// marketPrice 市价单的下单价格：买入取最优卖价，卖出取最优买价。
+// 优先使用实时盘口，未就绪时查询市场信息
+func (e *Executor) marketPrice(ctx context.Context, intent *models.TradeIntent) (decimal.Decimal, error) {
+	if e.quotes != nil {
+		if bid, ask, ok := e.quotes.TopOfBook(intent.OutcomeID); ok {
+			price := bid
+			if intent.Side == models.TradeSideBuy {
+				price = ask
+			}
+			if price.IsPositive() {
+				return price, nil
+			}
+		}
+	}
+
+	market, err := e.pmClient.GetMarket(ctx, intent.MarketID)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("获取市场信息失败: %w", err)
+	}
+	if intent.Side == models.TradeSideBuy {
+		return market.BestAsk, nil
+	}
+	return market.BestBid, nil
+}
+
 // updatePosition 更新持仓
 func (e *Executor) updatePosition(ctx context.Context, intent *models.TradeIntent, resp *OrderResponse) error {
 	// 查找现有持仓
 	position, err := e.repo.GetPosition(ctx, intent.FundID, intent.MarketID, intent.OutcomeID)
 	if err != nil {
+		return fmt.Errorf("获取持仓失败: %w", err)
+	}
+	if position == nil {
 		// 创建新持仓
 		position = &models.Position{
 			FundID:     intent.FundID,
@@ -216,6 +290,7 @@ func (e *Executor) updatePosition(ctx context.Context, intent *models.TradeInten
 	}
 
 	// 计算新持仓
+	prevSize := position.Size
 	if intent.Side == models.TradeSideBuy {
 		position.Size = position.Size.Add(resp.FilledSize)
 	} else {
@@ -229,6 +304,15 @@ func (e *Executor) updatePosition(ctx context.Context, intent *models.TradeInten
 		position.EntryPrice = totalCost.Div(position.Size.Abs())
 	}
 
+	// 新开仓时以成交价作为初始最有利价格并重新启用止损监控，平仓后清零
+	if prevSize.IsZero() {
+		position.PeakPrice = resp.AvgFillPrice
+		position.TriggerState = models.PositionTriggerArmed
+		position.TriggeredAt = nil
+	} else if position.Size.IsZero() {
+		position.PeakPrice = decimal.Zero
+	}
+
 	position.CurrentPrice = resp.AvgFillPrice
 	position.LastUpdated = time.Now()
 
@@ -238,8 +322,8 @@ func (e *Executor) updatePosition(ctx context.Context, intent *models.TradeInten
 // failIntent 标记意图失败
 func (e *Executor) failIntent(ctx context.Context, intentID uuid.UUID, reason string) {
 	intent, err := e.repo.GetTradeIntent(ctx, intentID)
-	if err != nil {
-		e.logger.Error("获取意图失败", zap.Error(err))
+	if err != nil || intent == nil {
+		e.logger.Error("获取意图失败", zap.String("intent_id", intentID.String()), zap.Error(err))
 		return
 	}
 
@@ -248,9 +332,39 @@ func (e *Executor) failIntent(ctx context.Context, intentID uuid.UUID, reason st
 	if err := e.repo.UpdateTradeIntent(ctx, intent); err != nil {
 		e.logger.Error("更新失败状态失败", zap.Error(err))
 	}
+	e.recordExecution(ctx, intent, nil, reason)
+}
+
+// recordExecution 写入执行记录（纳入审计哈希链）
+func (e *Executor) recordExecution(ctx context.Context, intent *models.TradeIntent, resp *OrderResponse, reason string) {
+	record := &models.ExecutionRecord{
+		IntentID:   intent.ID,
+		FundID:     intent.FundID,
+		MarketID:   intent.MarketID,
+		OutcomeID:  intent.OutcomeID,
+		Side:       intent.Side,
+		Size:       intent.Size,
+		Status:     intent.Status,
+		Reason:     reason,
+		ExecutedAt: time.Now(),
+	}
+	if resp != nil {
+		record.FilledSize = resp.FilledSize
+		record.AvgPrice = resp.AvgFillPrice
+		record.TxID = resp.TransactionID
+	}
+	if intent.ExecutedAt != nil {
+		record.ExecutedAt = *intent.ExecutedAt
+	}
+
+	if err := e.repo.CreateExecutionRecord(ctx, record); err != nil {
+		e.logger.Error("记录执行记录失败",
+			zap.String("intent_id", intent.ID.String()),
+			zap.Error(err))
+	}
 }
 
-// ExecuteStopLoss 执行止损平仓（供实时风控调用）
+// ExecuteStopLoss 执行止损平仓（供实时风控调用），执行失败的平仓意图标记为失败，不会滞留在执行中
 func (e *Executor) ExecuteStopLoss(ctx context.Context, position models.Position) error {
 	e.logger.Warn("执行止损平仓",
 		zap.String("fund_id", position.FundID.String()),
@@ -274,9 +388,16 @@ func (e *Executor) ExecuteStopLoss(ctx context.Context, position models.Position
 		return fmt.Errorf("创建平仓意图失败: %w", err)
 	}
 
-	// 直接执行，不经过队列
-	task := &ExecutionTask{IntentID: closeIntent.ID}
-	return e.executeTask(ctx, task)
+	// 直接执行，不经过队列；失败时将本次平仓意图标记失败，由实时风控在冷却期后重新发起
+	task := &ExecutionTask{IntentID: closeIntent.ID, StopLoss: true}
+	if err := e.executeTask(ctx, task); err != nil {
+		// 熔断拒绝时意图已标记失败
+		if !errors.Is(err, killswitch.ErrTradingHalted) {
+			e.failIntent(ctx, closeIntent.ID, err.Error())
+		}
+		return err
+	}
+	return nil
 }
 
 // getOppositeSide 获取相反方向
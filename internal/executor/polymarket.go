@@ -10,6 +10,8 @@ import (
 	"io"
 	"math/big"
 	"net/http"
+	"net/url"
+	"sort"
 	"time"
 
 	_ "github.com/ethereum/go-ethereum/common"
@@ -48,25 +50,46 @@ func NewPolymarketClient(baseURL, apiKey, apiSecret, passphrase, privateKeyHex s
 
 // Market 市场信息
 type Market struct {
-	ID          string          `json:"id"`
-	Question    string          `json:"question"`
-	Description string          `json:"description"`
-	EndDate     time.Time       `json:"end_date"`
-	Active      bool            `json:"active"`
-	Closed      bool            `json:"closed"`
-	Outcomes    []Outcome       `json:"outcomes"`
-	BestBid     decimal.Decimal `json:"best_bid"`
-	BestAsk     decimal.Decimal `json:"best_ask"`
-	LastPrice   decimal.Decimal `json:"last_price"`
-	Volume      decimal.Decimal `json:"volume"`
-	Liquidity   decimal.Decimal `json:"liquidity"`
+	ID              string          `json:"id"`
+	Question        string          `json:"question"`
+	Description     string          `json:"description"`
+	Category        string          `json:"category"`
+	EndDate         time.Time       `json:"end_date"`
+	Active          bool            `json:"active"`
+	Closed          bool            `json:"closed"`
+	AcceptingOrders *bool           `json:"accepting_orders"` // false 表示市场暂停接单，未返回该字段时视为接单
+	Outcomes        []Outcome       `json:"outcomes"`
+	BestBid         decimal.Decimal `json:"best_bid"`
+	BestAsk         decimal.Decimal `json:"best_ask"`
+	LastPrice       decimal.Decimal `json:"last_price"`
+	Volume          decimal.Decimal `json:"volume"`
+	Liquidity       decimal.Decimal `json:"liquidity"`
+}
+
+// IsAcceptingOrders 市场是否接单，未返回接单状态时视为接单
+func (m *Market) IsAcceptingOrders() bool {
+	return m.AcceptingOrders == nil || *m.AcceptingOrders
 }
 
 // Outcome 预测结果
 type Outcome struct {
-	ID    string          `json:"id"`
-	Name  string          `json:"name"`
-	Price decimal.Decimal `json:"price"`
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	Price  decimal.Decimal `json:"price"`
+	Winner bool            `json:"winner"` // 市场结算后的获胜结果
+}
+
+// WinningOutcome 已结算市场的获胜结果，市场未关闭或尚未公布结果时返回 false
+func (m *Market) WinningOutcome() (*Outcome, bool) {
+	if !m.Closed {
+		return nil, false
+	}
+	for i := range m.Outcomes {
+		if m.Outcomes[i].Winner {
+			return &m.Outcomes[i], true
+		}
+	}
+	return nil, false
 }
 
 // OrderRequest 下单请求
@@ -92,6 +115,75 @@ type OrderResponse struct {
 	Error         string          `json:"error,omitempty"`
 }
 
+// OrderBookLevel 订单簿档位
+type OrderBookLevel struct {
+	Price decimal.Decimal `json:"price"`
+	Size  decimal.Decimal `json:"size"`
+}
+
+// OrderBook 订单簿（按 outcome token 维度）
+type OrderBook struct {
+	Market  string           `json:"market"`
+	AssetID string           `json:"asset_id"`
+	Bids    []OrderBookLevel `json:"bids"`
+	Asks    []OrderBookLevel `json:"asks"`
+	// Timestamp 服务端快照时间（毫秒），与行情推送的时间戳同源
+	Timestamp string `json:"timestamp"`
+}
+
+// BestBid 最优买价，无买单时返回0
+func (b *OrderBook) BestBid() decimal.Decimal {
+	best := decimal.Zero
+	for _, level := range b.Bids {
+		if level.Price.GreaterThan(best) {
+			best = level.Price
+		}
+	}
+	return best
+}
+
+// BestAsk 最优卖价，无卖单时返回0
+func (b *OrderBook) BestAsk() decimal.Decimal {
+	best := decimal.Zero
+	for _, level := range b.Asks {
+		if best.IsZero() || level.Price.LessThan(best) {
+			best = level.Price
+		}
+	}
+	return best
+}
+
+// EstimateFill 按当前订单簿逐档吃单，估算成交均价
+// 买单消耗卖盘（价格从低到高），卖单消耗买盘（价格从高到低）；
+// 返回估算均价与可成交数量，深度不足时 filled 小于 size
+func (b *OrderBook) EstimateFill(side string, size decimal.Decimal) (avgPrice, filled decimal.Decimal) {
+	var levels []OrderBookLevel
+	if side == "BUY" {
+		levels = append(levels, b.Asks...)
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price.LessThan(levels[j].Price) })
+	} else {
+		levels = append(levels, b.Bids...)
+		sort.Slice(levels, func(i, j int) bool { return levels[i].Price.GreaterThan(levels[j].Price) })
+	}
+
+	var cost decimal.Decimal
+	remaining := size
+	for _, level := range levels {
+		if !remaining.IsPositive() {
+			break
+		}
+		take := decimal.Min(remaining, level.Size)
+		cost = cost.Add(take.Mul(level.Price))
+		filled = filled.Add(take)
+		remaining = remaining.Sub(take)
+	}
+
+	if filled.IsZero() {
+		return decimal.Zero, decimal.Zero
+	}
+	return cost.Div(filled), filled
+}
+
 // GetMarket 获取市场信息
 func (c *PolymarketClient) GetMarket(ctx context.Context, marketID string) (*Market, error) {
 	url := fmt.Sprintf("%s/markets/%s", c.baseURL, marketID)
@@ -122,6 +214,34 @@ func (c *PolymarketClient) GetMarket(ctx context.Context, marketID string) (*Mar
 	return &market, nil
 }
 
+// GetOrderBook 获取 outcome token 的订单簿
+func (c *PolymarketClient) GetOrderBook(ctx context.Context, tokenID string) (*OrderBook, error) {
+	reqURL := fmt.Sprintf("%s/book?token_id=%s", c.baseURL, url.QueryEscape(tokenID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("获取订单簿失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API错误: %s", string(body))
+	}
+
+	var book OrderBook
+	if err := json.NewDecoder(resp.Body).Decode(&book); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	return &book, nil
+}
+
 // PlaceOrder 下单
 func (c *PolymarketClient) PlaceOrder(ctx context.Context, req OrderRequest) (*OrderResponse, error) {
 	// 签名订单
@@ -0,0 +1,330 @@
+// Package fee 基金管理费与业绩报酬。
+// 管理费按日以净资产为基数计提；业绩报酬按结晶周期在高水位之上收取：
+// 基金层面高水位按日计提进净值，结晶时支付；投资人层面高水位不计提进净值，结晶时按各投资人超额收益扣减份额。
+// 计提的费用计入基金应计费用，单位净值按扣除应计费用后的净资产计算。
+package fee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/nav"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// 金额与份额精度，与 decimal(20,8) 一致
+const precision = 8
+
+// 管理费按 365 天年化
+var secondsPerYear = decimal.NewFromInt(365 * 24 * 60 * 60)
+
+// ErrFundNotFound 基金不存在
+var ErrFundNotFound = errors.New("基金不存在")
+
+// Summary 基金费用汇总
+type Summary struct {
+	FundID                uuid.UUID         `json:"fund_id"`
+	ManagementFeeRate     decimal.Decimal   `json:"management_fee_rate"`
+	PerformanceFeeRate    decimal.Decimal   `json:"performance_fee_rate"`
+	PerformanceFeePeriod  string            `json:"performance_fee_period"`
+	HWMMode               string            `json:"hwm_mode"`
+	HighWaterMark         decimal.Decimal   `json:"high_water_mark"` // 基金层面高水位
+	AccruedManagementFee  decimal.Decimal   `json:"accrued_management_fee"`
+	AccruedPerformanceFee decimal.Decimal   `json:"accrued_performance_fee"`
+	PaidManagementFee     decimal.Decimal   `json:"paid_management_fee"`
+	PaidPerformanceFee    decimal.Decimal   `json:"paid_performance_fee"`
+	AccruedAt             *time.Time        `json:"accrued_at"`
+	LastCrystallizedAt    *time.Time        `json:"last_crystallized_at"`
+	NextCrystallizationAt time.Time         `json:"next_crystallization_at"`
+	Entries               []models.FeeEntry `json:"entries"`
+	TotalEntries          int64             `json:"-"`
+}
+
+// Service 费用服务
+type Service struct {
+	repo   repository.Repository
+	nav    *nav.Engine
+	logger *logger.Logger
+}
+
+// NewService 创建费用服务
+func NewService(repo repository.Repository, navEngine *nav.Engine, logger *logger.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		nav:    navEngine,
+		logger: logger,
+	}
+}
+
+// Accrue 计提自上次计提以来的管理费，并按基金层面高水位重估应计业绩报酬
+// 成功后同步更新传入基金的应计费用，以便随后结算的净值扣除费用
+func (s *Service) Accrue(ctx context.Context, fund *models.Fund, at time.Time) error {
+	from := fund.CreatedAt
+	if fund.FeesAccruedAt != nil {
+		from = *fund.FeesAccruedAt
+	}
+	if !at.After(from) {
+		return nil
+	}
+
+	valuation, err := s.nav.Compute(ctx, fund)
+	if err != nil {
+		return err
+	}
+
+	var entries []models.FeeEntry
+	management := decimal.Zero
+	if fund.ManagementFeeRate.IsPositive() && valuation.NetAssets.IsPositive() {
+		elapsed := decimal.NewFromInt(int64(at.Sub(from) / time.Second)).Div(secondsPerYear)
+		management = valuation.NetAssets.Mul(fund.ManagementFeeRate).Mul(elapsed).Truncate(precision)
+		if management.IsPositive() {
+			entries = append(entries, models.FeeEntry{
+				FundID:      fund.ID,
+				Type:        models.FeeTypeManagement,
+				Kind:        models.FeeKindAccrual,
+				Amount:      management,
+				NAV:         valuation.NavPerShare,
+				Basis:       valuation.NetAssets,
+				PeriodStart: from,
+				PeriodEnd:   at,
+			})
+		}
+	}
+
+	performance := decimal.Zero
+	if fund.PerformanceHWMMode != models.FeeHWMInvestor && fund.TotalShares.IsPositive() {
+		// 业绩报酬前单位净值 = (扣除管理费后的净资产 + 已计提业绩报酬) / 总份额
+		gross := valuation.NetAssets.Sub(management).Add(fund.AccruedPerformanceFee)
+		grossNAV := gross.Div(fund.TotalShares).Round(precision)
+		hwm := hwmOf(fund.PerformanceHWM)
+
+		gain, target := decimal.Zero, decimal.Zero
+		if grossNAV.GreaterThan(hwm) && fund.PerformanceFeeRate.IsPositive() {
+			gain = grossNAV.Sub(hwm).Mul(fund.TotalShares)
+			target = gain.Mul(fund.PerformanceFeeRate).Truncate(precision)
+		}
+
+		// 净值回落时回拨已计提部分
+		performance = target.Sub(fund.AccruedPerformanceFee)
+		if !performance.IsZero() {
+			entries = append(entries, models.FeeEntry{
+				FundID:      fund.ID,
+				Type:        models.FeeTypePerformance,
+				Kind:        models.FeeKindAccrual,
+				Amount:      performance,
+				NAV:         grossNAV,
+				Basis:       gain,
+				PeriodStart: from,
+				PeriodEnd:   at,
+			})
+		}
+	}
+
+	fund.FeesAccruedAt = &at
+	if err := s.repo.SaveFeeAccrual(ctx, fund, entries); err != nil {
+		return fmt.Errorf("保存费用计提失败: %w", err)
+	}
+	fund.AccruedFees = fund.AccruedFees.Add(management).Add(performance)
+	fund.AccruedPerformanceFee = fund.AccruedPerformanceFee.Add(performance)
+
+	if len(entries) > 0 {
+		s.logger.Info("基金费用计提完成",
+			zap.String("fund_id", fund.ID.String()),
+			zap.String("management", management.String()),
+			zap.String("performance", performance.String()),
+			zap.String("accrued_fees", fund.AccruedFees.String()))
+	}
+	return nil
+}
+
+// CrystallizeIfDue 结晶周期到期时支付应计费用并收取投资人层面业绩报酬，未到期时返回 nil
+// 需在正式净值结算之后调用，投资人层面业绩报酬以基金当前单位净值计算
+func (s *Service) CrystallizeIfDue(ctx context.Context, fund *models.Fund, at time.Time) ([]models.FeeEntry, error) {
+	last := fund.CreatedAt
+	if fund.FeesCrystallizedAt != nil {
+		last = *fund.FeesCrystallizedAt
+	}
+	if at.Before(NextCrystallization(fund)) {
+		return nil, nil
+	}
+
+	nav := fund.CurrentNAV
+	if !nav.IsPositive() {
+		nav = decimal.NewFromInt(1)
+	}
+
+	var entries []models.FeeEntry
+	if management := fund.AccruedFees.Sub(fund.AccruedPerformanceFee); management.IsPositive() {
+		entries = append(entries, models.FeeEntry{
+			FundID:      fund.ID,
+			Type:        models.FeeTypeManagement,
+			Kind:        models.FeeKindPayment,
+			Amount:      management,
+			NAV:         nav,
+			PeriodStart: last,
+			PeriodEnd:   at,
+		})
+	}
+	if fund.AccruedPerformanceFee.IsPositive() {
+		entries = append(entries, models.FeeEntry{
+			FundID:      fund.ID,
+			Type:        models.FeeTypePerformance,
+			Kind:        models.FeeKindPayment,
+			Amount:      fund.AccruedPerformanceFee,
+			NAV:         nav,
+			PeriodStart: last,
+			PeriodEnd:   at,
+		})
+	}
+
+	if fund.PerformanceHWMMode == models.FeeHWMInvestor && fund.PerformanceFeeRate.IsPositive() {
+		holdings, err := s.repo.GetFundShareHoldings(ctx, fund.ID)
+		if err != nil {
+			return nil, fmt.Errorf("获取投资人份额失败: %w", err)
+		}
+		for _, holding := range holdings {
+			hwm := hwmOf(holding.HighWaterMark)
+			if !nav.GreaterThan(hwm) {
+				continue
+			}
+			gain := nav.Sub(hwm).Mul(holding.Shares)
+			shares := gain.Mul(fund.PerformanceFeeRate).Div(nav).Truncate(precision)
+			if !shares.IsPositive() {
+				continue
+			}
+			entries = append(entries, models.FeeEntry{
+				FundID:      fund.ID,
+				Type:        models.FeeTypePerformance,
+				Kind:        models.FeeKindPayment,
+				Investor:    holding.Investor,
+				Amount:      shares.Mul(nav).Truncate(precision),
+				Shares:      shares,
+				NAV:         nav,
+				Basis:       gain,
+				PeriodStart: last,
+				PeriodEnd:   at,
+			})
+		}
+	}
+
+	if nav.GreaterThan(hwmOf(fund.PerformanceHWM)) {
+		fund.PerformanceHWM = nav
+	}
+	fund.FeesCrystallizedAt = &at
+	if err := s.repo.CrystallizeFees(ctx, fund, entries); err != nil {
+		return nil, fmt.Errorf("保存费用结晶失败: %w", err)
+	}
+
+	paid := decimal.Zero
+	for _, entry := range entries {
+		paid = paid.Add(entry.Amount)
+		if entry.Investor == "" {
+			fund.AccruedFees = fund.AccruedFees.Sub(entry.Amount)
+		} else {
+			fund.TotalShares = fund.TotalShares.Sub(entry.Shares)
+		}
+	}
+	fund.AccruedPerformanceFee = decimal.Zero
+	fund.VaultCash = fund.VaultCash.Sub(paid)
+
+	s.logger.Info("基金费用结晶完成",
+		zap.String("fund_id", fund.ID.String()),
+		zap.Int("entries", len(entries)),
+		zap.String("paid", paid.String()),
+		zap.String("high_water_mark", fund.PerformanceHWM.String()))
+
+	return entries, nil
+}
+
+// Summary 基金应计与已支付费用汇总及分页费用流水
+func (s *Service) Summary(ctx context.Context, fundID uuid.UUID, page, pageSize int) (*Summary, error) {
+	fund, err := s.repo.GetFund(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金失败: %w", err)
+	}
+	if fund == nil {
+		return nil, ErrFundNotFound
+	}
+
+	totals, err := s.repo.GetFeeTotals(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取费用汇总失败: %w", err)
+	}
+	entries, total, err := s.repo.GetFeeEntries(ctx, fundID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取费用流水失败: %w", err)
+	}
+
+	summary := &Summary{
+		FundID:                fund.ID,
+		ManagementFeeRate:     fund.ManagementFeeRate,
+		PerformanceFeeRate:    fund.PerformanceFeeRate,
+		PerformanceFeePeriod:  periodOf(fund),
+		HWMMode:               fund.PerformanceHWMMode,
+		HighWaterMark:         hwmOf(fund.PerformanceHWM),
+		AccruedManagementFee:  fund.AccruedFees.Sub(fund.AccruedPerformanceFee),
+		AccruedPerformanceFee: fund.AccruedPerformanceFee,
+		AccruedAt:             fund.FeesAccruedAt,
+		LastCrystallizedAt:    fund.FeesCrystallizedAt,
+		NextCrystallizationAt: NextCrystallization(fund),
+		Entries:               entries,
+		TotalEntries:          total,
+	}
+	if summary.HWMMode == "" {
+		summary.HWMMode = models.FeeHWMFund
+	}
+	for _, t := range totals {
+		if t.Kind != models.FeeKindPayment {
+			continue
+		}
+		switch t.Type {
+		case models.FeeTypeManagement:
+			summary.PaidManagementFee = t.Amount
+		case models.FeeTypePerformance:
+			summary.PaidPerformanceFee = t.Amount
+		}
+	}
+	return summary, nil
+}
+
+// NextCrystallization 下一次费用结晶时间：自上次结晶（或基金成立）起满一个结晶周期
+func NextCrystallization(fund *models.Fund) time.Time {
+	last := fund.CreatedAt
+	if fund.FeesCrystallizedAt != nil {
+		last = *fund.FeesCrystallizedAt
+	}
+	switch periodOf(fund) {
+	case models.FeePeriodMonthly:
+		return last.AddDate(0, 1, 0)
+	case models.FeePeriodAnnual:
+		return last.AddDate(1, 0, 0)
+	default:
+		return last.AddDate(0, 3, 0)
+	}
+}
+
+// periodOf 结晶周期，未设置时默认按季度
+func periodOf(fund *models.Fund) string {
+	switch fund.PerformanceFeePeriod {
+	case models.FeePeriodMonthly, models.FeePeriodAnnual:
+		return fund.PerformanceFeePeriod
+	default:
+		return models.FeePeriodQuarterly
+	}
+}
+
+// hwmOf 高水位，未设置时按初始单位净值 1 计
+func hwmOf(hwm decimal.Decimal) decimal.Decimal {
+	if hwm.IsPositive() {
+		return hwm
+	}
+	return decimal.NewFromInt(1)
+}
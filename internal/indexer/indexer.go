@@ -0,0 +1,366 @@
+// Package indexer 链上 Vault 事件索引。
+// 通过 eth_getLogs 跟踪各基金 Vault 合约的 ERC-4626 Deposit / Withdraw 及份额 Transfer 事件，
+// 仅索引达到确认深度的区块，并以区块哈希检查点检测重组，回退时将孤块中的事件标记为 ORPHANED。
+package indexer
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// 默认配置
+const (
+	defaultConfirmations = 12
+	defaultBatchBlocks   = 2000
+	defaultReorgDepth    = 64
+	defaultDecimals      = 6 // USDC 及以其为底层资产的 Vault 份额精度
+)
+
+// 事件签名
+var (
+	topicDeposit  = topicOf("Deposit(address,address,uint256,uint256)")
+	topicWithdraw = topicOf("Withdraw(address,address,address,uint256,uint256)")
+	topicTransfer = topicOf("Transfer(address,address,uint256)")
+)
+
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// Config 索引配置
+type Config struct {
+	ChainID       int64
+	Confirmations uint64 // 确认深度，仅索引 最新高度-确认深度 之前的区块
+	BatchBlocks   uint64 // 单次 eth_getLogs 查询的区块数
+	StartBlock    uint64 // 无检查点时的起始区块（Vault 部署区块）
+	ReorgDepth    uint64 // 检测到重组时回退的区块数
+	AssetDecimals int32  // 底层资产精度
+	ShareDecimals int32  // Vault 份额精度
+}
+
+// SyncResult 单个基金的同步结果
+type SyncResult struct {
+	FundID    string `json:"fund_id"`
+	FromBlock uint64 `json:"from_block"`
+	ToBlock   uint64 `json:"to_block"`
+	Events    int    `json:"events"`
+	Orphaned  int64  `json:"orphaned"` // 因重组标记为孤块的事件数
+}
+
+// Indexer Vault 事件索引器
+type Indexer struct {
+	repo   repository.Repository
+	chain  ChainReader
+	logger *logger.Logger
+	config Config
+}
+
+// NewIndexer 创建索引器，未设置的配置项使用默认值
+func NewIndexer(repo repository.Repository, chain ChainReader, logger *logger.Logger, config Config) *Indexer {
+	if config.Confirmations == 0 {
+		config.Confirmations = defaultConfirmations
+	}
+	if config.BatchBlocks == 0 {
+		config.BatchBlocks = defaultBatchBlocks
+	}
+	if config.ReorgDepth == 0 {
+		config.ReorgDepth = defaultReorgDepth
+	}
+	if config.AssetDecimals == 0 {
+		config.AssetDecimals = defaultDecimals
+	}
+	if config.ShareDecimals == 0 {
+		config.ShareDecimals = defaultDecimals
+	}
+	return &Indexer{
+		repo:   repo,
+		chain:  chain,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Sync 将所有配置了 Vault 地址的基金同步到已确认区块
+func (ix *Indexer) Sync(ctx context.Context) ([]SyncResult, error) {
+	head, err := ix.chain.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新区块失败: %w", err)
+	}
+	if head < ix.config.Confirmations {
+		return nil, nil
+	}
+	safe := head - ix.config.Confirmations
+
+	funds, err := ix.repo.GetVaultFunds(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金列表失败: %w", err)
+	}
+
+	results := make([]SyncResult, 0, len(funds))
+	for i := range funds {
+		result, err := ix.SyncFund(ctx, &funds[i], safe)
+		if err != nil {
+			ix.logger.Error("同步Vault事件失败",
+				zap.String("fund_id", funds[i].ID.String()),
+				zap.String("vault", funds[i].VaultAddress),
+				zap.Error(err))
+			continue
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// SyncFund 将单个基金的 Vault 事件同步到 safe 区块（含）
+func (ix *Indexer) SyncFund(ctx context.Context, fund *models.Fund, safe uint64) (*SyncResult, error) {
+	vault := strings.ToLower(fund.VaultAddress)
+	result := &SyncResult{FundID: fund.ID.String()}
+
+	checkpoint, err := ix.repo.GetVaultCheckpoint(ctx, fund.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取索引进度失败: %w", err)
+	}
+
+	var next uint64
+	if checkpoint == nil || checkpoint.VaultAddress != vault {
+		checkpoint = &models.VaultCheckpoint{FundID: fund.ID, VaultAddress: vault}
+		next = ix.config.StartBlock
+	} else {
+		orphaned, err := ix.checkReorg(ctx, checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		result.Orphaned = orphaned
+		next = checkpoint.BlockNumber + 1
+	}
+
+	result.FromBlock = next
+	for from := next; from <= safe; from += ix.config.BatchBlocks {
+		to := from + ix.config.BatchBlocks - 1
+		if to > safe {
+			to = safe
+		}
+
+		count, err := ix.syncRange(ctx, fund, checkpoint, from, to)
+		if err != nil {
+			return nil, err
+		}
+		result.Events += count
+		result.ToBlock = to
+	}
+
+	if result.Events > 0 || result.Orphaned > 0 {
+		ix.logger.Info("Vault事件同步完成",
+			zap.String("fund_id", result.FundID),
+			zap.Uint64("from_block", result.FromBlock),
+			zap.Uint64("to_block", result.ToBlock),
+			zap.Int("events", result.Events),
+			zap.Int64("orphaned", result.Orphaned))
+	}
+	return result, nil
+}
+
+// checkReorg 检查点区块哈希与主链不一致时回退 ReorgDepth 个区块，并将其后的事件标记为孤块事件
+func (ix *Indexer) checkReorg(ctx context.Context, checkpoint *models.VaultCheckpoint) (int64, error) {
+	header, err := ix.chain.HeaderByNumber(ctx, checkpoint.BlockNumber)
+	if err != nil && !errors.Is(err, ErrBlockNotFound) {
+		return 0, fmt.Errorf("获取检查点区块失败: %w", err)
+	}
+	if header != nil && header.Hash == checkpoint.BlockHash {
+		return 0, nil
+	}
+
+	reorged := checkpoint.BlockNumber
+	rewind := ix.config.StartBlock
+	if reorged > ix.config.ReorgDepth && reorged-ix.config.ReorgDepth > rewind {
+		rewind = reorged - ix.config.ReorgDepth
+	}
+	rewindHeader, err := ix.chain.HeaderByNumber(ctx, rewind)
+	if err != nil {
+		return 0, fmt.Errorf("获取回退区块失败: %w", err)
+	}
+	checkpoint.BlockNumber = rewind
+	checkpoint.BlockHash = rewindHeader.Hash
+
+	orphaned, err := ix.repo.RollbackVaultEvents(ctx, checkpoint)
+	if err != nil {
+		return 0, fmt.Errorf("回退索引进度失败: %w", err)
+	}
+
+	ix.logger.Warn("检测到区块重组，回退索引进度",
+		zap.String("fund_id", checkpoint.FundID.String()),
+		zap.Uint64("checkpoint_block", reorged),
+		zap.Uint64("rewind_to", rewind),
+		zap.Int64("orphaned", orphaned))
+
+	return orphaned, nil
+}
+
+// syncRange 索引 [from, to] 区间的事件并推进检查点
+func (ix *Indexer) syncRange(ctx context.Context, fund *models.Fund, checkpoint *models.VaultCheckpoint, from, to uint64) (int, error) {
+	logs, err := ix.chain.GetLogs(ctx, LogFilter{
+		FromBlock: from,
+		ToBlock:   to,
+		Addresses: []string{checkpoint.VaultAddress},
+		Topics:    [][]string{{topicDeposit, topicWithdraw, topicTransfer}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("查询区块 %d-%d 事件失败: %w", from, to, err)
+	}
+
+	headers := make(map[uint64]*Header)
+	header := func(number uint64) (*Header, error) {
+		if h, ok := headers[number]; ok {
+			return h, nil
+		}
+		h, err := ix.chain.HeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, fmt.Errorf("获取区块 %d 失败: %w", number, err)
+		}
+		headers[number] = h
+		return h, nil
+	}
+
+	events := make([]models.VaultEvent, 0, len(logs))
+	for _, log := range logs {
+		if log.Removed {
+			continue
+		}
+		h, err := header(log.BlockNumber)
+		if err != nil {
+			return 0, err
+		}
+		// 查询期间发生重组，留待下次同步
+		if h.Hash != log.BlockHash {
+			return 0, fmt.Errorf("区块 %d 哈希不一致，等待下次同步", log.BlockNumber)
+		}
+
+		event, ok, err := ix.decode(log)
+		if err != nil {
+			ix.logger.Warn("解析Vault事件失败",
+				zap.String("tx_hash", log.TxHash),
+				zap.Uint64("log_index", log.LogIndex),
+				zap.Error(err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		event.FundID = fund.ID
+		event.ChainID = ix.config.ChainID
+		event.BlockTime = h.Time
+		events = append(events, event)
+	}
+
+	last, err := header(to)
+	if err != nil {
+		return 0, err
+	}
+	checkpoint.BlockNumber = to
+	checkpoint.BlockHash = last.Hash
+	if err := ix.repo.SaveVaultEvents(ctx, checkpoint, events); err != nil {
+		return 0, fmt.Errorf("保存Vault事件失败: %w", err)
+	}
+	return len(events), nil
+}
+
+// decode 解析事件日志，份额铸造与销毁已由 Deposit / Withdraw 事件体现，忽略
+func (ix *Indexer) decode(log Log) (models.VaultEvent, bool, error) {
+	event := models.VaultEvent{
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		TxHash:      log.TxHash,
+		LogIndex:    log.LogIndex,
+		Status:      models.VaultEventConfirmed,
+	}
+	if len(log.Topics) == 0 {
+		return event, false, nil
+	}
+
+	words, err := dataWords(log.Data)
+	if err != nil {
+		return event, false, err
+	}
+
+	switch log.Topics[0] {
+	case topicDeposit:
+		// Deposit(sender indexed, owner indexed, assets, shares)
+		if len(log.Topics) < 3 || len(words) < 2 {
+			return event, false, errors.New("Deposit 事件格式错误")
+		}
+		event.Type = models.VaultEventDeposit
+		event.Investor = topicAddress(log.Topics[2])
+		event.Counterparty = topicAddress(log.Topics[1])
+		event.Amount = decimal.NewFromBigInt(words[0], -ix.config.AssetDecimals)
+		event.Shares = decimal.NewFromBigInt(words[1], -ix.config.ShareDecimals)
+
+	case topicWithdraw:
+		// Withdraw(sender indexed, receiver indexed, owner indexed, assets, shares)
+		if len(log.Topics) < 4 || len(words) < 2 {
+			return event, false, errors.New("Withdraw 事件格式错误")
+		}
+		event.Type = models.VaultEventRedeem
+		event.Investor = topicAddress(log.Topics[3])
+		event.Counterparty = topicAddress(log.Topics[2])
+		event.Amount = decimal.NewFromBigInt(words[0], -ix.config.AssetDecimals)
+		event.Shares = decimal.NewFromBigInt(words[1], -ix.config.ShareDecimals)
+
+	case topicTransfer:
+		// Transfer(from indexed, to indexed, value)
+		if len(log.Topics) < 3 || len(words) < 1 {
+			return event, false, errors.New("Transfer 事件格式错误")
+		}
+		from, to := topicAddress(log.Topics[1]), topicAddress(log.Topics[2])
+		if from == zeroAddress || to == zeroAddress {
+			return event, false, nil
+		}
+		event.Type = models.VaultEventTransfer
+		event.Investor = from
+		event.Counterparty = to
+		event.Shares = decimal.NewFromBigInt(words[0], -ix.config.ShareDecimals)
+
+	default:
+		return event, false, nil
+	}
+
+	return event, true, nil
+}
+
+// dataWords 将事件 data 按 32 字节拆分为无符号整数
+func dataWords(data string) ([]*big.Int, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("无效的事件数据: %w", err)
+	}
+	if len(raw)%32 != 0 {
+		return nil, fmt.Errorf("事件数据长度 %d 不是32的整数倍", len(raw))
+	}
+	words := make([]*big.Int, 0, len(raw)/32)
+	for i := 0; i < len(raw); i += 32 {
+		words = append(words, new(big.Int).SetBytes(raw[i:i+32]))
+	}
+	return words, nil
+}
+
+// topicAddress 取 indexed 地址参数（32 字节左补零）的后 20 字节
+func topicAddress(topic string) string {
+	topic = strings.TrimPrefix(topic, "0x")
+	if len(topic) < 40 {
+		return ""
+	}
+	return "0x" + topic[len(topic)-40:]
+}
+
+func topicOf(signature string) string {
+	return "0x" + hex.EncodeToString(crypto.Keccak256([]byte(signature)))
+}
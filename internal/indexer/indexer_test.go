@@ -0,0 +1,341 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	testVault  = "0x00000000000000000000000000000000000000aa"
+	testSender = "0x00000000000000000000000000000000000000a1"
+	testOwner  = "0x00000000000000000000000000000000000000b2"
+	testPayee  = "0x00000000000000000000000000000000000000c3"
+)
+
+// fixtureLog 测试链上的事件日志，区块哈希取自所在区块
+type fixtureLog struct {
+	block  uint64
+	index  uint64
+	tx     string
+	topics []string
+	data   string
+}
+
+// rpcFixture 以 httptest 模拟的 JSON-RPC 节点：区块 0..head，forked 中的区块使用分叉后的哈希
+type rpcFixture struct {
+	head   uint64
+	forked map[uint64]bool
+	logs   []fixtureLog
+}
+
+func (f *rpcFixture) hash(number uint64) string {
+	if f.forked[number] {
+		return fmt.Sprintf("0xf%063x", number)
+	}
+	return fmt.Sprintf("0x%064x", number)
+}
+
+func (f *rpcFixture) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     uint64            `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	switch req.Method {
+	case "eth_blockNumber":
+		result = toQuantity(f.head)
+	case "eth_getBlockByNumber":
+		var tag string
+		_ = json.Unmarshal(req.Params[0], &tag)
+		number, _ := parseQuantity(tag)
+		if number <= f.head {
+			result = map[string]string{
+				"number":     tag,
+				"hash":       f.hash(number),
+				"parentHash": f.hash(number - 1),
+				"timestamp":  toQuantity(1700000000 + number*2),
+			}
+		}
+	case "eth_getLogs":
+		var filter struct {
+			FromBlock string `json:"fromBlock"`
+			ToBlock   string `json:"toBlock"`
+		}
+		_ = json.Unmarshal(req.Params[0], &filter)
+		from, _ := parseQuantity(filter.FromBlock)
+		to, _ := parseQuantity(filter.ToBlock)
+		logs := []map[string]interface{}{}
+		for _, l := range f.logs {
+			if l.block < from || l.block > to {
+				continue
+			}
+			logs = append(logs, map[string]interface{}{
+				"address":         testVault,
+				"topics":          l.topics,
+				"data":            l.data,
+				"blockNumber":     toQuantity(l.block),
+				"blockHash":       f.hash(l.block),
+				"transactionHash": l.tx,
+				"logIndex":        toQuantity(l.index),
+				"removed":         false,
+			})
+		}
+		result = logs
+	default:
+		result = nil
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"result":  result,
+	})
+}
+
+// fakeRepo 内存中的索引存储，未实现的方法调用时 panic
+type fakeRepo struct {
+	repository.Repository
+	funds      []models.Fund
+	checkpoint *models.VaultCheckpoint
+	events     map[string]models.VaultEvent
+}
+
+func eventKey(txHash string, logIndex uint64) string {
+	return txHash + "/" + strconv.FormatUint(logIndex, 10)
+}
+
+func (r *fakeRepo) GetVaultFunds(ctx context.Context) ([]models.Fund, error) {
+	return r.funds, nil
+}
+
+func (r *fakeRepo) GetVaultCheckpoint(ctx context.Context, fundID uuid.UUID) (*models.VaultCheckpoint, error) {
+	if r.checkpoint == nil {
+		return nil, nil
+	}
+	cp := *r.checkpoint
+	return &cp, nil
+}
+
+func (r *fakeRepo) SaveVaultEvents(ctx context.Context, checkpoint *models.VaultCheckpoint, events []models.VaultEvent) error {
+	for _, e := range events {
+		key := eventKey(e.TxHash, e.LogIndex)
+		if existing, ok := r.events[key]; ok {
+			existing.BlockNumber, existing.BlockHash = e.BlockNumber, e.BlockHash
+			existing.BlockTime, existing.Status = e.BlockTime, e.Status
+			r.events[key] = existing
+			continue
+		}
+		r.events[key] = e
+	}
+	cp := *checkpoint
+	r.checkpoint = &cp
+	return nil
+}
+
+func (r *fakeRepo) RollbackVaultEvents(ctx context.Context, checkpoint *models.VaultCheckpoint) (int64, error) {
+	var orphaned int64
+	for key, e := range r.events {
+		if e.FundID == checkpoint.FundID && e.BlockNumber > checkpoint.BlockNumber &&
+			e.Status == models.VaultEventConfirmed {
+			e.Status = models.VaultEventOrphaned
+			r.events[key] = e
+			orphaned++
+		}
+	}
+	cp := *checkpoint
+	r.checkpoint = &cp
+	return orphaned, nil
+}
+
+func addressTopic(address string) string {
+	return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(address, "0x")
+}
+
+func dataOf(values ...int64) string {
+	var sb strings.Builder
+	sb.WriteString("0x")
+	for _, v := range values {
+		sb.WriteString(fmt.Sprintf("%064x", v))
+	}
+	return sb.String()
+}
+
+func txHash(n int) string {
+	return fmt.Sprintf("0x%064x", 0xabc000+n)
+}
+
+func depositLog(block, index uint64, tx string, assets, shares int64) fixtureLog {
+	return fixtureLog{block: block, index: index, tx: tx,
+		topics: []string{topicDeposit, addressTopic(testSender), addressTopic(testOwner)},
+		data:   dataOf(assets, shares)}
+}
+
+func TestIndexerSyncFund(t *testing.T) {
+	fundID := uuid.New()
+	confirmed := func(block uint64, tx string, index uint64) models.VaultEvent {
+		return models.VaultEvent{FundID: fundID, BlockNumber: block, TxHash: tx, LogIndex: index,
+			Type: models.VaultEventDeposit, Status: models.VaultEventConfirmed,
+			Investor: testOwner, Counterparty: testSender,
+			Amount: decimal.NewFromInt(1), Shares: decimal.NewFromInt(1)}
+	}
+
+	tests := []struct {
+		name           string
+		fixture        rpcFixture
+		checkpoint     *models.VaultCheckpoint // 为空表示首次同步
+		existing       []models.VaultEvent
+		want           []models.VaultEvent
+		wantOrphaned   int64
+		wantCheckpoint uint64
+	}{
+		{
+			name: "解析 Deposit / Withdraw / Transfer，忽略份额铸造",
+			fixture: rpcFixture{head: 20, logs: []fixtureLog{
+				depositLog(3, 0, txHash(1), 1_500_000, 1_000_000),
+				{block: 7, index: 1, tx: txHash(2),
+					topics: []string{topicWithdraw, addressTopic(testOwner), addressTopic(testPayee), addressTopic(testOwner)},
+					data:   dataOf(2_000_000, 1_900_000)},
+				{block: 9, index: 2, tx: txHash(3),
+					topics: []string{topicTransfer, addressTopic(testOwner), addressTopic(testPayee)},
+					data:   dataOf(500_000)},
+				{block: 9, index: 3, tx: txHash(4),
+					topics: []string{topicTransfer, addressTopic(zeroAddress), addressTopic(testOwner)},
+					data:   dataOf(1_000_000)},
+			}},
+			want: []models.VaultEvent{
+				{BlockNumber: 3, TxHash: txHash(1), LogIndex: 0, Type: models.VaultEventDeposit,
+					Status: models.VaultEventConfirmed, Investor: testOwner, Counterparty: testSender,
+					Amount: decimal.RequireFromString("1.5"), Shares: decimal.NewFromInt(1)},
+				{BlockNumber: 7, TxHash: txHash(2), LogIndex: 1, Type: models.VaultEventRedeem,
+					Status: models.VaultEventConfirmed, Investor: testOwner, Counterparty: testPayee,
+					Amount: decimal.NewFromInt(2), Shares: decimal.RequireFromString("1.9")},
+				{BlockNumber: 9, TxHash: txHash(3), LogIndex: 2, Type: models.VaultEventTransfer,
+					Status: models.VaultEventConfirmed, Investor: testOwner, Counterparty: testPayee,
+					Shares: decimal.RequireFromString("0.5")},
+			},
+			wantCheckpoint: 15,
+		},
+		{
+			name: "仅索引达到确认深度的区块",
+			fixture: rpcFixture{head: 20, logs: []fixtureLog{
+				depositLog(15, 0, txHash(1), 1_000_000, 1_000_000),
+				depositLog(16, 0, txHash(2), 1_000_000, 1_000_000),
+			}},
+			want:           []models.VaultEvent{confirmed(15, txHash(1), 0)},
+			wantCheckpoint: 15,
+		},
+		{
+			name: "检查点区块被重组时回退并将孤块事件标记为 ORPHANED",
+			fixture: rpcFixture{head: 20, forked: map[uint64]bool{9: true, 10: true, 11: true, 12: true},
+				logs: []fixtureLog{depositLog(10, 0, txHash(4), 1_000_000, 1_000_000)}},
+			checkpoint: &models.VaultCheckpoint{FundID: fundID, VaultAddress: testVault,
+				BlockNumber: 12, BlockHash: fmt.Sprintf("0x%064x", 12)},
+			existing: []models.VaultEvent{
+				confirmed(5, txHash(1), 0),
+				confirmed(8, txHash(2), 0),
+				confirmed(11, txHash(3), 0),
+			},
+			want: []models.VaultEvent{
+				confirmed(5, txHash(1), 0),
+				confirmed(8, txHash(2), 0),
+				func() models.VaultEvent {
+					e := confirmed(11, txHash(3), 0)
+					e.Status = models.VaultEventOrphaned
+					return e
+				}(),
+				confirmed(10, txHash(4), 0),
+			},
+			wantOrphaned:   1,
+			wantCheckpoint: 15,
+		},
+		{
+			name: "重组后重新打包的事件恢复为已确认",
+			fixture: rpcFixture{head: 20, forked: map[uint64]bool{11: true, 12: true},
+				logs: []fixtureLog{depositLog(13, 0, txHash(3), 1_000_000, 1_000_000)}},
+			checkpoint: &models.VaultCheckpoint{FundID: fundID, VaultAddress: testVault,
+				BlockNumber: 12, BlockHash: fmt.Sprintf("0x%064x", 12)},
+			existing:       []models.VaultEvent{confirmed(11, txHash(3), 0)},
+			want:           []models.VaultEvent{confirmed(13, txHash(3), 0)},
+			wantOrphaned:   1,
+			wantCheckpoint: 15,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(&tt.fixture)
+			defer server.Close()
+
+			repo := &fakeRepo{
+				funds:      []models.Fund{{ID: fundID, VaultAddress: testVault}},
+				checkpoint: tt.checkpoint,
+				events:     make(map[string]models.VaultEvent),
+			}
+			for _, e := range tt.existing {
+				e.BlockHash = fmt.Sprintf("0x%064x", e.BlockNumber)
+				repo.events[eventKey(e.TxHash, e.LogIndex)] = e
+			}
+
+			ix := NewIndexer(repo, NewClient(server.URL), &logger.Logger{Logger: zap.NewNop()}, Config{
+				ChainID:       137,
+				Confirmations: 5,
+				BatchBlocks:   4,
+				ReorgDepth:    4,
+			})
+			results, err := ix.Sync(context.Background())
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("Sync() returned %d results, want 1", len(results))
+			}
+			if results[0].Orphaned != tt.wantOrphaned {
+				t.Errorf("Orphaned = %d, want %d", results[0].Orphaned, tt.wantOrphaned)
+			}
+
+			if repo.checkpoint == nil || repo.checkpoint.BlockNumber != tt.wantCheckpoint {
+				t.Fatalf("checkpoint = %+v, want block %d", repo.checkpoint, tt.wantCheckpoint)
+			}
+			if repo.checkpoint.BlockHash != tt.fixture.hash(tt.wantCheckpoint) {
+				t.Errorf("checkpoint hash = %s, want %s", repo.checkpoint.BlockHash, tt.fixture.hash(tt.wantCheckpoint))
+			}
+
+			if len(repo.events) != len(tt.want) {
+				t.Errorf("indexed %d events, want %d", len(repo.events), len(tt.want))
+			}
+			for _, want := range tt.want {
+				got, ok := repo.events[eventKey(want.TxHash, want.LogIndex)]
+				if !ok {
+					t.Errorf("event %s/%d not indexed", want.TxHash, want.LogIndex)
+					continue
+				}
+				if got.FundID != fundID || got.BlockNumber != want.BlockNumber || got.Type != want.Type ||
+					got.Status != want.Status || got.Investor != want.Investor ||
+					got.Counterparty != want.Counterparty || !got.Amount.Equal(want.Amount) ||
+					!got.Shares.Equal(want.Shares) {
+					t.Errorf("event %s/%d = %+v, want %+v", want.TxHash, want.LogIndex, got, want)
+				}
+			}
+		})
+	}
+}
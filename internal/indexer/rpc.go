@@ -0,0 +1,254 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBlockNotFound 节点尚无该区块
+var ErrBlockNotFound = errors.New("区块不存在")
+
+// ChainReader 索引所需的链上数据读取接口
+type ChainReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number uint64) (*Header, error)
+	GetLogs(ctx context.Context, filter LogFilter) ([]Log, error)
+}
+
+// Header 区块头
+type Header struct {
+	Number     uint64
+	Hash       string
+	ParentHash string
+	Time       time.Time
+}
+
+// Log 合约事件日志
+type Log struct {
+	Address     string
+	Topics      []string
+	Data        string
+	BlockNumber uint64
+	BlockHash   string
+	TxHash      string
+	LogIndex    uint64
+	Removed     bool // 节点标记为已被重组移除
+}
+
+// LogFilter eth_getLogs 查询条件，区块区间含两端
+type LogFilter struct {
+	FromBlock uint64
+	ToBlock   uint64
+	Addresses []string
+	Topics    [][]string // 按位置匹配，同一位置内任一匹配即可
+}
+
+// Client 以太坊 JSON-RPC 客户端，可对接任意兼容节点（含本地开发链或录制回放服务）
+type Client struct {
+	url        string
+	httpClient *http.Client
+	nextID     atomic.Uint64
+}
+
+// NewClient 创建 JSON-RPC 客户端
+func NewClient(url string) *Client {
+	return &Client{
+		url:        url,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError 节点返回的 JSON-RPC 错误
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("RPC错误 %d: %s", e.Code, e.Message)
+}
+
+// BlockNumber 最新区块高度
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	var result string
+	if err := c.call(ctx, "eth_blockNumber", &result); err != nil {
+		return 0, err
+	}
+	return parseQuantity(result)
+}
+
+// HeaderByNumber 查询区块头，节点尚无该区块时返回 ErrBlockNotFound
+func (c *Client) HeaderByNumber(ctx context.Context, number uint64) (*Header, error) {
+	var result *struct {
+		Number     string `json:"number"`
+		Hash       string `json:"hash"`
+		ParentHash string `json:"parentHash"`
+		Timestamp  string `json:"timestamp"`
+	}
+	if err := c.call(ctx, "eth_getBlockByNumber", &result, toQuantity(number), false); err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, fmt.Errorf("%w: %d", ErrBlockNotFound, number)
+	}
+
+	n, err := parseQuantity(result.Number)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := parseQuantity(result.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return &Header{
+		Number:     n,
+		Hash:       strings.ToLower(result.Hash),
+		ParentHash: strings.ToLower(result.ParentHash),
+		Time:       time.Unix(int64(ts), 0).UTC(),
+	}, nil
+}
+
+// GetLogs 查询区间内的合约事件
+func (c *Client) GetLogs(ctx context.Context, filter LogFilter) ([]Log, error) {
+	params := map[string]interface{}{
+		"fromBlock": toQuantity(filter.FromBlock),
+		"toBlock":   toQuantity(filter.ToBlock),
+	}
+	if len(filter.Addresses) > 0 {
+		params["address"] = filter.Addresses
+	}
+	if len(filter.Topics) > 0 {
+		params["topics"] = filter.Topics
+	}
+
+	var result []struct {
+		Address     string   `json:"address"`
+		Topics      []string `json:"topics"`
+		Data        string   `json:"data"`
+		BlockNumber string   `json:"blockNumber"`
+		BlockHash   string   `json:"blockHash"`
+		TxHash      string   `json:"transactionHash"`
+		LogIndex    string   `json:"logIndex"`
+		Removed     bool     `json:"removed"`
+	}
+	if err := c.call(ctx, "eth_getLogs", &result, params); err != nil {
+		return nil, err
+	}
+
+	logs := make([]Log, 0, len(result))
+	for _, raw := range result {
+		blockNumber, err := parseQuantity(raw.BlockNumber)
+		if err != nil {
+			return nil, err
+		}
+		logIndex, err := parseQuantity(raw.LogIndex)
+		if err != nil {
+			return nil, err
+		}
+		topics := make([]string, len(raw.Topics))
+		for i, topic := range raw.Topics {
+			topics[i] = strings.ToLower(topic)
+		}
+		logs = append(logs, Log{
+			Address:     strings.ToLower(raw.Address),
+			Topics:      topics,
+			Data:        raw.Data,
+			BlockNumber: blockNumber,
+			BlockHash:   strings.ToLower(raw.BlockHash),
+			TxHash:      strings.ToLower(raw.TxHash),
+			LogIndex:    logIndex,
+			Removed:     raw.Removed,
+		})
+	}
+	return logs, nil
+}
+
+// CallContract 在指定区块执行只读合约调用（eth_call），返回 0x 前缀的十六进制结果
+func (c *Client) CallContract(ctx context.Context, to, data string, block uint64) (string, error) {
+	var result string
+	params := map[string]string{"to": to, "data": data}
+	if err := c.call(ctx, "eth_call", &result, params, toQuantity(block)); err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// call 发送 JSON-RPC 请求并解析结果
+func (c *Client) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      c.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s 请求失败: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s 节点错误: %s", method, string(body))
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("%s 解析响应失败: %w", method, err)
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("%s: %w", method, rpcResp.Error)
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("%s 解析结果失败: %w", method, err)
+	}
+	return nil
+}
+
+// parseQuantity 解析 0x 前缀的十六进制数值
+func parseQuantity(s string) (uint64, error) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的十六进制数值 %q: %w", s, err)
+	}
+	return n, nil
+}
+
+func toQuantity(n uint64) string {
+	return "0x" + strconv.FormatUint(n, 16)
+}
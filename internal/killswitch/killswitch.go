@@ -0,0 +1,304 @@
+// Package killswitch 交易熔断开关，支持全局、基金、市场三个范围。
+// 数据库保存开关状态与切换记录，Redis 缓存当前生效的开关供高频检查。
+package killswitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// loadedField 缓存已从数据库加载的标记字段，缺失时（如 Redis 重启）重新加载
+const loadedField = "_loaded"
+
+var (
+	// ErrTradingHalted 交易已被熔断
+	ErrTradingHalted = errors.New("交易已熔断")
+	// ErrInvalidScope 熔断范围参数无效
+	ErrInvalidScope = errors.New("无效的熔断范围")
+)
+
+// Service 熔断开关服务
+type Service struct {
+	repo   repository.Repository
+	cache  repository.RedisRepository // 为 nil 时直接查询数据库
+	logger *logger.Logger
+}
+
+// NewService 创建熔断开关服务
+func NewService(repo repository.Repository, cache repository.RedisRepository, logger *logger.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+	}
+}
+
+// Set 切换熔断开关，记录切换历史并发布风控事件
+func (s *Service) Set(ctx context.Context, scope models.KillSwitchScope, scopeID string,
+	active, allowStopLoss bool, reason, operator string) (*models.KillSwitch, error) {
+
+	if err := validateScope(scope, scopeID); err != nil {
+		return nil, err
+	}
+	if scope == models.KillSwitchScopeGlobal {
+		scopeID = ""
+	}
+
+	ks := &models.KillSwitch{
+		Scope:         scope,
+		ScopeID:       scopeID,
+		Active:        active,
+		AllowStopLoss: allowStopLoss,
+		Reason:        reason,
+		Operator:      operator,
+		UpdatedAt:     time.Now(),
+	}
+	if err := s.repo.SaveKillSwitch(ctx, ks); err != nil {
+		return nil, fmt.Errorf("保存熔断开关失败: %w", err)
+	}
+
+	s.updateCache(ctx, ks)
+	s.announce(ctx, ks)
+
+	s.logger.Warn("熔断开关已切换",
+		zap.String("scope", string(ks.Scope)),
+		zap.String("scope_id", ks.ScopeID),
+		zap.Bool("active", ks.Active),
+		zap.Bool("allow_stop_loss", ks.AllowStopLoss),
+		zap.String("operator", ks.Operator),
+		zap.String("reason", ks.Reason))
+
+	return ks, nil
+}
+
+// Check 返回对该基金/市场生效的熔断开关，未熔断时返回 nil
+// stopLoss 为 true 时忽略允许止损平仓的开关
+func (s *Service) Check(ctx context.Context, fundID uuid.UUID, marketID string, stopLoss bool) (*models.KillSwitch, error) {
+	switches, err := s.lookup(ctx, fundID, marketID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range switches {
+		ks := &switches[i]
+		if !ks.Active || stopLoss && ks.AllowStopLoss {
+			continue
+		}
+		return ks, nil
+	}
+	return nil, nil
+}
+
+// Halted 检查是否熔断，熔断时返回包装 ErrTradingHalted 的错误；无法获取状态时同样视为熔断
+func (s *Service) Halted(ctx context.Context, fundID uuid.UUID, marketID string, stopLoss bool) error {
+	ks, err := s.Check(ctx, fundID, marketID, stopLoss)
+	if err != nil {
+		return fmt.Errorf("%w: 无法获取熔断状态: %v", ErrTradingHalted, err)
+	}
+	if ks != nil {
+		return fmt.Errorf("%w: %s", ErrTradingHalted, Describe(ks))
+	}
+	return nil
+}
+
+// Active 当前生效的全部熔断开关
+func (s *Service) Active(ctx context.Context) ([]models.KillSwitch, error) {
+	switches, err := s.repo.GetActiveKillSwitches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取熔断开关失败: %w", err)
+	}
+	return switches, nil
+}
+
+// Describe 熔断开关描述
+func Describe(ks *models.KillSwitch) string {
+	var target string
+	switch ks.Scope {
+	case models.KillSwitchScopeGlobal:
+		target = "全局交易"
+	case models.KillSwitchScopeFund:
+		target = fmt.Sprintf("基金 %s", ks.ScopeID)
+	default:
+		target = fmt.Sprintf("市场 %s", ks.ScopeID)
+	}
+
+	state := "已熔断"
+	if !ks.Active {
+		state = "已解除熔断"
+	}
+
+	desc := target + state
+	if ks.Reason != "" {
+		desc += "：" + ks.Reason
+	}
+	return desc
+}
+
+// lookup 读取与该基金/市场相关的开关，优先使用缓存
+func (s *Service) lookup(ctx context.Context, fundID uuid.UUID, marketID string) ([]models.KillSwitch, error) {
+	fields := []string{
+		cacheField(models.KillSwitchScopeGlobal, ""),
+		cacheField(models.KillSwitchScopeFund, fundID.String()),
+		cacheField(models.KillSwitchScopeMarket, marketID),
+	}
+
+	if s.cache != nil {
+		values, err := s.cache.GetKillSwitches(ctx, append(fields, loadedField)...)
+		if err == nil {
+			if _, loaded := values[loadedField]; loaded {
+				return decodeSwitches(values, fields)
+			}
+			if err := s.Sync(ctx); err != nil {
+				s.logger.Error("同步熔断开关缓存失败", zap.Error(err))
+			}
+		} else {
+			s.logger.Error("读取熔断开关缓存失败，改为查询数据库", zap.Error(err))
+		}
+	}
+
+	active, err := s.repo.GetActiveKillSwitches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取熔断开关失败: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		wanted[field] = true
+	}
+	switches := make([]models.KillSwitch, 0, len(active))
+	for _, ks := range active {
+		if wanted[cacheField(ks.Scope, ks.ScopeID)] {
+			switches = append(switches, ks)
+		}
+	}
+	return switches, nil
+}
+
+// Sync 以数据库为准重建缓存
+func (s *Service) Sync(ctx context.Context) error {
+	if s.cache == nil {
+		return nil
+	}
+
+	active, err := s.repo.GetActiveKillSwitches(ctx)
+	if err != nil {
+		return fmt.Errorf("获取熔断开关失败: %w", err)
+	}
+
+	values := map[string]string{loadedField: time.Now().Format(time.RFC3339)}
+	for _, ks := range active {
+		data, err := json.Marshal(ks)
+		if err != nil {
+			return err
+		}
+		values[cacheField(ks.Scope, ks.ScopeID)] = string(data)
+	}
+	return s.cache.ReplaceKillSwitches(ctx, values)
+}
+
+// updateCache 同步单个开关到缓存，失败时清除加载标记以强制下次从数据库重建
+func (s *Service) updateCache(ctx context.Context, ks *models.KillSwitch) {
+	if s.cache == nil {
+		return
+	}
+
+	field := cacheField(ks.Scope, ks.ScopeID)
+	var err error
+	if ks.Active {
+		var data []byte
+		if data, err = json.Marshal(ks); err == nil {
+			err = s.cache.SetKillSwitch(ctx, field, string(data))
+		}
+	} else {
+		err = s.cache.DeleteKillSwitch(ctx, field)
+	}
+
+	if err != nil {
+		s.logger.Error("更新熔断开关缓存失败", zap.String("field", field), zap.Error(err))
+		if err := s.cache.DeleteKillSwitch(ctx, loadedField); err != nil {
+			s.logger.Error("清除熔断开关缓存标记失败", zap.Error(err))
+		}
+	}
+}
+
+// announce 发布熔断切换风控事件
+func (s *Service) announce(ctx context.Context, ks *models.KillSwitch) {
+	severity := "CRITICAL"
+	if !ks.Active {
+		severity = "WARNING"
+	}
+
+	event := &models.RiskEvent{
+		ID:          uuid.New(),
+		RuleType:    models.RiskRuleTypeKillSwitch,
+		Severity:    severity,
+		Description: fmt.Sprintf("%s（操作人 %s）", Describe(ks), ks.Operator),
+		TriggeredAt: ks.UpdatedAt,
+	}
+	switch ks.Scope {
+	case models.KillSwitchScopeFund:
+		if fundID, err := uuid.Parse(ks.ScopeID); err == nil {
+			event.FundID = fundID
+		}
+	case models.KillSwitchScopeMarket:
+		event.MarketID = ks.ScopeID
+	}
+
+	if err := s.repo.CreateRiskEvent(ctx, event); err != nil {
+		s.logger.Error("记录熔断事件失败", zap.Error(err))
+	}
+}
+
+// validateScope 校验开关范围
+func validateScope(scope models.KillSwitchScope, scopeID string) error {
+	switch scope {
+	case models.KillSwitchScopeGlobal:
+		return nil
+	case models.KillSwitchScopeFund:
+		if _, err := uuid.Parse(scopeID); err != nil {
+			return fmt.Errorf("%w: 基金熔断需要有效的基金ID", ErrInvalidScope)
+		}
+		return nil
+	case models.KillSwitchScopeMarket:
+		if scopeID == "" {
+			return fmt.Errorf("%w: 市场熔断需要市场ID", ErrInvalidScope)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+}
+
+// cacheField 缓存字段名
+func cacheField(scope models.KillSwitchScope, scopeID string) string {
+	if scope == models.KillSwitchScopeGlobal {
+		return string(scope)
+	}
+	return string(scope) + ":" + scopeID
+}
+
+// decodeSwitches 解析缓存中的开关
+func decodeSwitches(values map[string]string, fields []string) ([]models.KillSwitch, error) {
+	switches := make([]models.KillSwitch, 0, len(fields))
+	for _, field := range fields {
+		data, ok := values[field]
+		if !ok {
+			continue
+		}
+		var ks models.KillSwitch
+		if err := json.Unmarshal([]byte(data), &ks); err != nil {
+			return nil, fmt.Errorf("解析熔断开关缓存失败: %w", err)
+		}
+		switches = append(switches, ks)
+	}
+	return switches, nil
+}
@@ -0,0 +1,491 @@
+// Package leaderboard 投资人收益榜单。
+// 定时任务按基金回放已结算申赎与业绩报酬份额扣减，计算各投资人在统计窗口内的盈亏、收益率与持仓，
+// 写入 Redis sorted set；全局榜单由各基金榜单合并得到。基金数据版本未变化时跳过重算。
+// 投资人可选择退出榜单，对外展示的地址默认截断。
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	precision  = 8
+	dateLayout = "2006-01-02"
+	keyPrefix  = "leaderboard"
+)
+
+// Metric 排名指标
+type Metric string
+
+const (
+	MetricProfit  Metric = "PROFIT"  // 窗口内绝对盈亏（USDC）
+	MetricReturn  Metric = "RETURN"  // 窗口内收益率：盈亏 / (期初价值 + 窗口内申购)
+	MetricHolding Metric = "HOLDING" // 当前持仓：单基金按份额，全局按持仓价值
+)
+
+// Window 统计窗口，窗口起点按 UTC 自然日对齐
+type Window string
+
+const (
+	Window7D  Window = "7D"
+	Window30D Window = "30D"
+	WindowAll Window = "ALL"
+)
+
+var windows = []Window{Window7D, Window30D, WindowAll}
+
+var (
+	// ErrFundNotFound 基金不存在
+	ErrFundNotFound = errors.New("基金不存在")
+	// ErrInvalidMetric 不支持的排名指标
+	ErrInvalidMetric = errors.New("排名指标必须为 PROFIT、RETURN 或 HOLDING")
+	// ErrInvalidWindow 不支持的统计窗口
+	ErrInvalidWindow = errors.New("统计窗口必须为 7D、30D 或 ALL")
+)
+
+// Config 榜单配置
+type Config struct {
+	TruncateAddress bool // 对外展示时截断投资人地址（查询者本人除外）
+}
+
+// Service 投资人收益榜单服务
+type Service struct {
+	repo   repository.Repository
+	cache  repository.RedisRepository
+	logger *logger.Logger
+	config Config
+}
+
+// NewService 创建榜单服务
+func NewService(repo repository.Repository, cache repository.RedisRepository, logger *logger.Logger, config Config) *Service {
+	return &Service{
+		repo:   repo,
+		cache:  cache,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Entry 榜单条目
+type Entry struct {
+	Rank            int64            `json:"rank"`
+	Investor        string           `json:"investor"`
+	IsSelf          bool             `json:"isSelf"`
+	Value           decimal.Decimal  `json:"value"`                     // 指标值：盈亏（USDC）、收益率（小数）或持仓
+	HoldingShares   *decimal.Decimal `json:"holdingShares,omitempty"`   // 单基金持仓榜：持有份额
+	HoldingSharePct *decimal.Decimal `json:"holdingSharePct,omitempty"` // 单基金持仓榜：占基金总份额比例
+}
+
+// Rankings 分页查询榜单，fundID 为 uuid.Nil 时查询全局榜单；持仓榜不区分窗口
+func (s *Service) Rankings(ctx context.Context, fundID uuid.UUID, metric Metric, window Window, viewer string, page, pageSize int) ([]Entry, int64, error) {
+	if err := validate(metric, window); err != nil {
+		return nil, 0, err
+	}
+
+	var fund *models.Fund
+	if fundID != uuid.Nil {
+		var err error
+		if fund, err = s.repo.GetFund(ctx, fundID); err != nil {
+			return nil, 0, fmt.Errorf("获取基金失败: %w", err)
+		}
+		if fund == nil {
+			return nil, 0, ErrFundNotFound
+		}
+	}
+
+	offset := int64((page - 1) * pageSize)
+	members, total, err := s.cache.GetLeaderboard(ctx, rankingKey(fundID, metric, window), offset, int64(pageSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("读取榜单失败: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(members))
+	for i, m := range members {
+		entry := Entry{
+			Rank:     offset + int64(i) + 1,
+			Investor: s.display(m.Member, viewer),
+			IsSelf:   viewer != "" && strings.EqualFold(m.Member, viewer),
+			Value:    decimal.NewFromFloat(m.Score).Round(precision),
+		}
+		if fund != nil && metric == MetricHolding {
+			shares := entry.Value
+			pct := decimal.Zero
+			if fund.TotalShares.IsPositive() {
+				pct = shares.Div(fund.TotalShares).Round(precision)
+			}
+			entry.HoldingShares, entry.HoldingSharePct = &shares, &pct
+		}
+		entries = append(entries, entry)
+	}
+	return entries, total, nil
+}
+
+// OptedOut 查询投资人是否已退出榜单
+func (s *Service) OptedOut(ctx context.Context, investor string) (bool, error) {
+	return s.repo.IsLeaderboardOptOut(ctx, investor)
+}
+
+// SetOptOut 设置投资人是否退出榜单。
+// 退出时立即从所持基金及全局榜单中移除；并清除相关基金的数据版本，下次刷新时按新设置重算
+func (s *Service) SetOptOut(ctx context.Context, investor string, optOut bool) error {
+	if err := s.repo.SetLeaderboardOptOut(ctx, investor, optOut); err != nil {
+		return fmt.Errorf("保存榜单隐私设置失败: %w", err)
+	}
+
+	holdings, err := s.repo.GetInvestorShareHoldings(ctx, investor)
+	if err != nil {
+		return fmt.Errorf("获取份额记录失败: %w", err)
+	}
+	fields := make([]string, 0, len(holdings))
+	keys := fundKeys(uuid.Nil)
+	for _, h := range holdings {
+		fields = append(fields, h.FundID.String())
+		keys = append(keys, fundKeys(h.FundID)...)
+	}
+
+	if optOut {
+		if err := s.cache.RemoveFromLeaderboards(ctx, investor, keys...); err != nil {
+			return fmt.Errorf("移除榜单成员失败: %w", err)
+		}
+	}
+	return s.cache.DeleteLeaderboardWatermarks(ctx, fields...)
+}
+
+// Refresh 增量刷新榜单：仅重算数据版本变化的基金，有基金变化时重新合并全局榜单
+func (s *Service) Refresh(ctx context.Context, now time.Time) error {
+	funds, err := s.repo.GetVaultFunds(ctx)
+	if err != nil {
+		return fmt.Errorf("获取基金列表失败: %w", err)
+	}
+	optOuts, err := s.repo.GetLeaderboardOptOuts(ctx)
+	if err != nil {
+		return fmt.Errorf("获取榜单隐私设置失败: %w", err)
+	}
+	hidden := make(map[string]bool, len(optOuts))
+	for _, investor := range optOuts {
+		hidden[investor] = true
+	}
+	watermarks, err := s.cache.GetLeaderboardWatermarks(ctx)
+	if err != nil {
+		return fmt.Errorf("读取榜单数据版本失败: %w", err)
+	}
+
+	changed := false
+	active := make(map[string]bool, len(funds))
+	for _, fund := range funds {
+		field := fund.ID.String()
+		active[field] = true
+
+		mark, err := s.watermark(ctx, fund, now)
+		if err != nil {
+			s.logger.Error("计算榜单数据版本失败", zap.String("fund_id", field), zap.Error(err))
+			continue
+		}
+		if watermarks[field] == mark {
+			continue
+		}
+		if err := s.refreshFund(ctx, fund, hidden, now); err != nil {
+			s.logger.Error("刷新基金榜单失败", zap.String("fund_id", field), zap.Error(err))
+			continue
+		}
+		if err := s.cache.SetLeaderboardWatermark(ctx, field, mark); err != nil {
+			s.logger.Error("保存榜单数据版本失败", zap.String("fund_id", field), zap.Error(err))
+		}
+		changed = true
+	}
+
+	// 已不在列表中的基金不再参与全局榜单
+	var stale []string
+	for field := range watermarks {
+		if !active[field] {
+			stale = append(stale, field)
+		}
+	}
+	if len(stale) > 0 {
+		if err := s.cache.DeleteLeaderboardWatermarks(ctx, stale...); err != nil {
+			return fmt.Errorf("删除榜单数据版本失败: %w", err)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return s.refreshGlobal(ctx, funds)
+}
+
+// watermark 基金榜单数据版本：日期（窗口起点按日滚动）、最新正式净值时间与总份额，
+// 申赎结算与业绩报酬份额扣减均会改变总份额
+func (s *Service) watermark(ctx context.Context, fund models.Fund, now time.Time) (string, error) {
+	latest, err := s.repo.GetLatestNavHistory(ctx, fund.ID, models.NavKindOfficial)
+	if err != nil {
+		return "", err
+	}
+	var navAt int64
+	if latest != nil {
+		navAt = latest.RecordedAt.UnixNano()
+	}
+	return fmt.Sprintf("%s|%d|%s|%s", now.UTC().Format(dateLayout), navAt, fund.CurrentNAV, fund.TotalShares), nil
+}
+
+// event 投资人份额变动
+type event struct {
+	at     time.Time
+	shares decimal.Decimal // 份额变动，申购为正，赎回与业绩报酬扣减为负
+	flow   decimal.Decimal // 资金流入，申购为正，赎回为负，业绩报酬扣减为0
+}
+
+// refreshFund 回放基金全部投资人的份额变动，重写该基金各指标、各窗口的榜单
+func (s *Service) refreshFund(ctx context.Context, fund models.Fund, hidden map[string]bool, now time.Time) error {
+	txns, err := s.repo.GetFundSettledTransactions(ctx, fund.ID)
+	if err != nil {
+		return fmt.Errorf("获取申赎记录失败: %w", err)
+	}
+	fees, err := s.repo.GetFundInvestorFeePayments(ctx, fund.ID)
+	if err != nil {
+		return fmt.Errorf("获取费用流水失败: %w", err)
+	}
+	navs, err := s.repo.GetNavHistory(ctx, fund.ID, models.NavKindOfficial, time.Time{}, now)
+	if err != nil {
+		return fmt.Errorf("获取净值历史失败: %w", err)
+	}
+	holdings, err := s.repo.GetFundShareHoldings(ctx, fund.ID)
+	if err != nil {
+		return fmt.Errorf("获取份额记录失败: %w", err)
+	}
+
+	events := make(map[string][]event)
+	for _, txn := range txns {
+		if txn.SettledAt == nil || hidden[txn.Investor] {
+			continue
+		}
+		e := event{at: *txn.SettledAt, shares: txn.Shares, flow: txn.Amount}
+		if txn.Type == models.InvestmentTxRedeem {
+			e.shares, e.flow = txn.Shares.Neg(), txn.Amount.Neg()
+		}
+		events[txn.Investor] = append(events[txn.Investor], e)
+	}
+	for _, fee := range fees {
+		if hidden[fee.Investor] {
+			continue
+		}
+		events[fee.Investor] = append(events[fee.Investor], event{at: fee.PeriodEnd, shares: fee.Shares.Neg()})
+	}
+
+	current := make(map[string]decimal.Decimal, len(holdings))
+	shareScores := make(map[string]float64, len(holdings))
+	valueScores := make(map[string]float64, len(holdings))
+	for _, h := range holdings {
+		if hidden[h.Investor] {
+			continue
+		}
+		value := h.Shares.Mul(fund.CurrentNAV).Round(precision)
+		current[h.Investor] = value
+		shareScores[h.Investor] = h.Shares.InexactFloat64()
+		valueScores[h.Investor] = value.InexactFloat64()
+	}
+
+	if err := s.cache.ReplaceLeaderboard(ctx, sharesKey(fund.ID), shareScores); err != nil {
+		return err
+	}
+	if err := s.cache.ReplaceLeaderboard(ctx, valueKey(fund.ID), valueScores); err != nil {
+		return err
+	}
+
+	for _, window := range windows {
+		start := windowStart(window, now)
+		startNAV := navAt(navs, start)
+
+		profits := make(map[string]float64, len(events))
+		bases := make(map[string]float64, len(events))
+		returns := make(map[string]float64, len(events))
+		for investor, list := range events {
+			sort.SliceStable(list, func(i, j int) bool { return list[i].at.Before(list[j].at) })
+
+			startShares, inflow, deposits := decimal.Zero, decimal.Zero, decimal.Zero
+			active := false
+			for _, e := range list {
+				if !e.at.After(start) {
+					startShares = startShares.Add(e.shares)
+					continue
+				}
+				inflow = inflow.Add(e.flow)
+				if e.flow.IsPositive() {
+					deposits = deposits.Add(e.flow)
+				}
+				active = true
+			}
+			startValue := startShares.Mul(startNAV).Round(precision)
+			if !active && !startValue.IsPositive() {
+				continue
+			}
+
+			profit := current[investor].Sub(startValue).Sub(inflow)
+			base := startValue.Add(deposits)
+			profits[investor] = profit.InexactFloat64()
+			bases[investor] = base.InexactFloat64()
+			if base.IsPositive() {
+				returns[investor] = profit.Div(base).Round(precision).InexactFloat64()
+			}
+		}
+
+		if err := s.cache.ReplaceLeaderboard(ctx, windowKey(fund.ID, MetricProfit, window), profits); err != nil {
+			return err
+		}
+		if err := s.cache.ReplaceLeaderboard(ctx, baseKey(fund.ID, window), bases); err != nil {
+			return err
+		}
+		if err := s.cache.ReplaceLeaderboard(ctx, windowKey(fund.ID, MetricReturn, window), returns); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshGlobal 合并各基金榜单：盈亏、收益基数与持仓价值求和，收益率按合并后的盈亏 / 基数计算
+func (s *Service) refreshGlobal(ctx context.Context, funds []models.Fund) error {
+	valueKeys := make([]string, 0, len(funds))
+	for _, fund := range funds {
+		valueKeys = append(valueKeys, valueKey(fund.ID))
+	}
+	if err := s.cache.UnionLeaderboards(ctx, rankingKey(uuid.Nil, MetricHolding, ""), valueKeys); err != nil {
+		return fmt.Errorf("合并持仓榜单失败: %w", err)
+	}
+
+	for _, window := range windows {
+		profitKeys := make([]string, 0, len(funds))
+		baseKeys := make([]string, 0, len(funds))
+		for _, fund := range funds {
+			profitKeys = append(profitKeys, windowKey(fund.ID, MetricProfit, window))
+			baseKeys = append(baseKeys, baseKey(fund.ID, window))
+		}
+		if err := s.cache.UnionLeaderboards(ctx, windowKey(uuid.Nil, MetricProfit, window), profitKeys); err != nil {
+			return fmt.Errorf("合并盈亏榜单失败: %w", err)
+		}
+		if err := s.cache.UnionLeaderboards(ctx, baseKey(uuid.Nil, window), baseKeys); err != nil {
+			return fmt.Errorf("合并收益基数失败: %w", err)
+		}
+
+		profits, err := s.cache.GetLeaderboardScores(ctx, windowKey(uuid.Nil, MetricProfit, window))
+		if err != nil {
+			return fmt.Errorf("读取盈亏榜单失败: %w", err)
+		}
+		bases, err := s.cache.GetLeaderboardScores(ctx, baseKey(uuid.Nil, window))
+		if err != nil {
+			return fmt.Errorf("读取收益基数失败: %w", err)
+		}
+		returns := make(map[string]float64, len(profits))
+		for investor, profit := range profits {
+			if base := bases[investor]; base > 0 {
+				returns[investor] = decimal.NewFromFloat(profit / base).Round(precision).InexactFloat64()
+			}
+		}
+		if err := s.cache.ReplaceLeaderboard(ctx, windowKey(uuid.Nil, MetricReturn, window), returns); err != nil {
+			return fmt.Errorf("写入收益率榜单失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// display 对外展示的投资人地址，查询者本人不截断
+func (s *Service) display(address, viewer string) string {
+	if !s.config.TruncateAddress || strings.EqualFold(address, viewer) || len(address) <= 10 {
+		return address
+	}
+	return address[:6] + "..." + address[len(address)-4:]
+}
+
+func validate(metric Metric, window Window) error {
+	switch metric {
+	case MetricProfit, MetricReturn, MetricHolding:
+	default:
+		return ErrInvalidMetric
+	}
+	switch window {
+	case Window7D, Window30D, WindowAll:
+	default:
+		return ErrInvalidWindow
+	}
+	return nil
+}
+
+// windowStart 窗口起点，ALL 为零值（期初价值为0）
+func windowStart(window Window, now time.Time) time.Time {
+	today := now.UTC().Truncate(24 * time.Hour)
+	switch window {
+	case Window7D:
+		return today.AddDate(0, 0, -7)
+	case Window30D:
+		return today.AddDate(0, 0, -30)
+	default:
+		return time.Time{}
+	}
+}
+
+// navAt 时点前最近一次正式单位净值，尚无净值时为0
+func navAt(navs []models.NavHistory, at time.Time) decimal.Decimal {
+	i := sort.Search(len(navs), func(i int) bool { return navs[i].RecordedAt.After(at) })
+	if i == 0 {
+		return decimal.Zero
+	}
+	return navs[i-1].NavPerShare
+}
+
+// 榜单 key：leaderboard:{fund|global}:{指标}[:窗口]
+func scope(fundID uuid.UUID) string {
+	if fundID == uuid.Nil {
+		return keyPrefix + ":global"
+	}
+	return keyPrefix + ":fund:" + fundID.String()
+}
+
+func rankingKey(fundID uuid.UUID, metric Metric, window Window) string {
+	if metric == MetricHolding {
+		if fundID == uuid.Nil {
+			return valueKey(fundID)
+		}
+		return sharesKey(fundID)
+	}
+	return windowKey(fundID, metric, window)
+}
+
+func windowKey(fundID uuid.UUID, metric Metric, window Window) string {
+	return scope(fundID) + ":" + strings.ToLower(string(metric)) + ":" + string(window)
+}
+
+func baseKey(fundID uuid.UUID, window Window) string {
+	return scope(fundID) + ":base:" + string(window)
+}
+
+func sharesKey(fundID uuid.UUID) string {
+	return scope(fundID) + ":shares"
+}
+
+func valueKey(fundID uuid.UUID) string {
+	return scope(fundID) + ":value"
+}
+
+// fundKeys 基金（uuid.Nil 为全局）的全部榜单 key
+func fundKeys(fundID uuid.UUID) []string {
+	keys := []string{sharesKey(fundID), valueKey(fundID)}
+	for _, window := range windows {
+		keys = append(keys,
+			windowKey(fundID, MetricProfit, window),
+			windowKey(fundID, MetricReturn, window),
+			baseKey(fundID, window))
+	}
+	return keys
+}
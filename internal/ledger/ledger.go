@@ -0,0 +1,367 @@
+// Package ledger 投资人份额账本。
+// 申购、赎回请求先以 PENDING 状态排队，由每日结算任务按最新单位净值统一发行或注销份额。
+// 申购须附链上入金交易哈希，待链上索引确认该笔 Vault 入金后才发行份额。
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// 份额与金额精度，与 decimal(20,8) 一致
+const precision = 8
+
+// txHashPattern 链上交易哈希格式
+var txHashPattern = regexp.MustCompile(`^0x[0-9a-f]{64}$`)
+
+var (
+	// ErrFundNotFound 基金不存在
+	ErrFundNotFound = errors.New("基金不存在")
+	// ErrFundNotOpen 基金未开放申购
+	ErrFundNotOpen = errors.New("基金未开放申购")
+	// ErrInvalidTxHash 申购入金交易哈希缺失或格式错误
+	ErrInvalidTxHash = errors.New("无效的入金交易哈希")
+	// ErrDepositTxUsed 入金交易已被其他申购请求使用
+	ErrDepositTxUsed = errors.New("入金交易已被使用")
+	// ErrInvalidAmount 金额或份额必须为正数
+	ErrInvalidAmount = errors.New("金额或份额必须大于0")
+	// ErrBelowMinimumDeposit 低于最低申购金额
+	ErrBelowMinimumDeposit = errors.New("低于最低申购金额")
+	// ErrBelowMinimumRedeem 低于最低赎回金额
+	ErrBelowMinimumRedeem = errors.New("低于最低赎回金额")
+	// ErrInsufficientShares 可赎回份额不足
+	ErrInsufficientShares = errors.New("可赎回份额不足")
+	// ErrTransactionNotFound 申赎记录不存在
+	ErrTransactionNotFound = errors.New("申赎记录不存在")
+	// ErrTransactionNotPending 申赎记录已结算或已取消
+	ErrTransactionNotPending = errors.New("申赎记录已结算或已取消，无法撤销")
+)
+
+// SettleSummary 单个基金的结算汇总
+type SettleSummary struct {
+	FundID        uuid.UUID       `json:"fund_id"`
+	NAV           decimal.Decimal `json:"nav"`
+	Deposits      int             `json:"deposits"`
+	Redemptions   int             `json:"redemptions"`
+	Canceled      int             `json:"canceled"`
+	Unconfirmed   int             `json:"unconfirmed"` // 入金尚未在链上确认、顺延至下次结算的申购
+	SharesIssued  decimal.Decimal `json:"shares_issued"`
+	SharesBurned  decimal.Decimal `json:"shares_burned"`
+	AmountIn      decimal.Decimal `json:"amount_in"`
+	AmountPayable decimal.Decimal `json:"amount_payable"` // 应付赎回金额，由 Vault 出金
+}
+
+// Service 份额账本服务
+type Service struct {
+	repo   repository.Repository
+	logger *logger.Logger
+}
+
+// NewService 创建份额账本服务
+func NewService(repo repository.Repository, logger *logger.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Deposit 提交申购请求，按链上入金交易申购，份额在结算时确定
+func (s *Service) Deposit(ctx context.Context, fundID uuid.UUID, investor string, amount decimal.Decimal, txHash string) (*models.InvestmentTransaction, error) {
+	if !amount.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+	txHash = strings.ToLower(strings.TrimSpace(txHash))
+	if !txHashPattern.MatchString(txHash) {
+		return nil, ErrInvalidTxHash
+	}
+
+	fund, err := s.fund(ctx, fundID)
+	if err != nil {
+		return nil, err
+	}
+	if fund.Status != models.FundStatusActive {
+		return nil, ErrFundNotOpen
+	}
+	if amount.LessThan(fund.MinimumDeposit) {
+		return nil, fmt.Errorf("%w: %s USDC", ErrBelowMinimumDeposit, fund.MinimumDeposit.String())
+	}
+
+	used, err := s.repo.IsDepositTxUsed(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("校验入金交易失败: %w", err)
+	}
+	if used {
+		return nil, ErrDepositTxUsed
+	}
+
+	txn := &models.InvestmentTransaction{
+		FundID:   fundID,
+		Investor: investor,
+		Type:     models.InvestmentTxDeposit,
+		Status:   models.InvestmentTxPending,
+		Amount:   amount.Round(precision),
+		TxHash:   txHash,
+	}
+	if err := s.repo.CreateInvestmentTransaction(ctx, txn); err != nil {
+		return nil, fmt.Errorf("保存申购请求失败: %w", err)
+	}
+
+	s.logger.Info("提交申购请求",
+		zap.String("fund_id", fundID.String()),
+		zap.String("investor", investor),
+		zap.String("amount", txn.Amount.String()),
+		zap.String("tx_hash", txHash))
+
+	return txn, nil
+}
+
+// Redeem 提交赎回请求，按份额赎回，金额在结算时确定
+// 按当前单位净值估算的赎回金额不得低于最低赎回金额，全部赎回除外
+func (s *Service) Redeem(ctx context.Context, fundID uuid.UUID, investor string, shares decimal.Decimal) (*models.InvestmentTransaction, error) {
+	shares = shares.Round(precision)
+	if !shares.IsPositive() {
+		return nil, ErrInvalidAmount
+	}
+
+	fund, err := s.fund(ctx, fundID)
+	if err != nil {
+		return nil, err
+	}
+
+	holding, err := s.repo.GetShareHolding(ctx, fundID, investor)
+	if err != nil {
+		return nil, fmt.Errorf("获取持有份额失败: %w", err)
+	}
+	pending, err := s.repo.GetPendingRedeemShares(ctx, fundID, investor)
+	if err != nil {
+		return nil, fmt.Errorf("获取待赎回份额失败: %w", err)
+	}
+
+	available := decimal.Zero
+	if holding != nil {
+		available = holding.Shares.Sub(pending)
+	}
+	if shares.GreaterThan(available) {
+		return nil, fmt.Errorf("%w: 可赎回 %s 份", ErrInsufficientShares, available.String())
+	}
+
+	estimated := shares.Mul(navOf(fund))
+	if !shares.Equal(available) && estimated.LessThan(fund.MinimumRedeem) {
+		return nil, fmt.Errorf("%w: %s USDC", ErrBelowMinimumRedeem, fund.MinimumRedeem.String())
+	}
+
+	txn := &models.InvestmentTransaction{
+		FundID:   fundID,
+		Investor: investor,
+		Type:     models.InvestmentTxRedeem,
+		Status:   models.InvestmentTxPending,
+		Shares:   shares,
+	}
+	if err := s.repo.CreateInvestmentTransaction(ctx, txn); err != nil {
+		return nil, fmt.Errorf("保存赎回请求失败: %w", err)
+	}
+
+	s.logger.Info("提交赎回请求",
+		zap.String("fund_id", fundID.String()),
+		zap.String("investor", investor),
+		zap.String("shares", shares.String()))
+
+	return txn, nil
+}
+
+// Cancel 撤销本人待结算的申赎请求
+func (s *Service) Cancel(ctx context.Context, fundID, txnID uuid.UUID, investor string) (*models.InvestmentTransaction, error) {
+	txn, err := s.repo.GetInvestmentTransaction(ctx, txnID)
+	if err != nil {
+		return nil, fmt.Errorf("获取申赎记录失败: %w", err)
+	}
+	if txn == nil || txn.FundID != fundID || txn.Investor != investor {
+		return nil, ErrTransactionNotFound
+	}
+	if txn.Status != models.InvestmentTxPending {
+		return nil, ErrTransactionNotPending
+	}
+
+	now := time.Now()
+	txn.Status = models.InvestmentTxCanceled
+	txn.Note = "投资人撤销"
+	txn.CanceledAt = &now
+	ok, err := s.repo.CancelInvestmentTransaction(ctx, txn)
+	if err != nil {
+		return nil, fmt.Errorf("撤销申赎请求失败: %w", err)
+	}
+	if !ok {
+		return nil, ErrTransactionNotPending
+	}
+
+	s.logger.Info("撤销申赎请求",
+		zap.String("fund_id", fundID.String()),
+		zap.String("transaction_id", txnID.String()),
+		zap.String("investor", investor))
+
+	return txn, nil
+}
+
+// Transactions 分页查询申赎记录，investor 为空时查询基金全部记录
+func (s *Service) Transactions(ctx context.Context, fundID uuid.UUID, investor string,
+	statuses []models.InvestmentTxStatus, page, pageSize int) ([]models.InvestmentTransaction, int64, error) {
+	txns, total, err := s.repo.GetInvestmentTransactions(ctx, fundID, investor, statuses, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取申赎记录失败: %w", err)
+	}
+	return txns, total, nil
+}
+
+// Settle 按基金最新单位净值结算 strikeAt 之前提交的申赎请求
+// 申购以已确认的链上入金金额发行份额，入金未确认的申购保持待结算；
+// 申购份额与赎回金额均向下取整，差额留在基金内
+func (s *Service) Settle(ctx context.Context, fund *models.Fund, strikeAt time.Time) (*SettleSummary, error) {
+	txns, err := s.repo.GetPendingInvestmentTransactions(ctx, fund.ID, strikeAt)
+	if err != nil {
+		return nil, fmt.Errorf("获取待结算申赎请求失败: %w", err)
+	}
+
+	nav := navOf(fund)
+	summary := &SettleSummary{FundID: fund.ID, NAV: nav}
+	for i := range txns {
+		txn := &txns[i]
+		settledAt := time.Now()
+		txn.ExecutedNAV = nav
+		txn.SettledAt = &settledAt
+
+		switch txn.Type {
+		case models.InvestmentTxDeposit:
+			confirmed, err := s.confirmDeposit(ctx, txn)
+			if err != nil {
+				s.logger.Error("核对链上入金失败",
+					zap.String("fund_id", fund.ID.String()),
+					zap.String("transaction_id", txn.ID.String()),
+					zap.Error(err))
+			}
+			if !confirmed {
+				summary.Unconfirmed++
+				continue
+			}
+			txn.Shares = txn.Amount.Div(nav).Truncate(precision)
+		case models.InvestmentTxRedeem:
+			txn.Amount = txn.Shares.Mul(nav).Truncate(precision)
+		}
+
+		ok, err := s.repo.SettleInvestmentTransaction(ctx, txn)
+		if err != nil {
+			s.logger.Error("结算申赎请求失败",
+				zap.String("fund_id", fund.ID.String()),
+				zap.String("transaction_id", txn.ID.String()),
+				zap.Error(err))
+			// 仅份额不足的赎回取消，数据库等临时错误保留待结算，下一次定价日重试
+			if errors.Is(err, repository.ErrInsufficientShares) && s.cancelFailed(ctx, txn, err) {
+				summary.Canceled++
+			}
+			continue
+		}
+		if !ok {
+			if txn.Type == models.InvestmentTxDeposit {
+				summary.Unconfirmed++
+			}
+			continue
+		}
+
+		if txn.Type == models.InvestmentTxDeposit {
+			summary.Deposits++
+			summary.SharesIssued = summary.SharesIssued.Add(txn.Shares)
+			summary.AmountIn = summary.AmountIn.Add(txn.Amount)
+		} else {
+			summary.Redemptions++
+			summary.SharesBurned = summary.SharesBurned.Add(txn.Shares)
+			summary.AmountPayable = summary.AmountPayable.Add(txn.Amount)
+		}
+	}
+
+	if summary.Deposits > 0 || summary.Redemptions > 0 || summary.Canceled > 0 || summary.Unconfirmed > 0 {
+		s.logger.Info("申赎结算完成",
+			zap.String("fund_id", fund.ID.String()),
+			zap.String("nav", nav.String()),
+			zap.Int("deposits", summary.Deposits),
+			zap.Int("redemptions", summary.Redemptions),
+			zap.Int("canceled", summary.Canceled),
+			zap.Int("unconfirmed", summary.Unconfirmed),
+			zap.String("shares_issued", summary.SharesIssued.String()),
+			zap.String("shares_burned", summary.SharesBurned.String()))
+	}
+
+	return summary, nil
+}
+
+// confirmDeposit 核对申购对应的链上入金：须为本基金已确认的 Vault 入金事件且入金人为申购投资人，
+// 核对通过后以链上入金金额作为申购金额
+func (s *Service) confirmDeposit(ctx context.Context, txn *models.InvestmentTransaction) (bool, error) {
+	if txn.TxHash == "" {
+		return false, nil
+	}
+	event, err := s.repo.GetConfirmedVaultDeposit(ctx, txn.FundID, txn.TxHash)
+	if err != nil {
+		return false, err
+	}
+	if event == nil {
+		return false, nil
+	}
+	if !strings.EqualFold(event.Investor, txn.Investor) || !event.Amount.IsPositive() {
+		s.logger.Warn("链上入金与申购请求不符",
+			zap.String("transaction_id", txn.ID.String()),
+			zap.String("tx_hash", txn.TxHash),
+			zap.String("investor", txn.Investor),
+			zap.String("onchain_investor", event.Investor),
+			zap.String("onchain_amount", event.Amount.String()))
+		return false, nil
+	}
+	txn.Amount = event.Amount.Round(precision)
+	return true, nil
+}
+
+// cancelFailed 结算失败的请求标记为取消
+func (s *Service) cancelFailed(ctx context.Context, txn *models.InvestmentTransaction, cause error) bool {
+	now := time.Now()
+	txn.Status = models.InvestmentTxCanceled
+	txn.Note = fmt.Sprintf("结算失败: %v", cause)
+	txn.CanceledAt = &now
+	ok, err := s.repo.CancelInvestmentTransaction(ctx, txn)
+	if err != nil {
+		s.logger.Error("取消结算失败的申赎请求失败",
+			zap.String("transaction_id", txn.ID.String()),
+			zap.Error(err))
+		return false
+	}
+	return ok
+}
+
+// fund 获取基金
+func (s *Service) fund(ctx context.Context, fundID uuid.UUID) (*models.Fund, error) {
+	fund, err := s.repo.GetFund(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金失败: %w", err)
+	}
+	if fund == nil {
+		return nil, ErrFundNotFound
+	}
+	return fund, nil
+}
+
+// navOf 基金单位净值，尚未结算过净值的新基金按 1 计
+func navOf(fund *models.Fund) decimal.Decimal {
+	if fund.CurrentNAV.IsPositive() {
+		return fund.CurrentNAV
+	}
+	return decimal.NewFromInt(1)
+}
@@ -0,0 +1,284 @@
+package marketdata
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"polyagent-backend/internal/executor"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// book 单个结果代币的内存订单簿
+type book struct {
+	market    string
+	bids      map[string]decimal.Decimal // 价格 -> 挂单量
+	asks      map[string]decimal.Decimal
+	lastPrice decimal.Decimal
+	timestamp int64 // 最近一次应用的推送时间（毫秒），早于该时间的推送视为乱序丢弃
+	synced    bool  // 是否已有完整快照，断线或增量不一致时置为 false
+}
+
+// wsEvent market 频道推送
+type wsEvent struct {
+	EventType    string          `json:"event_type"`
+	AssetID      string          `json:"asset_id"`
+	Market       string          `json:"market"`
+	Bids         []wsLevel       `json:"bids"`
+	Asks         []wsLevel       `json:"asks"`
+	Price        string          `json:"price"`
+	Timestamp    string          `json:"timestamp"`
+	PriceChanges []wsPriceChange `json:"price_changes"`
+}
+
+type wsLevel struct {
+	Price string `json:"price"`
+	Size  string `json:"size"`
+}
+
+type wsPriceChange struct {
+	AssetID string `json:"asset_id"`
+	Price   string `json:"price"`
+	Size    string `json:"size"`
+	Side    string `json:"side"` // BUY 更新买盘，SELL 更新卖盘
+	BestBid string `json:"best_bid"`
+	BestAsk string `json:"best_ask"`
+}
+
+// handleMessage 处理推送消息，消息可能是单个事件或事件数组
+func (f *Feed) handleMessage(data []byte) {
+	if len(data) == 0 || string(data) == "PONG" {
+		return
+	}
+
+	var events []wsEvent
+	var err error
+	if data[0] == '[' {
+		err = json.Unmarshal(data, &events)
+	} else {
+		var event wsEvent
+		err = json.Unmarshal(data, &event)
+		events = append(events, event)
+	}
+	if err != nil {
+		f.logger.Debug("解析行情消息失败", zap.ByteString("data", data), zap.Error(err))
+		return
+	}
+
+	for _, e := range events {
+		switch e.EventType {
+		case "book":
+			f.applySnapshot(e.AssetID, e.Market, toLevels(e.Bids), toLevels(e.Asks), parseTimestamp(e.Timestamp))
+		case "price_change":
+			f.applyChanges(e)
+		case "last_trade_price":
+			f.applyTrade(e)
+		}
+	}
+}
+
+// applySnapshot 以完整快照替换订单簿
+func (f *Feed) applySnapshot(tokenID, market string, bids, asks []executor.OrderBookLevel, ts int64) {
+	f.mu.Lock()
+	b := f.books[tokenID]
+	if b == nil {
+		b = &book{}
+		f.books[tokenID] = b
+	}
+	if b.synced && ts < b.timestamp {
+		f.mu.Unlock()
+		return
+	}
+	b.market = market
+	b.bids = levelMap(bids)
+	b.asks = levelMap(asks)
+	b.timestamp = ts
+	b.synced = true
+	f.mu.Unlock()
+
+	f.notify(tokenID)
+}
+
+// applyChanges 应用增量更新。订单簿尚无快照、或更新后最优价与推送不一致（遗漏了增量）时请求重新同步
+func (f *Feed) applyChanges(e wsEvent) {
+	ts := parseTimestamp(e.Timestamp)
+
+	var changed, resync []string
+	f.mu.Lock()
+	for _, c := range e.PriceChanges {
+		tokenID := c.AssetID
+		if tokenID == "" {
+			tokenID = e.AssetID
+		}
+		b := f.books[tokenID]
+		if b == nil || !b.synced {
+			resync = append(resync, tokenID)
+			continue
+		}
+		if ts < b.timestamp {
+			continue
+		}
+
+		price, err := decimal.NewFromString(c.Price)
+		if err != nil {
+			continue
+		}
+		size, err := decimal.NewFromString(c.Size)
+		if err != nil {
+			continue
+		}
+
+		levels := b.bids
+		if c.Side == "SELL" {
+			levels = b.asks
+		}
+		if size.IsZero() {
+			delete(levels, price.String())
+		} else {
+			levels[price.String()] = size
+		}
+		b.timestamp = ts
+
+		if !b.consistent(c.BestBid, c.BestAsk) {
+			b.synced = false
+			resync = append(resync, tokenID)
+			continue
+		}
+		changed = append(changed, tokenID)
+	}
+	f.mu.Unlock()
+
+	for _, tokenID := range resync {
+		f.requestResync(tokenID)
+	}
+	for _, tokenID := range changed {
+		f.notify(tokenID)
+	}
+}
+
+// applyTrade 记录最新成交价
+func (f *Feed) applyTrade(e wsEvent) {
+	price, err := decimal.NewFromString(e.Price)
+	if err != nil {
+		return
+	}
+
+	f.mu.Lock()
+	b := f.books[e.AssetID]
+	if b == nil {
+		b = &book{}
+		f.books[e.AssetID] = b
+	}
+	b.lastPrice = price
+	synced := b.synced
+	f.mu.Unlock()
+
+	if synced {
+		f.notify(e.AssetID)
+	}
+}
+
+// consistent 与推送携带的最优价核对，推送未携带或本地该侧为空时不校验
+func (b *book) consistent(bestBid, bestAsk string) bool {
+	bid, ask := b.best()
+	if pushed, err := decimal.NewFromString(bestBid); err == nil && pushed.IsPositive() && bid.IsPositive() {
+		if !pushed.Equal(bid) {
+			return false
+		}
+	}
+	if pushed, err := decimal.NewFromString(bestAsk); err == nil && pushed.IsPositive() && ask.IsPositive() {
+		if !pushed.Equal(ask) {
+			return false
+		}
+	}
+	return true
+}
+
+// best 最优买卖价，无挂单的一侧为0
+func (b *book) best() (bid, ask decimal.Decimal) {
+	for p := range b.bids {
+		price := decimal.RequireFromString(p)
+		if price.GreaterThan(bid) {
+			bid = price
+		}
+	}
+	for p := range b.asks {
+		price := decimal.RequireFromString(p)
+		if ask.IsZero() || price.LessThan(ask) {
+			ask = price
+		}
+	}
+	return bid, ask
+}
+
+// orderBook 转换为订单簿副本，买盘价格从高到低，卖盘从低到高
+func (b *book) orderBook(tokenID string) *executor.OrderBook {
+	return &executor.OrderBook{
+		Market:  b.market,
+		AssetID: tokenID,
+		Bids:    sortedLevels(b.bids, true),
+		Asks:    sortedLevels(b.asks, false),
+	}
+}
+
+func sortedLevels(levels map[string]decimal.Decimal, desc bool) []executor.OrderBookLevel {
+	result := make([]executor.OrderBookLevel, 0, len(levels))
+	for p, size := range levels {
+		result = append(result, executor.OrderBookLevel{Price: decimal.RequireFromString(p), Size: size})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if desc {
+			return result[i].Price.GreaterThan(result[j].Price)
+		}
+		return result[i].Price.LessThan(result[j].Price)
+	})
+	return result
+}
+
+func levelMap(levels []executor.OrderBookLevel) map[string]decimal.Decimal {
+	m := make(map[string]decimal.Decimal, len(levels))
+	for _, level := range levels {
+		if level.Size.IsPositive() {
+			m[level.Price.String()] = level.Size
+		}
+	}
+	return m
+}
+
+func toLevels(levels []wsLevel) []executor.OrderBookLevel {
+	result := make([]executor.OrderBookLevel, 0, len(levels))
+	for _, level := range levels {
+		price, err := decimal.NewFromString(level.Price)
+		if err != nil {
+			continue
+		}
+		size, err := decimal.NewFromString(level.Size)
+		if err != nil {
+			continue
+		}
+		result = append(result, executor.OrderBookLevel{Price: price, Size: size})
+	}
+	return result
+}
+
+// snapshotTimestamp REST 快照时间戳。使用服务端时间，与推送时间戳比较时不受本地时钟偏差影响；
+// 缺失时返回0，接受其后的第一条增量
+func snapshotTimestamp(s string) int64 {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return ts
+}
+
+// parseTimestamp 解析毫秒时间戳，缺失时取当前时间
+func parseTimestamp(s string) int64 {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Now().UnixMilli()
+	}
+	return ts
+}
@@ -0,0 +1,212 @@
+package marketdata
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/pkg/logger"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const testToken = "token-yes"
+
+// step 一条推送消息，或 rest 非空时模拟重新同步取回的 REST 快照
+type step struct {
+	msg  string
+	rest *executor.OrderBook
+}
+
+func bookMsg(ts int64, bid, ask string) step {
+	return step{msg: fmt.Sprintf(
+		`{"event_type":"book","asset_id":%q,"market":"0xcond","timestamp":"%d","bids":[{"price":"0.40","size":"100"},{"price":%q,"size":"50"}],"asks":[{"price":%q,"size":"80"},{"price":"0.50","size":"100"}]}`,
+		testToken, ts, bid, ask)}
+}
+
+func changeMsg(ts int64, side, price, size, bestBid, bestAsk string) step {
+	return step{msg: fmt.Sprintf(
+		`{"event_type":"price_change","market":"0xcond","timestamp":"%d","price_changes":[{"asset_id":%q,"price":%q,"size":%q,"side":%q,"best_bid":%q,"best_ask":%q}]}`,
+		ts, testToken, price, size, side, bestBid, bestAsk)}
+}
+
+func restSnapshot(timestamp, bid, ask string) step {
+	return step{rest: &executor.OrderBook{
+		Market:    "0xcond",
+		AssetID:   testToken,
+		Timestamp: timestamp,
+		Bids:      []executor.OrderBookLevel{{Price: decimal.RequireFromString(bid), Size: decimal.NewFromInt(10)}},
+		Asks:      []executor.OrderBookLevel{{Price: decimal.RequireFromString(ask), Size: decimal.NewFromInt(10)}},
+	}}
+}
+
+func TestFeedOrderBook(t *testing.T) {
+	tests := []struct {
+		name       string
+		steps      []step
+		wantOK     bool
+		wantBid    string
+		wantAsk    string
+		wantResync []string
+	}{
+		{
+			name:    "快照建立订单簿",
+			steps:   []step{bookMsg(100, "0.41", "0.45")},
+			wantOK:  true,
+			wantBid: "0.41",
+			wantAsk: "0.45",
+		},
+		{
+			name: "增量更新最优价",
+			steps: []step{
+				bookMsg(100, "0.41", "0.45"),
+				changeMsg(200, "BUY", "0.43", "10", "0.43", "0.45"),
+				changeMsg(210, "SELL", "0.44", "5", "0.43", "0.44"),
+			},
+			wantOK:  true,
+			wantBid: "0.43",
+			wantAsk: "0.44",
+		},
+		{
+			name: "挂单量为0时删除价位",
+			steps: []step{
+				bookMsg(100, "0.41", "0.45"),
+				changeMsg(200, "BUY", "0.41", "0", "0.40", "0.45"),
+			},
+			wantOK:  true,
+			wantBid: "0.40",
+			wantAsk: "0.45",
+		},
+		{
+			name: "乱序增量丢弃",
+			steps: []step{
+				bookMsg(100, "0.41", "0.45"),
+				changeMsg(300, "BUY", "0.43", "10", "0.43", "0.45"),
+				changeMsg(200, "BUY", "0.44", "10", "0.44", "0.45"),
+			},
+			wantOK:  true,
+			wantBid: "0.43",
+			wantAsk: "0.45",
+		},
+		{
+			name: "早于已应用增量的快照丢弃",
+			steps: []step{
+				bookMsg(100, "0.41", "0.45"),
+				changeMsg(300, "SELL", "0.44", "10", "0.41", "0.44"),
+				bookMsg(200, "0.42", "0.46"),
+			},
+			wantOK:  true,
+			wantBid: "0.41",
+			wantAsk: "0.44",
+		},
+		{
+			name: "最优价不一致时请求重新同步",
+			steps: []step{
+				bookMsg(100, "0.41", "0.45"),
+				changeMsg(200, "BUY", "0.42", "5", "0.43", "0.45"),
+			},
+			wantResync: []string{testToken},
+		},
+		{
+			name: "重复不一致只排队一次",
+			steps: []step{
+				bookMsg(100, "0.41", "0.45"),
+				changeMsg(200, "BUY", "0.42", "5", "0.43", "0.45"),
+				changeMsg(210, "SELL", "0.44", "5", "0.41", "0.43"),
+			},
+			wantResync: []string{testToken},
+		},
+		{
+			name:       "无快照的增量请求重新同步",
+			steps:      []step{changeMsg(200, "BUY", "0.42", "5", "0.42", "0.45")},
+			wantResync: []string{testToken},
+		},
+		{
+			name: "重新同步快照恢复订单簿",
+			steps: []step{
+				bookMsg(100, "0.41", "0.45"),
+				changeMsg(200, "BUY", "0.42", "5", "0.43", "0.45"),
+				restSnapshot("250", "0.43", "0.45"),
+				changeMsg(240, "BUY", "0.44", "5", "0.44", "0.45"),
+				changeMsg(260, "SELL", "0.44", "5", "0.43", "0.44"),
+			},
+			wantOK:     true,
+			wantBid:    "0.43",
+			wantAsk:    "0.44",
+			wantResync: []string{testToken},
+		},
+		{
+			name: "缺少时间戳的快照接受其后的增量",
+			steps: []step{
+				changeMsg(200, "BUY", "0.42", "5", "0.42", "0.45"),
+				restSnapshot("", "0.41", "0.45"),
+				changeMsg(210, "BUY", "0.42", "5", "0.42", "0.45"),
+			},
+			wantOK:     true,
+			wantBid:    "0.42",
+			wantAsk:    "0.45",
+			wantResync: []string{testToken},
+		},
+		{
+			name: "数组消息逐条处理",
+			steps: []step{{msg: fmt.Sprintf(`[%s,%s]`,
+				bookMsg(100, "0.41", "0.45").msg,
+				changeMsg(200, "BUY", "0.42", "5", "0.42", "0.45").msg)}},
+			wantOK:  true,
+			wantBid: "0.42",
+			wantAsk: "0.45",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFeed(nil, nil, &logger.Logger{Logger: zap.NewNop()}, Config{})
+			f.connect()
+
+			var resync []string
+			for _, s := range tt.steps {
+				if s.rest != nil {
+					// 模拟 resyncLoop：取出排队的请求后应用 REST 快照
+					resync = append(resync, drainResync(f)...)
+					f.applySnapshot(testToken, s.rest.Market, s.rest.Bids, s.rest.Asks, snapshotTimestamp(s.rest.Timestamp))
+					f.mu.Lock()
+					delete(f.resyncing, testToken)
+					f.mu.Unlock()
+					continue
+				}
+				f.handleMessage([]byte(s.msg))
+			}
+			resync = append(resync, drainResync(f)...)
+
+			if !reflect.DeepEqual(resync, tt.wantResync) {
+				t.Errorf("resync requests = %v, want %v", resync, tt.wantResync)
+			}
+
+			bid, ask, ok := f.TopOfBook(testToken)
+			if ok != tt.wantOK {
+				t.Fatalf("TopOfBook() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !bid.Equal(decimal.RequireFromString(tt.wantBid)) || !ask.Equal(decimal.RequireFromString(tt.wantAsk)) {
+				t.Errorf("TopOfBook() = %s/%s, want %s/%s", bid, ask, tt.wantBid, tt.wantAsk)
+			}
+		})
+	}
+}
+
+// drainResync 取出已排队的重新同步请求
+func drainResync(f *Feed) []string {
+	var tokens []string
+	for {
+		select {
+		case tokenID := <-f.resyncCh:
+			tokens = append(tokens, tokenID)
+		default:
+			return tokens
+		}
+	}
+}
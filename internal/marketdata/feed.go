@@ -0,0 +1,400 @@
+// Package marketdata 实时行情。
+// Feed 订阅 CLOB market WebSocket 频道，为持仓及未终结意图涉及的结果代币维护内存订单簿；
+// 订单簿缺失、推送乱序或与推送的最优价不一致时，通过 REST 快照重新同步。
+// Feed 同时实现 risk.MarketDataProvider 与 nav.PriceSource，缓存未就绪时回退到 REST 查询。
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	maxBackoff   = time.Minute
+	updateBuffer = 1024
+)
+
+// Provider REST 行情接口（由 executor.PolymarketClient 实现）
+type Provider interface {
+	GetMarket(ctx context.Context, marketID string) (*executor.Market, error)
+	GetOrderBook(ctx context.Context, tokenID string) (*executor.OrderBook, error)
+}
+
+// Config 实时行情配置
+type Config struct {
+	URL             string        // CLOB market 频道地址
+	PingInterval    time.Duration // 心跳间隔，超过3个心跳周期无消息视为断线
+	RefreshInterval time.Duration // 从持仓与意图刷新订阅代币的间隔
+	MarketTTL       time.Duration // 市场信息缓存时长
+}
+
+// Feed 实时行情订阅与订单簿缓存
+type Feed struct {
+	rest   Provider
+	repo   repository.Repository
+	logger *logger.Logger
+	config Config
+
+	mu        sync.RWMutex
+	connected bool
+	books     map[string]*book
+	markets   map[string]cachedMarket
+	tracked   map[string]bool
+	resyncing map[string]bool
+
+	trackCh  chan []string
+	resyncCh chan string
+	updates  chan string
+}
+
+type cachedMarket struct {
+	market    *executor.Market
+	fetchedAt time.Time
+}
+
+// subscribeMessage 订阅请求：首次连接携带 type，连接后追加订阅携带 operation
+type subscribeMessage struct {
+	AssetIDs  []string `json:"assets_ids"`
+	Type      string   `json:"type,omitempty"`
+	Operation string   `json:"operation,omitempty"`
+}
+
+// NewFeed 创建实时行情
+func NewFeed(rest Provider, repo repository.Repository, logger *logger.Logger, config Config) *Feed {
+	if config.PingInterval <= 0 {
+		config.PingInterval = 10 * time.Second
+	}
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = 30 * time.Second
+	}
+	if config.MarketTTL <= 0 {
+		config.MarketTTL = 15 * time.Second
+	}
+	return &Feed{
+		rest:      rest,
+		repo:      repo,
+		logger:    logger,
+		config:    config,
+		books:     make(map[string]*book),
+		markets:   make(map[string]cachedMarket),
+		tracked:   make(map[string]bool),
+		resyncing: make(map[string]bool),
+		trackCh:   make(chan []string, 64),
+		resyncCh:  make(chan string, 256),
+		updates:   make(chan string, updateBuffer),
+	}
+}
+
+// Run 维持行情连接直至 ctx 取消，断线后指数退避重连
+func (f *Feed) Run(ctx context.Context) {
+	go f.resyncLoop(ctx)
+
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := f.session(ctx)
+		f.disconnect()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > maxBackoff {
+			backoff = time.Second
+		}
+		f.logger.Warn("行情连接断开，准备重连", zap.Duration("backoff", backoff), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < maxBackoff {
+			backoff *= 2
+		}
+	}
+}
+
+// session 建立一次连接：订阅全部跟踪代币，处理心跳与追加订阅，直到连接出错
+func (f *Feed) session(ctx context.Context) error {
+	f.refreshTokens(ctx)
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, f.config.URL, nil)
+	if err != nil {
+		return fmt.Errorf("连接行情服务失败: %w", err)
+	}
+	defer conn.Close()
+
+	tokens := f.trackedTokens()
+	if err := conn.WriteJSON(subscribeMessage{AssetIDs: tokens, Type: "market"}); err != nil {
+		return fmt.Errorf("订阅行情失败: %w", err)
+	}
+	f.connect()
+	f.logger.Info("行情已连接", zap.Int("tokens", len(tokens)))
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- f.readLoop(conn)
+	}()
+
+	ping := time.NewTicker(f.config.PingInterval)
+	defer ping.Stop()
+	refresh := time.NewTicker(f.config.RefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		var added []string
+		select {
+		case <-ctx.Done():
+			_ = conn.WriteMessage(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case <-ping.C:
+			if err := conn.WriteMessage(websocket.TextMessage, []byte("PING")); err != nil {
+				return fmt.Errorf("发送心跳失败: %w", err)
+			}
+			continue
+		case <-refresh.C:
+			added = f.refreshTokens(ctx)
+		case tokens := <-f.trackCh:
+			added = f.track(tokens)
+		}
+
+		if len(added) == 0 {
+			continue
+		}
+		if err := conn.WriteJSON(subscribeMessage{AssetIDs: added, Operation: "subscribe"}); err != nil {
+			return fmt.Errorf("追加订阅失败: %w", err)
+		}
+		f.logger.Debug("追加行情订阅", zap.Strings("tokens", added))
+	}
+}
+
+// readLoop 读取推送消息，超过3个心跳周期无任何消息（含 PONG）时返回超时错误
+func (f *Feed) readLoop(conn *websocket.Conn) error {
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(3 * f.config.PingInterval)); err != nil {
+			return err
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return fmt.Errorf("读取行情失败: %w", err)
+		}
+		f.handleMessage(data)
+	}
+}
+
+// refreshTokens 从持仓与未终结意图刷新跟踪代币，返回新增的代币
+func (f *Feed) refreshTokens(ctx context.Context) []string {
+	var tokens []string
+
+	positions, err := f.repo.GetUnresolvedPositions(ctx)
+	if err != nil {
+		f.logger.Error("获取持仓失败", zap.Error(err))
+	}
+	for _, pos := range positions {
+		tokens = append(tokens, pos.OutcomeID)
+	}
+
+	intentTokens, err := f.repo.GetOpenIntentOutcomes(ctx)
+	if err != nil {
+		f.logger.Error("获取交易意图失败", zap.Error(err))
+	}
+	tokens = append(tokens, intentTokens...)
+
+	return f.track(tokens)
+}
+
+// track 加入跟踪集合，返回此前未跟踪的代币
+func (f *Feed) track(tokens []string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var added []string
+	for _, id := range tokens {
+		if id != "" && !f.tracked[id] {
+			f.tracked[id] = true
+			added = append(added, id)
+		}
+	}
+	return added
+}
+
+func (f *Feed) trackedTokens() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	tokens := make([]string, 0, len(f.tracked))
+	for id := range f.tracked {
+		tokens = append(tokens, id)
+	}
+	return tokens
+}
+
+// requestTrack 请求订阅代币，由连接协程发送订阅消息
+func (f *Feed) requestTrack(tokenID string) {
+	f.mu.RLock()
+	tracked := f.tracked[tokenID]
+	f.mu.RUnlock()
+	if tracked {
+		return
+	}
+
+	select {
+	case f.trackCh <- []string{tokenID}:
+	default:
+	}
+}
+
+func (f *Feed) connect() {
+	f.mu.Lock()
+	f.connected = true
+	f.mu.Unlock()
+}
+
+// disconnect 断线后所有订单簿失效，重连后以服务端推送的快照重建
+func (f *Feed) disconnect() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.connected = false
+	for _, b := range f.books {
+		b.synced = false
+	}
+}
+
+// requestResync 请求通过 REST 快照重新同步订单簿，同一代币同时只排队一次
+func (f *Feed) requestResync(tokenID string) {
+	f.mu.Lock()
+	if f.resyncing[tokenID] {
+		f.mu.Unlock()
+		return
+	}
+	f.resyncing[tokenID] = true
+	f.mu.Unlock()
+
+	select {
+	case f.resyncCh <- tokenID:
+	default:
+		f.mu.Lock()
+		delete(f.resyncing, tokenID)
+		f.mu.Unlock()
+	}
+}
+
+// resyncLoop 处理订单簿重新同步请求
+func (f *Feed) resyncLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tokenID := <-f.resyncCh:
+			ob, err := f.rest.GetOrderBook(ctx, tokenID)
+			if err != nil {
+				f.logger.Error("同步订单簿快照失败",
+					zap.String("token_id", tokenID),
+					zap.Error(err))
+			} else {
+				f.applySnapshot(tokenID, ob.Market, ob.Bids, ob.Asks, snapshotTimestamp(ob.Timestamp))
+			}
+
+			f.mu.Lock()
+			delete(f.resyncing, tokenID)
+			f.mu.Unlock()
+		}
+	}
+}
+
+// notify 推送订单簿变动，消费不及时时丢弃
+func (f *Feed) notify(tokenID string) {
+	select {
+	case f.updates <- tokenID:
+	default:
+	}
+}
+
+// Updates 订单簿发生变动的代币ID
+func (f *Feed) Updates() <-chan string {
+	return f.updates
+}
+
+// GetOrderBook 优先返回已同步的缓存订单簿，否则回退 REST 查询并订阅该代币
+func (f *Feed) GetOrderBook(ctx context.Context, tokenID string) (*executor.OrderBook, error) {
+	f.mu.RLock()
+	b := f.books[tokenID]
+	if f.connected && b != nil && b.synced {
+		ob := b.orderBook(tokenID)
+		f.mu.RUnlock()
+		return ob, nil
+	}
+	f.mu.RUnlock()
+
+	f.requestTrack(tokenID)
+	return f.rest.GetOrderBook(ctx, tokenID)
+}
+
+// GetMarket 查询市场信息，结果按 MarketTTL 缓存
+func (f *Feed) GetMarket(ctx context.Context, marketID string) (*executor.Market, error) {
+	f.mu.RLock()
+	cached, ok := f.markets[marketID]
+	f.mu.RUnlock()
+	if ok && time.Since(cached.fetchedAt) < f.config.MarketTTL {
+		market := *cached.market
+		return &market, nil
+	}
+
+	market, err := f.rest.GetMarket(ctx, marketID)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	f.markets[marketID] = cachedMarket{market: market, fetchedAt: time.Now()}
+	f.mu.Unlock()
+
+	result := *market
+	return &result, nil
+}
+
+// TopOfBook 缓存中的最优买卖价，订单簿未同步时 ok 为 false
+func (f *Feed) TopOfBook(tokenID string) (bid, ask decimal.Decimal, ok bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	b := f.books[tokenID]
+	if !f.connected || b == nil || !b.synced {
+		return decimal.Zero, decimal.Zero, false
+	}
+	bid, ask = b.best()
+	return bid, ask, true
+}
+
+// Price 参考价：优先买卖中间价，单边盘口时取最新成交价
+func (f *Feed) Price(tokenID string) (decimal.Decimal, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	b := f.books[tokenID]
+	if !f.connected || b == nil || !b.synced {
+		return decimal.Zero, false
+	}
+	bid, ask := b.best()
+	if bid.IsPositive() && ask.IsPositive() {
+		return bid.Add(ask).Div(decimal.NewFromInt(2)), true
+	}
+	if b.lastPrice.IsPositive() {
+		return b.lastPrice, true
+	}
+	return decimal.Zero, false
+}
@@ -72,14 +72,18 @@ func GenerateToken(address, role, secret string, duration time.Duration) (string
 	return token.SignedString([]byte(secret))
 }
 
-// RoleGuard 用于特定角色的权限控制中间件
-func RoleGuard(requiredRole string) gin.HandlerFunc {
+// RoleGuard 用于特定角色的权限控制中间件，满足任一角色即可访问
+func RoleGuard(requiredRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("user_role")
-		if !exists || role.(string) != requiredRole {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: " + requiredRole + " role required"})
-			return
+		if exists {
+			for _, requiredRole := range requiredRoles {
+				if role.(string) == requiredRole {
+					c.Next()
+					return
+				}
+			}
 		}
-		c.Next()
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: " + strings.Join(requiredRoles, " or ") + " role required"})
 	}
 }
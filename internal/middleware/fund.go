@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"polyagent-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FundLookup 按ID查询基金，不存在时返回 nil
+type FundLookup interface {
+	GetFund(ctx context.Context, id uuid.UUID) (*models.Fund, error)
+}
+
+// FundOwnerGuard 校验调用者管理路径中的 :fundId 基金，需在 JWTMiddleware 之后使用
+// 基金不存在返回 404；调用者既不是该基金经理（ManagerAddress）也不是管理员时返回 403。
+// 校验通过后基金写入 Context（"fund"），后续 Controller 可直接使用
+func FundOwnerGuard(funds FundLookup) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fundID, err := uuid.Parse(c.Param("fundId"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "无效的基金ID"})
+			return
+		}
+
+		fund, err := funds.GetFund(c.Request.Context(), fundID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "获取基金失败"})
+			return
+		}
+		if fund == nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "基金不存在"})
+			return
+		}
+
+		address := c.GetString("user_address")
+		if c.GetString("user_role") != "ADMIN" &&
+			(fund.ManagerAddress == "" || !strings.EqualFold(fund.ManagerAddress, address)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission denied: not the manager of this fund"})
+			return
+		}
+
+		c.Set("fund", fund)
+		c.Next()
+	}
+}
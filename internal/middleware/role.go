@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 特权角色，仅能通过配置授予，登录签发的 Token 不携带
+const (
+	RoleAdmin      = "ADMIN"      // 管理员
+	RoleCompliance = "COMPLIANCE" // 合规复核
+	RoleInvestor   = "INVESTOR"   // 默认角色
+)
+
+// RoleGrants 按钱包地址（小写）授予的特权角色，来源于配置 auth.admins / auth.compliance
+type RoleGrants map[string]string
+
+// NewRoleGrants 由配置的管理员与合规地址创建角色授予表，同一地址同时出现时取管理员
+func NewRoleGrants(admins, compliance []string) RoleGrants {
+	grants := make(RoleGrants, len(admins)+len(compliance))
+	for _, address := range compliance {
+		if address = strings.ToLower(strings.TrimSpace(address)); address != "" {
+			grants[address] = RoleCompliance
+		}
+	}
+	for _, address := range admins {
+		if address = strings.ToLower(strings.TrimSpace(address)); address != "" {
+			grants[address] = RoleAdmin
+		}
+	}
+	return grants
+}
+
+// GrantRoles 按配置覆盖 JWTMiddleware 注入的角色：
+// 已授予地址使用配置角色；未授予地址的 Token 若声明特权角色则降为默认角色
+func GrantRoles(grants RoleGrants) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := strings.ToLower(c.GetString("user_address"))
+		if role, ok := grants[address]; ok && address != "" {
+			c.Set("user_role", role)
+		} else if role := c.GetString("user_role"); role == RoleAdmin || role == RoleCompliance {
+			c.Set("user_role", RoleInvestor)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 现金账户
+type CashAccount string
+
+const (
+	CashAccountVault     CashAccount = "VAULT"     // Vault 合约
+	CashAccountExecution CashAccount = "EXECUTION" // 执行钱包
+)
+
+// 现金流水类型
+type CashEntryKind string
+
+const (
+	CashEntryTrade   CashEntryKind = "TRADE"   // 成交：买入支出，卖出收回
+	CashEntryFee     CashEntryKind = "FEE"     // 费用支付
+	CashEntryDeposit CashEntryKind = "DEPOSIT" // 申购入金
+	CashEntryRedeem  CashEntryKind = "REDEEM"  // 赎回出金
+)
+
+// CashEntry 基金现金流水，只追加；基金现金余额为各账户流水之和
+type CashEntry struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	FundID    uuid.UUID       `gorm:"type:uuid;not null;index:idx_cash_entry_fund_time" json:"fund_id"`
+	Account   CashAccount     `gorm:"size:10;not null" json:"account"`
+	Kind      CashEntryKind   `gorm:"size:10;not null" json:"kind"`
+	Amount    decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"amount"` // 正数流入，负数流出
+	Reference *uuid.UUID      `gorm:"type:uuid" json:"reference,omitempty"`      // 关联的意图、申赎或费用流水ID
+	CreatedAt time.Time       `gorm:"index:idx_cash_entry_fund_time" json:"created_at"`
+}
+
+// CashSnapshot 链上 USDC 余额快照，与账本余额对账
+type CashSnapshot struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	FundID      uuid.UUID       `gorm:"type:uuid;not null;index:idx_cash_snapshot_fund_time" json:"fund_id"`
+	Account     CashAccount     `gorm:"size:10;not null" json:"account"`
+	Address     string          `gorm:"size:42;not null" json:"address"`
+	Balance     decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"balance"`      // 链上余额
+	BookBalance decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"book_balance"` // 账本余额
+	Drift       decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"drift"`        // 链上余额 - 账本余额
+	BlockNumber uint64          `json:"block_number"`
+	RecordedAt  time.Time       `gorm:"not null;index:idx_cash_snapshot_fund_time" json:"recorded_at"`
+}
+
+func (e *CashEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *CashSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
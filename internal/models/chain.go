@@ -0,0 +1,198 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 哈希链记录类型
+type ChainRecordType string
+
+const (
+	ChainRecordAudit     ChainRecordType = "AUDIT"      // 审计日志
+	ChainRecordRisk      ChainRecordType = "RISK_EVENT" // 风控事件
+	ChainRecordExecution ChainRecordType = "EXECUTION"  // 执行记录
+)
+
+// ChainRecord 纳入哈希链的记录
+type ChainRecord interface {
+	ChainRecordType() ChainRecordType
+	ChainRecordID() uuid.UUID
+	ChainFundID() uuid.UUID
+	// ChainContent 参与哈希的规范化内容，字段取值需与数据库往返后一致
+	ChainContent() string
+}
+
+// ChainEntry 审计哈希链条目，按基金串联、只追加
+type ChainEntry struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	FundID      uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_chain_fund_seq" json:"fund_id"`
+	Seq         int64           `gorm:"not null;uniqueIndex:idx_chain_fund_seq" json:"seq"` // 基金内序号，从1开始连续
+	RecordType  ChainRecordType `gorm:"size:20;not null" json:"record_type"`
+	RecordID    uuid.UUID       `gorm:"type:uuid;not null;index" json:"record_id"`
+	ContentHash string          `gorm:"size:64;not null" json:"content_hash"` // 记录内容哈希
+	PrevHash    string          `gorm:"size:64" json:"prev_hash"`             // 上一条目哈希，首条为空
+	Hash        string          `gorm:"size:64;not null" json:"hash"`         // 本条目哈希
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// ChainHeadSnapshot 链头导出快照，用于公开发布或锚定
+type ChainHeadSnapshot struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key" json:"id"`
+	FundID     uuid.UUID `gorm:"type:uuid;not null;index" json:"fund_id"`
+	Seq        int64     `gorm:"not null" json:"seq"`
+	Hash       string    `gorm:"size:64;not null" json:"hash"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// ExecutionRecord 交易执行记录，只追加
+type ExecutionRecord struct {
+	ID         uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	IntentID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"intent_id"`
+	FundID     uuid.UUID       `gorm:"type:uuid;not null;index" json:"fund_id"`
+	MarketID   string          `gorm:"size:100;not null" json:"market_id"`
+	OutcomeID  string          `gorm:"size:100;not null" json:"outcome_id"`
+	Side       TradeSide       `gorm:"size:10;not null" json:"side"`
+	Size       decimal.Decimal `gorm:"type:decimal(20,8)" json:"size"`        // 委托数量
+	FilledSize decimal.Decimal `gorm:"type:decimal(20,8)" json:"filled_size"` // 成交数量
+	AvgPrice   decimal.Decimal `gorm:"type:decimal(20,8)" json:"avg_price"`   // 成交均价
+	TxID       string          `gorm:"size:100" json:"tx_id"`
+	Status     IntentStatus    `gorm:"size:20;not null" json:"status"` // COMPLETED, FAILED
+	Reason     string          `gorm:"size:500" json:"reason,omitempty"`
+	ExecutedAt time.Time       `json:"executed_at"`
+}
+
+// ChainContentHash 记录内容哈希
+func ChainContentHash(r ChainRecord) string {
+	sum := sha256.Sum256([]byte(r.ChainContent()))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChainLinkHash 条目哈希：sha256(prev_hash|fund_id|seq|record_type|record_id|content_hash)
+func ChainLinkHash(prevHash string, fundID uuid.UUID, seq int64,
+	recordType ChainRecordType, recordID uuid.UUID, contentHash string) string {
+	data := prevHash + "|" + fundID.String() + "|" + strconv.FormatInt(seq, 10) + "|" +
+		string(recordType) + "|" + recordID.String() + "|" + contentHash
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// ChainTime 规范化时间：UTC、微秒精度（与 PostgreSQL 一致）
+func ChainTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// ChainDecimal 规范化金额：8位小数（与 decimal(20,8) 一致）
+func ChainDecimal(d decimal.Decimal) decimal.Decimal {
+	return d.Round(8)
+}
+
+// chainJSON 序列化规范化内容
+func chainJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func (l *AuditLog) ChainRecordType() ChainRecordType { return ChainRecordAudit }
+func (l *AuditLog) ChainRecordID() uuid.UUID         { return l.ID }
+func (l *AuditLog) ChainFundID() uuid.UUID           { return l.FundID }
+
+func (l *AuditLog) ChainContent() string {
+	var riskRuleID string
+	if l.RiskRuleID != nil {
+		riskRuleID = l.RiskRuleID.String()
+	}
+	return chainJSON([]string{
+		l.ID.String(),
+		l.FundID.String(),
+		l.IntentID.String(),
+		string(l.RuleType),
+		riskRuleID,
+		strconv.Itoa(l.RuleVersion),
+		l.Result,
+		l.Details,
+		l.Reviewer,
+		ChainTime(l.CheckedAt).Format(time.RFC3339Nano),
+	})
+}
+
+func (e *RiskEvent) ChainRecordType() ChainRecordType { return ChainRecordRisk }
+func (e *RiskEvent) ChainRecordID() uuid.UUID         { return e.ID }
+func (e *RiskEvent) ChainFundID() uuid.UUID           { return e.FundID }
+
+// ChainContent 不包含 IsHandled，处理状态允许更新
+func (e *RiskEvent) ChainContent() string {
+	return chainJSON([]string{
+		e.ID.String(),
+		e.FundID.String(),
+		string(e.RuleType),
+		e.Severity,
+		e.MarketID,
+		e.Description,
+		ChainTime(e.TriggeredAt).Format(time.RFC3339Nano),
+	})
+}
+
+func (r *ExecutionRecord) ChainRecordType() ChainRecordType { return ChainRecordExecution }
+func (r *ExecutionRecord) ChainRecordID() uuid.UUID         { return r.ID }
+func (r *ExecutionRecord) ChainFundID() uuid.UUID           { return r.FundID }
+
+func (r *ExecutionRecord) ChainContent() string {
+	return chainJSON([]string{
+		r.ID.String(),
+		r.FundID.String(),
+		r.IntentID.String(),
+		r.MarketID,
+		r.OutcomeID,
+		string(r.Side),
+		ChainDecimal(r.Size).String(),
+		ChainDecimal(r.FilledSize).String(),
+		ChainDecimal(r.AvgPrice).String(),
+		r.TxID,
+		string(r.Status),
+		r.Reason,
+		ChainTime(r.ExecutedAt).Format(time.RFC3339Nano),
+	})
+}
+
+func (l *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return nil
+}
+
+func (e *RiskEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+func (r *ExecutionRecord) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+func (c *ChainEntry) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+func (s *ChainHeadSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// 基金健康等级
+const (
+	HealthLevelHealthy  = "HEALTHY"  // 健康
+	HealthLevelWarning  = "WARNING"  // 需关注
+	HealthLevelCritical = "CRITICAL" // 高风险
+)
+
+// FundHealth 基金健康度缓存，由调度任务定时刷新，供基金经理看板查询
+type FundHealth struct {
+	FundID         uuid.UUID `gorm:"type:uuid;primary_key" json:"fund_id"`
+	ManagerAddress string    `gorm:"size:42;index" json:"manager_address"`
+
+	Score int    `json:"score"`                // 综合健康分 0-100，越高越健康
+	Level string `gorm:"size:10" json:"level"` // HEALTHY / WARNING / CRITICAL
+
+	// 规模与净值
+	AUM           decimal.Decimal `gorm:"type:decimal(20,8)" json:"aum"`
+	NavPerShare   decimal.Decimal `gorm:"type:decimal(20,8)" json:"nav_per_share"`
+	NavChange1D   decimal.Decimal `gorm:"type:decimal(20,8)" json:"nav_change_1d"` // 最近两次正式净值变动（小数）
+	InvestorCount int             `json:"investor_count"`
+	NetInvested   decimal.Decimal `gorm:"type:decimal(20,8)" json:"net_invested"` // 已结算申购 - 已结算赎回（USDC）
+
+	// 持仓与现金
+	OpenPositions int             `json:"open_positions"`
+	PositionValue decimal.Decimal `gorm:"type:decimal(20,8)" json:"position_value"`
+	VaultCash     decimal.Decimal `gorm:"type:decimal(20,8)" json:"vault_cash"`
+	ExecutionCash decimal.Decimal `gorm:"type:decimal(20,8)" json:"execution_cash"`
+	CashRatio     decimal.Decimal `gorm:"type:decimal(10,6)" json:"cash_ratio"` // 现金 / AUM
+
+	// 风控规则使用率：当前值 / 规则上限，取各规则最大值
+	MaxRuleUtilization decimal.Decimal `gorm:"type:decimal(10,6)" json:"max_rule_utilization"`
+	RuleUtilization    string          `gorm:"type:jsonb" json:"rule_utilization"` // 各规则使用率 (JSON)
+
+	// 风控事件（统计窗口内）
+	OpenRiskEvents     int    `json:"open_risk_events"`                     // 未处理事件数
+	CriticalRiskEvents int    `json:"critical_risk_events"`                 // 其中 CRITICAL 事件数
+	RecentRiskEvents   string `gorm:"type:jsonb" json:"recent_risk_events"` // 最近事件 (JSON)
+
+	// 交易意图漏斗（统计窗口内按创建时间）
+	PendingIntents   int64 `json:"pending_intents"` // 审计、复核与执行中
+	RejectedIntents  int64 `json:"rejected_intents"`
+	FailedIntents    int64 `json:"failed_intents"`
+	CompletedIntents int64 `json:"completed_intents"`
+
+	ComputedAt time.Time `json:"computed_at"`
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 费用类型
+type FeeType string
+
+const (
+	FeeTypeManagement  FeeType = "MANAGEMENT"  // 管理费
+	FeeTypePerformance FeeType = "PERFORMANCE" // 业绩报酬
+)
+
+// 费用流水类型
+type FeeKind string
+
+const (
+	FeeKindAccrual FeeKind = "ACCRUAL" // 计提
+	FeeKindPayment FeeKind = "PAYMENT" // 结晶支付
+)
+
+// 业绩报酬结晶周期
+const (
+	FeePeriodMonthly   = "MONTHLY"
+	FeePeriodQuarterly = "QUARTERLY"
+	FeePeriodAnnual    = "ANNUAL"
+)
+
+// 业绩报酬高水位口径
+const (
+	FeeHWMFund     = "FUND"     // 基金层面高水位，按日计提进净值
+	FeeHWMInvestor = "INVESTOR" // 投资人层面高水位，结晶时扣减投资人份额
+)
+
+// FeeEntry 费用流水
+// 计提金额为负表示业绩报酬计提回拨；按投资人高水位结晶时记录投资人及扣减的份额
+type FeeEntry struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	FundID      uuid.UUID       `gorm:"type:uuid;not null;index:idx_fee_entry_fund_time" json:"fund_id"`
+	Type        FeeType         `gorm:"size:20;not null" json:"type"`
+	Kind        FeeKind         `gorm:"size:10;not null" json:"kind"`
+	Investor    string          `gorm:"size:42" json:"investor,omitempty"`
+	Amount      decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"amount"` // 金额（USDC）
+	Shares      decimal.Decimal `gorm:"type:decimal(20,8)" json:"shares"`          // 扣减的投资人份额
+	NAV         decimal.Decimal `gorm:"type:decimal(20,8)" json:"nav"`             // 计提/结晶时单位净值
+	Basis       decimal.Decimal `gorm:"type:decimal(20,8)" json:"basis"`           // 计费基数：管理费为净资产，业绩报酬为超额收益
+	PeriodStart time.Time       `json:"period_start"`
+	PeriodEnd   time.Time       `json:"period_end"`
+	CreatedAt   time.Time       `gorm:"index:idx_fee_entry_fund_time" json:"created_at"`
+}
+
+// FeeTotal 按费用类型与流水类型汇总的金额
+type FeeTotal struct {
+	Type   FeeType         `json:"type"`
+	Kind   FeeKind         `json:"kind"`
+	Amount decimal.Decimal `json:"amount"`
+}
+
+func (e *FeeEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 申赎类型
+type InvestmentTxType string
+
+const (
+	InvestmentTxDeposit InvestmentTxType = "DEPOSIT" // 申购
+	InvestmentTxRedeem  InvestmentTxType = "REDEEM"  // 赎回
+)
+
+// 申赎状态
+type InvestmentTxStatus string
+
+const (
+	InvestmentTxPending  InvestmentTxStatus = "PENDING"  // 待结算
+	InvestmentTxSettled  InvestmentTxStatus = "SETTLED"  // 已结算
+	InvestmentTxCanceled InvestmentTxStatus = "CANCELED" // 已取消
+)
+
+// InvestmentTransaction 投资人申赎记录
+// 申购按链上入金交易提交，结算时以已确认的入金金额按单位净值发行份额；赎回按份额提交、结算时按单位净值计算应付金额
+type InvestmentTransaction struct {
+	ID          uuid.UUID          `gorm:"type:uuid;primary_key" json:"id"`
+	FundID      uuid.UUID          `gorm:"type:uuid;not null;index:idx_investment_tx_fund_investor" json:"fund_id"`
+	Investor    string             `gorm:"size:42;not null;index:idx_investment_tx_fund_investor" json:"investor"` // 投资人地址
+	Type        InvestmentTxType   `gorm:"size:10;not null" json:"type"`
+	Status      InvestmentTxStatus `gorm:"size:10;not null;index" json:"status"`
+	Amount      decimal.Decimal    `gorm:"type:decimal(20,8)" json:"amount"`       // 金额（USDC），赎回结算前为0
+	Shares      decimal.Decimal    `gorm:"type:decimal(20,8)" json:"shares"`       // 份额，申购结算前为0
+	ExecutedNAV decimal.Decimal    `gorm:"type:decimal(20,8)" json:"executed_nav"` // 结算单位净值
+	TxHash      string             `gorm:"size:66;index" json:"tx_hash,omitempty"` // 申购入金的链上交易哈希，结算时核对已确认的 Vault 入金事件
+	Note        string             `gorm:"size:500" json:"note,omitempty"`         // 取消原因等
+	SettledAt   *time.Time         `json:"settled_at,omitempty"`
+	CanceledAt  *time.Time         `json:"canceled_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+// ShareHolding 投资人持有份额
+type ShareHolding struct {
+	FundID        uuid.UUID       `gorm:"type:uuid;primaryKey" json:"fund_id"`
+	Investor      string          `gorm:"size:42;primaryKey" json:"investor"`
+	Shares        decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"shares"`
+	HighWaterMark decimal.Decimal `gorm:"type:decimal(20,8)" json:"high_water_mark"` // 投资人业绩报酬高水位（按份额加权的单位净值）
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+func (t *InvestmentTransaction) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// LeaderboardOptOut 投资人选择不出现在收益榜单中，记录存在即表示退出
+type LeaderboardOptOut struct {
+	Investor  string    `gorm:"size:42;primaryKey" json:"investor"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -20,6 +20,8 @@ const (
 	IntentStatusCompleted IntentStatus = "COMPLETED" // 执行完成
 	IntentStatusFailed    IntentStatus = "FAILED"    // 执行失败
 	IntentStatusCancelled IntentStatus = "CANCELLED" // 已取消
+
+	IntentStatusManualReview IntentStatus = "MANUAL_REVIEW" // 风险分数处于复核区间，待人工复核
 )
 
 // 交易方向
@@ -39,19 +41,79 @@ const (
 	RiskRuleTypePriceDeviation RiskRuleType = "PRICE_DEVIATION"  // 价格偏离
 	RiskRuleTypeConcentration  RiskRuleType = "CONCENTRATION"    // 集中度限制
 	RiskRuleTypeStopLoss       RiskRuleType = "STOP_LOSS"        // 止损线
+	RiskRuleTypeMinLiquidity   RiskRuleType = "MIN_LIQUIDITY"    // 最小流动性
+	RiskRuleTypeMaxSpread      RiskRuleType = "MAX_SPREAD"       // 最大买卖价差
+	RiskRuleTypeMaxSlippage    RiskRuleType = "MAX_SLIPPAGE"     // 最大滑点
+	RiskRuleTypeMarketStatus   RiskRuleType = "MARKET_STATUS"    // 市场状态与结算时间
+	RiskRuleTypeMaxDrawdown    RiskRuleType = "MAX_DRAWDOWN"     // 基金净值最大回撤
+	RiskRuleTypeTrailingStop   RiskRuleType = "TRAILING_STOP"    // 移动止损
+	RiskRuleTypeTakeProfit     RiskRuleType = "TAKE_PROFIT"      // 止盈
+	RiskRuleTypeTimeExit       RiskRuleType = "TIME_EXIT"        // 临近结算定时离场
+	RiskRuleTypeCustomExpr     RiskRuleType = "CUSTOM_EXPR"      // 自定义表达式
+	RiskRuleTypeKillSwitch     RiskRuleType = "KILL_SWITCH"      // 交易熔断开关（非规则，用于审计与风控事件）
+	RiskRuleTypeCashBalance    RiskRuleType = "CASH_BALANCE"     // 可用现金（非规则，买入意图始终检查）
+)
+
+// 持仓止损/离场触发状态
+type PositionTriggerState string
+
+const (
+	PositionTriggerArmed     PositionTriggerState = "ARMED"     // 监控中
+	PositionTriggerTriggered PositionTriggerState = "TRIGGERED" // 已触发，待平仓
+	PositionTriggerClosing   PositionTriggerState = "CLOSING"   // 平仓中
+	PositionTriggerClosed    PositionTriggerState = "CLOSED"    // 平仓完成
+	PositionTriggerSettled   PositionTriggerState = "SETTLED"   // 市场已结算，停止监控
+)
+
+// 熔断开关范围
+type KillSwitchScope string
+
+const (
+	KillSwitchScopeGlobal KillSwitchScope = "GLOBAL" // 全局
+	KillSwitchScopeFund   KillSwitchScope = "FUND"   // 单个基金
+	KillSwitchScopeMarket KillSwitchScope = "MARKET" // 单个市场
+)
+
+// 基金状态
+const (
+	FundStatusActive      = "ACTIVE"      // 正常运营
+	FundStatusLiquidating = "LIQUIDATING" // 触发回撤止损，清仓中
 )
 
 // Fund 基金
 type Fund struct {
-	ID              uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
-	Name            string          `gorm:"size:100;not null" json:"name"`
-	ManagerID       uuid.UUID       `gorm:"type:uuid;not null" json:"manager_id"`
-	TotalAUM        decimal.Decimal `gorm:"type:decimal(20,8)" json:"total_aum"`
-	DailyLossLimit  decimal.Decimal `gorm:"type:decimal(20,8)" json:"daily_loss_limit"`
-	StopLossPercent decimal.Decimal `gorm:"type:decimal(5,2)" json:"stop_loss_percent"` // 止损百分比
-	Status          string          `gorm:"size:20;default:'ACTIVE'" json:"status"`
-	CreatedAt       time.Time       `json:"created_at"`
-	UpdatedAt       time.Time       `json:"updated_at"`
+	ID               uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	Name             string          `gorm:"size:100;not null" json:"name"`
+	ManagerID        uuid.UUID       `gorm:"type:uuid;not null" json:"manager_id"`
+	ManagerAddress   string          `gorm:"size:42;index" json:"manager_address"` // 基金经理钱包地址（登录地址），用于经理看板
+	VaultAddress     string          `gorm:"size:42;index" json:"vault_address"`   // 链上 Vault 合约地址
+	ExecutionAddress string          `gorm:"size:42" json:"execution_address"`     // 执行钱包地址
+	TotalAUM         decimal.Decimal `gorm:"type:decimal(20,8)" json:"total_aum"`
+	DailyLossLimit   decimal.Decimal `gorm:"type:decimal(20,8)" json:"daily_loss_limit"`
+	StopLossPercent  decimal.Decimal `gorm:"type:decimal(5,2)" json:"stop_loss_percent"` // 止损百分比
+	StrategyConfig   string          `gorm:"type:jsonb" json:"strategy_config"`          // 策略配置 (JSON，包含白名单、滑点、止损等)
+	CurrentNAV       decimal.Decimal `gorm:"type:decimal(20,8)" json:"current_nav"`      // 单位净值
+	HighWaterMark    decimal.Decimal `gorm:"type:decimal(20,8)" json:"high_water_mark"`  // 单位净值历史高点
+	TotalShares      decimal.Decimal `gorm:"type:decimal(20,8)" json:"total_shares"`     // 已发行总份额
+	VaultCash        decimal.Decimal `gorm:"type:decimal(20,8)" json:"vault_cash"`       // Vault 中的 USDC 余额
+	ExecutionCash    decimal.Decimal `gorm:"type:decimal(20,8)" json:"execution_cash"`   // 执行钱包中的 USDC 余额
+	AccruedFees      decimal.Decimal `gorm:"type:decimal(20,8)" json:"accrued_fees"`     // 已计提未支付的费用（管理费 + 基金层面业绩报酬）
+
+	// 费用
+	ManagementFeeRate     decimal.Decimal `gorm:"type:decimal(10,6)" json:"management_fee_rate"`             // 年化管理费率（小数，如 0.02）
+	PerformanceFeeRate    decimal.Decimal `gorm:"type:decimal(10,6)" json:"performance_fee_rate"`            // 业绩报酬比例（小数，如 0.2）
+	PerformanceFeePeriod  string          `gorm:"size:10;default:'QUARTERLY'" json:"performance_fee_period"` // 结晶周期：MONTHLY / QUARTERLY / ANNUAL
+	PerformanceHWMMode    string          `gorm:"size:10;default:'FUND'" json:"performance_hwm_mode"`        // 高水位口径：FUND / INVESTOR
+	PerformanceHWM        decimal.Decimal `gorm:"type:decimal(20,8)" json:"performance_hwm"`                 // 基金层面业绩报酬高水位（单位净值）
+	AccruedPerformanceFee decimal.Decimal `gorm:"type:decimal(20,8)" json:"accrued_performance_fee"`         // 其中已计提未结晶的业绩报酬
+	FeesAccruedAt         *time.Time      `json:"fees_accrued_at,omitempty"`                                 // 费用计提截至时间
+	FeesCrystallizedAt    *time.Time      `json:"fees_crystallized_at,omitempty"`                            // 上次结晶时间
+
+	MinimumDeposit decimal.Decimal `gorm:"type:decimal(20,8)" json:"minimum_deposit"` // 最低申购金额（USDC）
+	MinimumRedeem  decimal.Decimal `gorm:"type:decimal(20,8)" json:"minimum_redeem"`  // 最低赎回金额（USDC）
+	Status         string          `gorm:"size:20;default:'ACTIVE'" json:"status"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
 }
 
 // TradeIntent 交易意图
@@ -68,6 +130,9 @@ type TradeIntent struct {
 	Status        IntentStatus    `gorm:"size:20;default:'PENDING'" json:"status"`
 	AuditResult   string          `gorm:"type:text" json:"audit_result,omitempty"`
 	RejectReason  string          `gorm:"size:500" json:"reject_reason,omitempty"`
+	ReviewDueAt   *time.Time      `json:"review_due_at,omitempty"`              // 人工复核截止时间
+	ReviewedBy    string          `gorm:"size:42" json:"reviewed_by,omitempty"` // 复核人地址
+	ReviewedAt    *time.Time      `json:"reviewed_at,omitempty"`
 	ExecutedTx    string          `gorm:"size:100" json:"executed_tx,omitempty"`
 	ExecutedPrice decimal.Decimal `gorm:"type:decimal(20,8)" json:"executed_price"`
 	ExecutedAt    *time.Time      `json:"executed_at,omitempty"`
@@ -78,27 +143,42 @@ type TradeIntent struct {
 
 // Position 持仓
 type Position struct {
-	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
-	FundID        uuid.UUID       `gorm:"type:uuid;not null;index" json:"fund_id"`
-	MarketID      string          `gorm:"size:100;not null" json:"market_id"`
-	OutcomeID     string          `gorm:"size:100;not null" json:"outcome_id"`
-	Size          decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"size"`
-	EntryPrice    decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"entry_price"`
-	CurrentPrice  decimal.Decimal `gorm:"type:decimal(20,8)" json:"current_price"`
-	UnrealizedPnL decimal.Decimal `gorm:"type:decimal(20,8)" json:"unrealized_pnl"`
-	LastUpdated   time.Time       `json:"last_updated"`
-	CreatedAt     time.Time       `json:"created_at"`
+	ID            uuid.UUID            `gorm:"type:uuid;primary_key" json:"id"`
+	FundID        uuid.UUID            `gorm:"type:uuid;not null;index" json:"fund_id"`
+	MarketID      string               `gorm:"size:100;not null" json:"market_id"`
+	OutcomeID     string               `gorm:"size:100;not null" json:"outcome_id"`
+	Size          decimal.Decimal      `gorm:"type:decimal(20,8);not null" json:"size"`
+	EntryPrice    decimal.Decimal      `gorm:"type:decimal(20,8);not null" json:"entry_price"`
+	CurrentPrice  decimal.Decimal      `gorm:"type:decimal(20,8)" json:"current_price"`
+	UnrealizedPnL decimal.Decimal      `gorm:"type:decimal(20,8)" json:"unrealized_pnl"`
+	PeakPrice     decimal.Decimal      `gorm:"type:decimal(20,8)" json:"peak_price"`         // 持仓期间最有利价格（移动止损用）
+	TriggerState  PositionTriggerState `gorm:"size:20;default:'ARMED'" json:"trigger_state"` // 止损/离场触发状态
+	TriggeredAt   *time.Time           `json:"triggered_at,omitempty"`                       // 最近一次触发或平仓尝试时间
+
+	// 市场结算
+	SettlementPrice decimal.Decimal `gorm:"type:decimal(20,8)" json:"settlement_price"` // 结算价：获胜结果为1，其余为0
+	RealizedPnL     decimal.Decimal `gorm:"type:decimal(20,8)" json:"realized_pnl"`
+	ResolvedAt      *time.Time      `gorm:"index" json:"resolved_at,omitempty"` // 为空表示市场未结算
+
+	LastUpdated time.Time `json:"last_updated"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
-// RiskRule 风控规则
+// RiskRule 风控规则（按版本存储，每次修改生成新版本）
 type RiskRule struct {
-	ID          uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
-	FundID      uuid.UUID    `gorm:"type:uuid;not null" json:"fund_id"`
-	RuleType    RiskRuleType `gorm:"size:30;not null" json:"rule_type"`
-	Params      string       `gorm:"type:jsonb" json:"params"` // JSON格式参数
-	IsActive    bool         `gorm:"default:true" json:"is_active"`
-	Description string       `gorm:"size:500" json:"description"`
-	CreatedAt   time.Time    `json:"created_at"`
+	ID            uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`                            // 版本ID
+	RuleID        uuid.UUID    `gorm:"type:uuid;uniqueIndex:idx_risk_rule_version" json:"rule_id"` // 规则ID，各版本相同
+	Version       int          `gorm:"default:1;uniqueIndex:idx_risk_rule_version" json:"version"`
+	FundID        uuid.UUID    `gorm:"type:uuid;not null" json:"fund_id"`
+	RuleType      RiskRuleType `gorm:"size:30;not null" json:"rule_type"`
+	Params        string       `gorm:"type:jsonb" json:"params"` // JSON格式参数
+	IsActive      bool         `gorm:"default:true" json:"is_active"`
+	AdminOnly     bool         `gorm:"default:false" json:"admin_only"` // 管理员规则，基金经理不可修改或停用
+	Description   string       `gorm:"size:500" json:"description"`
+	EffectiveFrom time.Time    `gorm:"index" json:"effective_from"`
+	EffectiveTo   *time.Time   `json:"effective_to,omitempty"` // 为空表示持续生效
+	CreatedBy     string       `gorm:"size:42" json:"created_by"`
+	CreatedAt     time.Time    `json:"created_at"`
 }
 
 // RiskEvent 风控事件
@@ -115,29 +195,78 @@ type RiskEvent struct {
 
 // AuditLog 审计日志
 type AuditLog struct {
-	ID        uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
-	IntentID  uuid.UUID    `gorm:"type:uuid;not null;index" json:"intent_id"`
-	RuleType  RiskRuleType `gorm:"size:30" json:"rule_type"`
-	Result    string       `gorm:"size:20;not null" json:"result"` // PASS, FAIL
-	Details   string       `gorm:"type:text" json:"details"`
-	CheckedAt time.Time    `json:"checked_at"`
+	ID          uuid.UUID    `gorm:"type:uuid;primary_key" json:"id"`
+	IntentID    uuid.UUID    `gorm:"type:uuid;not null;index" json:"intent_id"`
+	FundID      uuid.UUID    `gorm:"type:uuid;index" json:"fund_id"`
+	RuleType    RiskRuleType `gorm:"size:30" json:"rule_type"`
+	RiskRuleID  *uuid.UUID   `gorm:"type:uuid" json:"risk_rule_id,omitempty"` // 评估的规则版本ID
+	RuleVersion int          `json:"rule_version,omitempty"`
+	Result      string       `gorm:"size:20;not null" json:"result"` // PASS, FAIL；人工复核为 APPROVE, REJECT, EXPIRED
+	Details     string       `gorm:"type:text" json:"details"`
+	Reviewer    string       `gorm:"size:42" json:"reviewer,omitempty"` // 人工复核人地址
+	CheckedAt   time.Time    `json:"checked_at"`
+}
+
+// KillSwitch 交易熔断开关当前状态，每个范围一条
+type KillSwitch struct {
+	Scope         KillSwitchScope `gorm:"size:10;primaryKey" json:"scope"`
+	ScopeID       string          `gorm:"size:100;primaryKey" json:"scope_id"` // 基金ID或市场ID，全局为空
+	Active        bool            `gorm:"not null" json:"active"`
+	AllowStopLoss bool            `gorm:"not null" json:"allow_stop_loss"` // 熔断期间是否仍允许止损平仓
+	Reason        string          `gorm:"size:500" json:"reason"`
+	Operator      string          `gorm:"size:42" json:"operator"` // 操作人地址或 CLI 标识
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// KillSwitchToggle 熔断开关切换记录
+type KillSwitchToggle struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	Scope         KillSwitchScope `gorm:"size:10;not null;index:idx_kill_switch_toggle_scope" json:"scope"`
+	ScopeID       string          `gorm:"size:100;index:idx_kill_switch_toggle_scope" json:"scope_id"`
+	Active        bool            `gorm:"not null" json:"active"`
+	AllowStopLoss bool            `gorm:"not null" json:"allow_stop_loss"`
+	Reason        string          `gorm:"size:500" json:"reason"`
+	Operator      string          `gorm:"size:42" json:"operator"`
+	CreatedAt     time.Time       `json:"created_at"`
 }
 
 // MarketData 市场数据缓存表对应结构体
 type MarketData struct {
-	ID          string          `gorm:"primaryKey;type:varchar(100)" json:"market_id"`
-	Question    string          `gorm:"type:varchar(500)" json:"question"`
-	Description string          `gorm:"type:text" json:"description"`
-	EndDate     time.Time       `gorm:"column:end_date" json:"end_date"`
-	Active      bool            `gorm:"default:true" json:"active"`
-	Closed      bool            `gorm:"default:false" json:"closed"`
-	BestBid     decimal.Decimal `gorm:"type:decimal(20,8)" json:"best_bid"`
-	BestAsk     decimal.Decimal `gorm:"type:decimal(20,8)" json:"best_ask"`
-	LastPrice   decimal.Decimal `gorm:"type:decimal(20,8)" json:"last_price"`
-	Volume      decimal.Decimal `gorm:"type:decimal(20,8)" json:"volume"`
-	Liquidity   decimal.Decimal `gorm:"type:decimal(20,8)" json:"liquidity"`
-	UpdatedAt   time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
-	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	ID             string          `gorm:"primaryKey;type:varchar(100)" json:"market_id"` // 条件ID
+	Question       string          `gorm:"type:varchar(500)" json:"question"`
+	Description    string          `gorm:"type:text" json:"description"`
+	Slug           string          `gorm:"size:255" json:"slug"`
+	EventID        string          `gorm:"size:100;index" json:"event_id"`
+	Category       string          `gorm:"size:100;index" json:"category"`
+	Tags           string          `gorm:"type:jsonb;default:'[]'" json:"tags"` // 标签（JSON数组）
+	EndDate        time.Time       `gorm:"column:end_date" json:"end_date"`
+	Active         bool            `gorm:"default:true" json:"active"`
+	Closed         bool            `gorm:"default:false" json:"closed"`
+	NegRisk        bool            `gorm:"default:false" json:"neg_risk"` // 互斥多结果市场，下单需走 NegRisk 合约
+	TickSize       decimal.Decimal `gorm:"type:decimal(10,6)" json:"tick_size"`
+	MinOrderSize   decimal.Decimal `gorm:"type:decimal(20,8)" json:"min_order_size"`
+	Resolved       bool            `gorm:"default:false" json:"resolved"`
+	WinningOutcome string          `gorm:"size:100" json:"winning_outcome,omitempty"`
+	ResolvedAt     *time.Time      `json:"resolved_at,omitempty"`
+	BestBid        decimal.Decimal `gorm:"type:decimal(20,8)" json:"best_bid"`
+	BestAsk        decimal.Decimal `gorm:"type:decimal(20,8)" json:"best_ask"`
+	LastPrice      decimal.Decimal `gorm:"type:decimal(20,8)" json:"last_price"`
+	Volume         decimal.Decimal `gorm:"type:decimal(20,8)" json:"volume"`
+	Liquidity      decimal.Decimal `gorm:"type:decimal(20,8)" json:"liquidity"`
+	Outcomes       []MarketOutcome `gorm:"foreignKey:MarketID" json:"outcomes,omitempty"`
+	SyncedAt       *time.Time      `gorm:"index" json:"synced_at,omitempty"` // 最近一次目录同步时间
+	UpdatedAt      time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// MarketOutcome 市场结果及其条件代币，TokenID 即持仓与意图中的 OutcomeID
+type MarketOutcome struct {
+	TokenID   string          `gorm:"primaryKey;size:100" json:"token_id"`
+	MarketID  string          `gorm:"size:100;not null;index" json:"market_id"`
+	Name      string          `gorm:"size:100" json:"name"`
+	Index     int             `json:"index"`
+	Price     decimal.Decimal `gorm:"type:decimal(20,8)" json:"price"`
+	UpdatedAt time.Time       `json:"updated_at"`
 }
 
 // BeforeCreate GORM钩子
@@ -155,9 +284,26 @@ func (t *TradeIntent) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+func (r *RiskRule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	if r.RuleID == uuid.Nil {
+		r.RuleID = r.ID
+	}
+	return nil
+}
+
 func (p *Position) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == uuid.Nil {
 		p.ID = uuid.New()
 	}
 	return nil
 }
+
+func (k *KillSwitchToggle) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
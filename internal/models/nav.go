@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 净值类型
+type NavKind string
+
+const (
+	NavKindOfficial NavKind = "OFFICIAL" // 每日结算的正式净值，用于申赎定价
+	NavKindEstimate NavKind = "ESTIMATE" // 盘中估算净值，仅供展示
+)
+
+// NavHistory 基金净值历史
+type NavHistory struct {
+	ID            uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	FundID        uuid.UUID       `gorm:"type:uuid;not null;index:idx_nav_history_fund_time" json:"fund_id"`
+	Kind          NavKind         `gorm:"size:10;not null;index:idx_nav_history_fund_time" json:"kind"`
+	NavPerShare   decimal.Decimal `gorm:"type:decimal(20,8);not null" json:"nav_per_share"`
+	TotalAUM      decimal.Decimal `gorm:"type:decimal(20,8)" json:"total_aum"`      // 扣除费用后的净资产
+	PositionValue decimal.Decimal `gorm:"type:decimal(20,8)" json:"position_value"` // 持仓市值
+	VaultCash     decimal.Decimal `gorm:"type:decimal(20,8)" json:"vault_cash"`
+	ExecutionCash decimal.Decimal `gorm:"type:decimal(20,8)" json:"execution_cash"`
+	AccruedFees   decimal.Decimal `gorm:"type:decimal(20,8)" json:"accrued_fees"`
+	TotalShares   decimal.Decimal `gorm:"type:decimal(20,8)" json:"total_shares"`
+	Mark          string          `gorm:"size:10" json:"mark"` // 持仓估值方式：MID / BID / LAST
+	RecordedAt    time.Time       `gorm:"not null;index:idx_nav_history_fund_time" json:"recorded_at"`
+}
+
+func (h *NavHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	return nil
+}
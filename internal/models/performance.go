@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// FundPerformance 基金业绩指标缓存，每次净值结算后重新计算
+// 收益率、回撤等比例均为原始小数（如 0.123 表示 12.3%）
+type FundPerformance struct {
+	FundID      uuid.UUID       `gorm:"type:uuid;primary_key" json:"fund_id"`
+	NavPerShare decimal.Decimal `gorm:"type:decimal(20,8)" json:"nav_per_share"` // 最新正式单位净值
+	AsOf        *time.Time      `json:"as_of,omitempty"`                         // 最新正式净值时间，尚无净值时为空
+
+	// 区间收益率，基金成立不足区间长度时按成立以来计算
+	Return1D        decimal.Decimal `gorm:"type:decimal(20,8)" json:"return_1d"`
+	Return7D        decimal.Decimal `gorm:"type:decimal(20,8)" json:"return_7d"`
+	Return30D       decimal.Decimal `gorm:"type:decimal(20,8)" json:"return_30d"`
+	ReturnYTD       decimal.Decimal `gorm:"type:decimal(20,8)" json:"return_ytd"`
+	ReturnInception decimal.Decimal `gorm:"type:decimal(20,8)" json:"return_inception"`
+
+	// 回撤（负数或0）
+	MaxDrawdown         decimal.Decimal `gorm:"type:decimal(20,8)" json:"max_drawdown"`
+	MaxDrawdownDays     int             `json:"max_drawdown_days"`                // 最大回撤持续天数：自前高至恢复前高，未恢复时截至最新净值
+	MaxDrawdownPeakAt   *time.Time      `json:"max_drawdown_peak_at,omitempty"`   // 最大回撤前高时间
+	MaxDrawdownTroughAt *time.Time      `json:"max_drawdown_trough_at,omitempty"` // 最大回撤谷底时间
+	CurrentDrawdown     decimal.Decimal `gorm:"type:decimal(20,8)" json:"current_drawdown"`
+
+	// 风险调整收益，按日收益率年化（365天）
+	Volatility decimal.Decimal `gorm:"type:decimal(20,8)" json:"volatility"`
+	Sharpe     decimal.Decimal `gorm:"type:decimal(20,8)" json:"sharpe"`
+	Sortino    decimal.Decimal `gorm:"type:decimal(20,8)" json:"sortino"`
+
+	// 逐笔交易统计：卖出成交与市场结算各计一笔平仓
+	TradeCount int             `json:"trade_count"`
+	WinCount   int             `json:"win_count"`
+	HitRate    decimal.Decimal `gorm:"type:decimal(10,6)" json:"hit_rate"`
+	AvgWin     decimal.Decimal `gorm:"type:decimal(20,8)" json:"avg_win"`  // 盈利交易平均盈利（USDC）
+	AvgLoss    decimal.Decimal `gorm:"type:decimal(20,8)" json:"avg_loss"` // 亏损交易平均亏损（USDC，负数）
+
+	ComputedAt time.Time `json:"computed_at"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// K线周期
+type CandleInterval string
+
+const (
+	CandleInterval1m CandleInterval = "1m"
+	CandleInterval1h CandleInterval = "1h"
+	CandleInterval1d CandleInterval = "1d"
+)
+
+// CandleIntervals 价格采样汇总的全部K线周期
+var CandleIntervals = []CandleInterval{CandleInterval1m, CandleInterval1h, CandleInterval1d}
+
+// Duration K线周期时长，未知周期返回0
+func (i CandleInterval) Duration() time.Duration {
+	switch i {
+	case CandleInterval1m:
+		return time.Minute
+	case CandleInterval1h:
+		return time.Hour
+	case CandleInterval1d:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// PriceSample 结果代币价格采样
+type PriceSample struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primary_key" json:"id"`
+	TokenID   string          `gorm:"size:100;not null;index:idx_price_sample_token_time" json:"token_id"`
+	MarketID  string          `gorm:"size:100;not null" json:"market_id"`
+	Mid       decimal.Decimal `gorm:"type:decimal(20,8)" json:"mid"`    // 买卖中间价
+	Last      decimal.Decimal `gorm:"type:decimal(20,8)" json:"last"`   // 最新成交价
+	Volume    decimal.Decimal `gorm:"type:decimal(20,8)" json:"volume"` // 所属市场累计成交量
+	SampledAt time.Time       `gorm:"not null;index:idx_price_sample_token_time" json:"sampled_at"`
+}
+
+// Candle 结果代币K线，由价格采样按周期汇总
+type Candle struct {
+	TokenID   string          `gorm:"primaryKey;size:100" json:"token_id"`
+	Interval  CandleInterval  `gorm:"primaryKey;column:period;size:5" json:"interval"`
+	OpenTime  time.Time       `gorm:"primaryKey" json:"open_time"`
+	MarketID  string          `gorm:"size:100;not null;index" json:"market_id"`
+	Open      decimal.Decimal `gorm:"type:decimal(20,8)" json:"open"`
+	High      decimal.Decimal `gorm:"type:decimal(20,8)" json:"high"`
+	Low       decimal.Decimal `gorm:"type:decimal(20,8)" json:"low"`
+	Close     decimal.Decimal `gorm:"type:decimal(20,8)" json:"close"`
+	Volume    decimal.Decimal `gorm:"type:decimal(20,8)" json:"volume"` // 所属市场在本周期内的成交量增量
+	Samples   int             `json:"samples"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (s *PriceSample) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 条件代币兑付任务状态
+type RedemptionStatus string
+
+const (
+	RedemptionPending   RedemptionStatus = "PENDING"   // 待提交兑付交易
+	RedemptionSubmitted RedemptionStatus = "SUBMITTED" // 已提交，等待上链确认
+	RedemptionCompleted RedemptionStatus = "COMPLETED" // 已兑付到执行钱包
+	RedemptionFailed    RedemptionStatus = "FAILED"
+)
+
+// RedemptionTask 市场结算后，执行钱包持有的获胜条件代币兑付（CTF redeemPositions）任务。
+// 每个基金每个市场一条，兑付时一并销毁该市场的失败结果代币
+type RedemptionTask struct {
+	ID             uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	FundID         uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_redemption_fund_market" json:"fund_id"`
+	MarketID       string           `gorm:"size:100;not null;uniqueIndex:idx_redemption_fund_market" json:"market_id"` // 条件ID
+	WinningOutcome string           `gorm:"size:100;not null" json:"winning_outcome"`
+	Size           decimal.Decimal  `gorm:"type:decimal(20,8);not null" json:"size"`   // 获胜结果代币数量
+	Payout         decimal.Decimal  `gorm:"type:decimal(20,8);not null" json:"payout"` // 预计兑付金额（USDC）
+	Address        string           `gorm:"size:42" json:"address"`                    // 执行钱包地址
+	Status         RedemptionStatus `gorm:"size:20;not null;index" json:"status"`
+	TxHash         string           `gorm:"size:66" json:"tx_hash,omitempty"`
+	Error          string           `gorm:"type:text" json:"error,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+}
+
+func (r *RedemptionTask) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 链上 Vault 事件类型
+type VaultEventType string
+
+const (
+	VaultEventDeposit  VaultEventType = "DEPOSIT"  // 申购入金（ERC-4626 Deposit）
+	VaultEventRedeem   VaultEventType = "REDEEM"   // 赎回出金（ERC-4626 Withdraw）
+	VaultEventTransfer VaultEventType = "TRANSFER" // 投资人之间的份额转让
+)
+
+// 链上 Vault 事件状态
+type VaultEventStatus string
+
+const (
+	VaultEventConfirmed VaultEventStatus = "CONFIRMED" // 已达到确认深度
+	VaultEventOrphaned  VaultEventStatus = "ORPHANED"  // 所在区块被重组移出主链
+)
+
+// VaultEvent 基金 Vault 合约的链上事件，以交易哈希与日志序号唯一标识
+type VaultEvent struct {
+	ID           uuid.UUID        `gorm:"type:uuid;primary_key" json:"id"`
+	FundID       uuid.UUID        `gorm:"type:uuid;not null;index:idx_vault_event_fund_block" json:"fund_id"`
+	ChainID      int64            `gorm:"not null" json:"chain_id"`
+	BlockNumber  uint64           `gorm:"not null;index:idx_vault_event_fund_block" json:"block_number"`
+	BlockHash    string           `gorm:"size:66;not null" json:"block_hash"`
+	BlockTime    time.Time        `json:"block_time"`
+	TxHash       string           `gorm:"size:66;not null;uniqueIndex:idx_vault_event_tx_log" json:"tx_hash"`
+	LogIndex     uint64           `gorm:"not null;uniqueIndex:idx_vault_event_tx_log" json:"log_index"`
+	Type         VaultEventType   `gorm:"size:10;not null" json:"type"`
+	Status       VaultEventStatus `gorm:"size:10;not null;index" json:"status"`
+	Investor     string           `gorm:"size:42;index" json:"investor"`    // 份额持有人（转让时为转出方）
+	Counterparty string           `gorm:"size:42" json:"counterparty"`      // 转让接收方 / 赎回资金接收方
+	Amount       decimal.Decimal  `gorm:"type:decimal(20,8)" json:"amount"` // 资产金额（USDC），转让时为0
+	Shares       decimal.Decimal  `gorm:"type:decimal(20,8)" json:"shares"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UpdatedAt    time.Time        `json:"updated_at"`
+}
+
+// VaultCheckpoint 基金 Vault 事件索引进度
+type VaultCheckpoint struct {
+	FundID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"fund_id"`
+	VaultAddress string    `gorm:"size:42;not null" json:"vault_address"`
+	BlockNumber  uint64    `gorm:"not null" json:"block_number"` // 已索引到的区块（含）
+	BlockHash    string    `gorm:"size:66" json:"block_hash"`    // 该区块哈希，用于检测重组
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (e *VaultEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
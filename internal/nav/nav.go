@@ -0,0 +1,259 @@
+// Package nav 基金净值计算。
+// 单位净值 = (持仓市值 + Vault 现金 + 执行钱包现金 - 应计费用) / 已发行总份额。
+// 每日结算产生正式净值（用于申赎定价），盘中按需产生估算净值（仅供展示）。
+package nav
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// 单位净值精度
+const precision = 8
+
+// Mark 持仓估值方式
+type Mark string
+
+const (
+	MarkMid  Mark = "MID"  // 买一卖一中间价
+	MarkBid  Mark = "BID"  // 可变现价格：多头取买一，空头取卖一
+	MarkLast Mark = "LAST" // 最新成交价
+
+	MarkSettlement Mark = "SETTLEMENT" // 市场已结算，按结算价（仅用于单个持仓）
+)
+
+// ParseMark 解析估值方式，为空时默认中间价
+func ParseMark(s string) (Mark, error) {
+	switch mark := Mark(strings.ToUpper(s)); mark {
+	case "":
+		return MarkMid, nil
+	case MarkMid, MarkBid, MarkLast:
+		return mark, nil
+	default:
+		return "", fmt.Errorf("unsupported NAV mark: %s", s)
+	}
+}
+
+// PriceSource 订单簿数据源
+type PriceSource interface {
+	GetOrderBook(ctx context.Context, tokenID string) (*executor.OrderBook, error)
+}
+
+// CashSource 基金现金余额来源
+type CashSource interface {
+	CashBalances(ctx context.Context, fund *models.Fund) (vault, execution decimal.Decimal, err error)
+}
+
+// bookCash 使用账本记录的现金余额
+type bookCash struct{}
+
+func (bookCash) CashBalances(ctx context.Context, fund *models.Fund) (decimal.Decimal, decimal.Decimal, error) {
+	return fund.VaultCash, fund.ExecutionCash, nil
+}
+
+// PositionMark 单个持仓估值
+type PositionMark struct {
+	MarketID  string          `json:"market_id"`
+	OutcomeID string          `json:"outcome_id"`
+	Size      decimal.Decimal `json:"size"`
+	Price     decimal.Decimal `json:"price"`
+	Value     decimal.Decimal `json:"value"`
+	Mark      Mark            `json:"mark"` // 实际使用的估值方式，订单簿缺失时回退为 LAST
+}
+
+// Valuation 基金估值结果
+type Valuation struct {
+	Kind          models.NavKind  `json:"kind"`
+	Mark          Mark            `json:"mark"`
+	PositionValue decimal.Decimal `json:"position_value"`
+	VaultCash     decimal.Decimal `json:"vault_cash"`
+	ExecutionCash decimal.Decimal `json:"execution_cash"`
+	AccruedFees   decimal.Decimal `json:"accrued_fees"`
+	NetAssets     decimal.Decimal `json:"net_assets"`
+	TotalShares   decimal.Decimal `json:"total_shares"`
+	NavPerShare   decimal.Decimal `json:"nav_per_share"`
+	Positions     []PositionMark  `json:"positions"`
+	ValuedAt      time.Time       `json:"valued_at"`
+}
+
+// Engine 净值计算引擎
+type Engine struct {
+	repo   repository.Repository
+	prices PriceSource
+	cash   CashSource
+	mark   Mark
+	logger *logger.Logger
+}
+
+// NewEngine 创建净值计算引擎，默认使用账本现金余额
+func NewEngine(repo repository.Repository, prices PriceSource, mark Mark, logger *logger.Logger) *Engine {
+	return &Engine{
+		repo:   repo,
+		prices: prices,
+		cash:   bookCash{},
+		mark:   mark,
+		logger: logger,
+	}
+}
+
+// SetCashSource 设置现金余额来源（如链上余额）
+func (e *Engine) SetCashSource(cash CashSource) {
+	e.cash = cash
+}
+
+// Strike 计算并记录正式净值，更新基金单位净值与规模
+func (e *Engine) Strike(ctx context.Context, fund *models.Fund) (*Valuation, error) {
+	valuation, err := e.record(ctx, fund, models.NavKindOfficial)
+	if err != nil {
+		return nil, err
+	}
+
+	fund.CurrentNAV = valuation.NavPerShare
+	fund.TotalAUM = valuation.NetAssets
+
+	e.logger.Info("基金净值结算完成",
+		zap.String("fund_id", fund.ID.String()),
+		zap.String("nav", valuation.NavPerShare.String()),
+		zap.String("net_assets", valuation.NetAssets.String()),
+		zap.String("total_shares", valuation.TotalShares.String()))
+
+	return valuation, nil
+}
+
+// Estimate 计算并记录盘中估算净值，不影响申赎定价
+func (e *Engine) Estimate(ctx context.Context, fund *models.Fund) (*Valuation, error) {
+	return e.record(ctx, fund, models.NavKindEstimate)
+}
+
+// Compute 计算基金估值，不落库
+func (e *Engine) Compute(ctx context.Context, fund *models.Fund) (*Valuation, error) {
+	positions, err := e.repo.GetFundPositions(ctx, fund.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金持仓失败: %w", err)
+	}
+
+	vault, execution, err := e.cash.CashBalances(ctx, fund)
+	if err != nil {
+		return nil, fmt.Errorf("获取现金余额失败: %w", err)
+	}
+
+	valuation := &Valuation{
+		Mark:          e.mark,
+		VaultCash:     vault,
+		ExecutionCash: execution,
+		AccruedFees:   fund.AccruedFees,
+		TotalShares:   fund.TotalShares,
+		Positions:     make([]PositionMark, 0, len(positions)),
+		ValuedAt:      time.Now(),
+	}
+
+	for _, pos := range positions {
+		if pos.Size.IsZero() {
+			continue
+		}
+		price, mark := e.markPrice(ctx, pos)
+		value := pos.Size.Mul(price)
+		valuation.PositionValue = valuation.PositionValue.Add(value)
+		valuation.Positions = append(valuation.Positions, PositionMark{
+			MarketID:  pos.MarketID,
+			OutcomeID: pos.OutcomeID,
+			Size:      pos.Size,
+			Price:     price,
+			Value:     value,
+			Mark:      mark,
+		})
+	}
+
+	valuation.NetAssets = valuation.PositionValue.Add(vault).Add(execution).Sub(fund.AccruedFees)
+
+	// 尚未发行份额时沿用上次净值，新基金按 1 计
+	switch {
+	case fund.TotalShares.IsPositive():
+		valuation.NavPerShare = valuation.NetAssets.Div(fund.TotalShares).Round(precision)
+	case fund.CurrentNAV.IsPositive():
+		valuation.NavPerShare = fund.CurrentNAV
+	default:
+		valuation.NavPerShare = decimal.NewFromInt(1)
+	}
+
+	if valuation.NetAssets.IsNegative() {
+		e.logger.Warn("基金净资产为负",
+			zap.String("fund_id", fund.ID.String()),
+			zap.String("net_assets", valuation.NetAssets.String()))
+	}
+
+	return valuation, nil
+}
+
+// record 计算并写入净值历史
+func (e *Engine) record(ctx context.Context, fund *models.Fund, kind models.NavKind) (*Valuation, error) {
+	valuation, err := e.Compute(ctx, fund)
+	if err != nil {
+		return nil, err
+	}
+	valuation.Kind = kind
+
+	history := &models.NavHistory{
+		FundID:        fund.ID,
+		Kind:          kind,
+		NavPerShare:   valuation.NavPerShare,
+		TotalAUM:      valuation.NetAssets,
+		PositionValue: valuation.PositionValue,
+		VaultCash:     valuation.VaultCash,
+		ExecutionCash: valuation.ExecutionCash,
+		AccruedFees:   valuation.AccruedFees,
+		TotalShares:   valuation.TotalShares,
+		Mark:          string(valuation.Mark),
+		RecordedAt:    valuation.ValuedAt,
+	}
+	if err := e.repo.SaveFundValuation(ctx, history); err != nil {
+		return nil, fmt.Errorf("保存净值记录失败: %w", err)
+	}
+	return valuation, nil
+}
+
+// markPrice 按估值方式确定持仓价格，订单簿不可用时回退为最新成交价；已结算市场的持仓按结算价
+func (e *Engine) markPrice(ctx context.Context, pos models.Position) (decimal.Decimal, Mark) {
+	if pos.ResolvedAt != nil {
+		return pos.SettlementPrice, MarkSettlement
+	}
+	if e.mark == MarkLast || e.prices == nil {
+		return pos.CurrentPrice, MarkLast
+	}
+
+	book, err := e.prices.GetOrderBook(ctx, pos.OutcomeID)
+	if err != nil {
+		e.logger.Warn("获取订单簿失败，按最新成交价估值",
+			zap.String("market_id", pos.MarketID),
+			zap.String("outcome_id", pos.OutcomeID),
+			zap.Error(err))
+		return pos.CurrentPrice, MarkLast
+	}
+
+	bid, ask := book.BestBid(), book.BestAsk()
+	switch e.mark {
+	case MarkBid:
+		price := bid
+		if pos.Size.IsNegative() {
+			price = ask
+		}
+		if price.IsPositive() {
+			return price, MarkBid
+		}
+	default:
+		if bid.IsPositive() && ask.IsPositive() {
+			return bid.Add(ask).Div(decimal.NewFromInt(2)), MarkMid
+		}
+	}
+	return pos.CurrentPrice, MarkLast
+}
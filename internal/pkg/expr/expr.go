@@ -0,0 +1,337 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// Env 表达式求值环境，变量值支持 decimal.Decimal、string、bool 及 []interface{}
+type Env map[string]interface{}
+
+// Expr 已编译的表达式
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile 解析表达式，语法错误时返回带位置的错误信息
+func Compile(src string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, fmt.Errorf("expression is empty")
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("position %d: unexpected %s", tok.pos, describe(tok))
+	}
+
+	return &Expr{src: src, root: root}, nil
+}
+
+// String 返回表达式源码
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Identifiers 返回表达式引用的全部变量名（去重排序）
+func (e *Expr) Identifiers() []string {
+	seen := make(map[string]bool)
+	collectIdents(e.root, seen)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eval 在给定环境中求值
+func (e *Expr) Eval(env Env) (interface{}, error) {
+	return e.root.eval(env)
+}
+
+// EvalBool 求值并要求结果为布尔值
+func (e *Expr) EvalBool(env Env) (bool, error) {
+	v, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression must evaluate to a boolean, got %s", typeName(v))
+	}
+	return b, nil
+}
+
+// node 语法树节点
+type node interface {
+	eval(env Env) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(Env) (interface{}, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+	pos  int
+}
+
+func (n *identNode) eval(env Env) (interface{}, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("position %d: unknown variable %q", n.pos, n.name)
+	}
+	return normalize(v), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(env Env) (interface{}, error) {
+	values := make([]interface{}, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+type notNode struct {
+	operand node
+	pos     int
+}
+
+func (n *notNode) eval(env Env) (interface{}, error) {
+	v, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return nil, fmt.Errorf("position %d: '!' requires a boolean, got %s", n.pos, typeName(v))
+	}
+	return !b, nil
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+	pos         int
+}
+
+func (n *logicalNode) eval(env Env) (interface{}, error) {
+	lv, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	lb, ok := lv.(bool)
+	if !ok {
+		return nil, fmt.Errorf("position %d: '%s' requires booleans, got %s", n.pos, n.op, typeName(lv))
+	}
+
+	// 短路求值
+	if n.op == "&&" && !lb || n.op == "||" && lb {
+		return lb, nil
+	}
+
+	rv, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	rb, ok := rv.(bool)
+	if !ok {
+		return nil, fmt.Errorf("position %d: '%s' requires booleans, got %s", n.pos, n.op, typeName(rv))
+	}
+	return rb, nil
+}
+
+type arithNode struct {
+	op          string
+	left, right node
+	pos         int
+}
+
+func (n *arithNode) eval(env Env) (interface{}, error) {
+	lv, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	ld, lok := lv.(decimal.Decimal)
+	rd, rok := rv.(decimal.Decimal)
+	if !lok || !rok {
+		return nil, fmt.Errorf("position %d: '%s' requires numbers, got %s and %s",
+			n.pos, n.op, typeName(lv), typeName(rv))
+	}
+
+	switch n.op {
+	case "+":
+		return ld.Add(rd), nil
+	case "-":
+		return ld.Sub(rd), nil
+	case "*":
+		return ld.Mul(rd), nil
+	case "/":
+		if rd.IsZero() {
+			return nil, fmt.Errorf("position %d: division by zero", n.pos)
+		}
+		return ld.Div(rd), nil
+	}
+	return nil, fmt.Errorf("position %d: unknown operator %q", n.pos, n.op)
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+	pos         int
+}
+
+func (n *compareNode) eval(env Env) (interface{}, error) {
+	lv, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "in":
+		list, ok := rv.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("position %d: 'in' requires a list on the right, got %s", n.pos, typeName(rv))
+		}
+		for _, item := range list {
+			if eq, ok := equal(lv, item); ok && eq {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "==", "!=":
+		eq, ok := equal(lv, rv)
+		if !ok {
+			return nil, fmt.Errorf("position %d: cannot compare %s with %s", n.pos, typeName(lv), typeName(rv))
+		}
+		return eq == (n.op == "=="), nil
+	}
+
+	ld, lok := lv.(decimal.Decimal)
+	rd, rok := rv.(decimal.Decimal)
+	if !lok || !rok {
+		return nil, fmt.Errorf("position %d: '%s' requires numbers, got %s and %s",
+			n.pos, n.op, typeName(lv), typeName(rv))
+	}
+
+	switch n.op {
+	case "<":
+		return ld.LessThan(rd), nil
+	case "<=":
+		return ld.LessThanOrEqual(rd), nil
+	case ">":
+		return ld.GreaterThan(rd), nil
+	case ">=":
+		return ld.GreaterThanOrEqual(rd), nil
+	}
+	return nil, fmt.Errorf("position %d: unknown operator %q", n.pos, n.op)
+}
+
+// equal 比较两个同类型值，类型不同时 ok 为 false
+func equal(a, b interface{}) (eq bool, ok bool) {
+	switch av := a.(type) {
+	case decimal.Decimal:
+		bv, ok := b.(decimal.Decimal)
+		return ok && av.Equal(bv), ok
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv, ok
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv, ok
+	}
+	return false, false
+}
+
+// normalize 将环境中的常见 Go 类型统一为表达式内部类型
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case int:
+		return decimal.NewFromInt(int64(t))
+	case int64:
+		return decimal.NewFromInt(t)
+	case float64:
+		return decimal.NewFromFloat(t)
+	case []string:
+		list := make([]interface{}, len(t))
+		for i, s := range t {
+			list[i] = s
+		}
+		return list
+	}
+	return v
+}
+
+// typeName 值类型名称，用于错误信息
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case decimal.Decimal:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []interface{}:
+		return "list"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// collectIdents 收集语法树中的变量名
+func collectIdents(n node, seen map[string]bool) {
+	switch t := n.(type) {
+	case *identNode:
+		seen[t.name] = true
+	case *listNode:
+		for _, item := range t.items {
+			collectIdents(item, seen)
+		}
+	case *notNode:
+		collectIdents(t.operand, seen)
+	case *logicalNode:
+		collectIdents(t.left, seen)
+		collectIdents(t.right, seen)
+	case *arithNode:
+		collectIdents(t.left, seen)
+		collectIdents(t.right, seen)
+	case *compareNode:
+		collectIdents(t.left, seen)
+		collectIdents(t.right, seen)
+	}
+}
@@ -0,0 +1,168 @@
+package expr
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+var testEnv = Env{
+	"intent.size":     decimal.RequireFromString("100"),
+	"intent.side":     "BUY",
+	"fund.aum":        10000,
+	"fund.nav":        1.25,
+	"market.category": "Politics",
+	"market.active":   true,
+	"market.tags":     []string{"election", "us"},
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{name: "乘法优先于加法", src: "1 + 2 * 3", want: decimal.NewFromInt(7)},
+		{name: "括号改变优先级", src: "(1 + 2) * 3", want: decimal.NewFromInt(9)},
+		{name: "减法左结合", src: "10 - 4 - 3", want: decimal.NewFromInt(3)},
+		{name: "除法左结合", src: "24 / 4 / 2", want: decimal.NewFromInt(3)},
+		{name: "一元负号", src: "-2 * 3", want: decimal.NewFromInt(-6)},
+		{name: "一元负号连用", src: "- -2", want: decimal.NewFromInt(2)},
+		{name: "小数精确运算", src: "0.1 + 0.2 == 0.3", want: true},
+		{name: "算术优先于比较", src: "1 + 2 < 4", want: true},
+		{name: "与优先于或", src: "true || false && false", want: true},
+		{name: "非优先于与", src: "!false && false", want: false},
+		{name: "比较优先于逻辑", src: "intent.size > 50 && intent.side == \"BUY\"", want: true},
+		{name: "整数与浮点变量", src: "fund.aum * 0.05 > intent.size * fund.nav", want: true},
+		{name: "in 命中", src: "market.category in [\"Sports\", \"Politics\"]", want: true},
+		{name: "in 未命中", src: "intent.side in ['SELL']", want: false},
+		{name: "in 空列表", src: "intent.size in []", want: false},
+		{name: "in 混合类型列表", src: "\"100\" in [100, \"x\"]", want: false},
+		{name: "in 字符串切片变量", src: "\"us\" in market.tags", want: true},
+		{name: "not in 命中", src: "market.category not in [\"Politics\"]", want: false},
+		{name: "not in 未命中", src: "market.category not in [\"Crypto\"]", want: true},
+		{name: "与短路不求值右侧", src: "false && missing.var > 1", want: false},
+		{name: "或短路不求值右侧", src: "market.active || missing.var", want: true},
+		{name: "字符串转义", src: `"a\"b" == 'a"b'`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tt.src, err)
+			}
+			got, err := compiled.Eval(testEnv)
+			if err != nil {
+				t.Fatalf("Eval(%q) error = %v", tt.src, err)
+			}
+			if want, ok := tt.want.(decimal.Decimal); ok {
+				if d, ok := got.(decimal.Decimal); !ok || !d.Equal(want) {
+					t.Errorf("Eval(%q) = %v, want %s", tt.src, got, want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "空表达式", src: "   ", wantErr: "expression is empty"},
+		{name: "缺少右括号", src: "(1 + 2", wantErr: "expected ')'"},
+		{name: "多余右括号", src: "1 + 2)", wantErr: "position 6: unexpected \")\""},
+		{name: "多余词法单元", src: "1 2", wantErr: "position 3: unexpected \"2\""},
+		{name: "比较链", src: "1 < 2 < 3", wantErr: "unexpected \"<\""},
+		{name: "缺少右操作数", src: "intent.size >", wantErr: "unexpected end of expression"},
+		{name: "列表未闭合", src: "intent.side in [\"BUY\"", wantErr: "expected ']'"},
+		{name: "not 后缺少 in", src: "intent.side not [\"BUY\"]", wantErr: "expected 'in' after 'not'"},
+		{name: "关键字作操作数", src: "in == 1", wantErr: "unexpected keyword \"in\""},
+		{name: "字符串未闭合", src: "intent.side == \"BUY", wantErr: "unterminated string literal"},
+		{name: "非法标识符", src: "intent..size > 1", wantErr: "invalid identifier"},
+		{name: "非法字符", src: "1 # 2", wantErr: "unexpected character '#'"},
+		{name: "非法数字", src: "1.2.3 > 1", wantErr: "invalid number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.src)
+			if err == nil {
+				t.Fatalf("Compile(%q) succeeded, want error containing %q", tt.src, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile(%q) error = %q, want it to contain %q", tt.src, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "未知变量", src: "missing.var > 1", wantErr: "position 1: unknown variable \"missing.var\""},
+		{name: "除以零", src: "intent.size / (2 - 2)", wantErr: "position 13: division by zero"},
+		{name: "字符串参与算术", src: "intent.side + 1", wantErr: "'+' requires numbers, got string and number"},
+		{name: "字符串参与大小比较", src: "intent.side > 1", wantErr: "'>' requires numbers"},
+		{name: "不同类型判等", src: "intent.size == \"100\"", wantErr: "cannot compare number with string"},
+		{name: "非布尔取反", src: "!intent.size", wantErr: "'!' requires a boolean, got number"},
+		{name: "非布尔逻辑运算", src: "intent.size && true", wantErr: "'&&' requires booleans"},
+		{name: "in 右侧非列表", src: "intent.side in \"BUY\"", wantErr: "'in' requires a list on the right, got string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := Compile(tt.src)
+			if err != nil {
+				t.Fatalf("Compile(%q) error = %v", tt.src, err)
+			}
+			_, err = compiled.Eval(testEnv)
+			if err == nil {
+				t.Fatalf("Eval(%q) succeeded, want error containing %q", tt.src, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Eval(%q) error = %q, want it to contain %q", tt.src, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	compiled, err := Compile("intent.size * 2")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if _, err := compiled.EvalBool(testEnv); err == nil || !strings.Contains(err.Error(), "must evaluate to a boolean") {
+		t.Errorf("EvalBool() error = %v, want non-boolean error", err)
+	}
+
+	compiled, err = Compile("intent.size <= fund.aum")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if ok, err := compiled.EvalBool(testEnv); err != nil || !ok {
+		t.Errorf("EvalBool() = %v, %v, want true", ok, err)
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	compiled, err := Compile("!(fund.aum > 0 && intent.size in [fund.aum, 1]) || market.category not in [\"x\"]")
+	if err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	want := []string{"fund.aum", "intent.size", "market.category"}
+	if got := compiled.Identifiers(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Identifiers() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+// Package expr 实现风控自定义规则使用的小型表达式语言。
+//
+// 支持的语法：
+//
+//	字面量    1.5  "Politics"  true  false  ["a", "b"]
+//	变量      intent.notional  fund.aum  market.category
+//	算术      + - * /  一元 -
+//	比较      == != < <= > >=
+//	集合      in  not in
+//	逻辑      && || !  括号
+//
+// 数值统一使用 decimal 运算，避免浮点误差。
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokComma
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // 在源码中的起始位置（从1开始）
+}
+
+// twoCharOps 双字符运算符
+var twoCharOps = []string{"&&", "||", "==", "!=", "<=", ">="}
+
+// lex 将表达式切分为词法单元
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[start:i]), pos: pos})
+
+		case r == '"' || r == '\'':
+			quote := r
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == quote {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("position %d: unterminated string literal", pos)
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: pos})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+				runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if strings.HasSuffix(text, ".") || strings.Contains(text, "..") {
+				return nil, fmt.Errorf("position %d: invalid identifier %q", pos, text)
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: text, pos: pos})
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: pos})
+			i++
+		case r == '[':
+			tokens = append(tokens, token{kind: tokLBracket, text: "[", pos: pos})
+			i++
+		case r == ']':
+			tokens = append(tokens, token{kind: tokRBracket, text: "]", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: pos})
+			i++
+
+		default:
+			if i+1 < len(runes) {
+				pair := string(runes[i : i+2])
+				matched := false
+				for _, op := range twoCharOps {
+					if pair == op {
+						tokens = append(tokens, token{kind: tokOp, text: op, pos: pos})
+						i += 2
+						matched = true
+						break
+					}
+				}
+				if matched {
+					continue
+				}
+			}
+			if strings.ContainsRune("+-*/<>!", r) {
+				tokens = append(tokens, token{kind: tokOp, text: string(r), pos: pos})
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("position %d: unexpected character %q", pos, r)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes) + 1})
+	return tokens, nil
+}
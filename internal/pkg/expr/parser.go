@@ -0,0 +1,243 @@
+package expr
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// parser 递归下降语法分析器
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp 当前词法单元是否为指定运算符或关键字
+func (p *parser) isOp(text string) bool {
+	tok := p.peek()
+	switch tok.kind {
+	case tokOp:
+		return tok.text == text
+	case tokIdent:
+		return tok.text == text && (text == "in" || text == "not")
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, fmt.Errorf("position %d: expected %s, got %s", tok.pos, what, describe(tok))
+	}
+	return tok, nil
+}
+
+// parseOr or := and ('||' and)*
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right, pos: tok.pos}
+	}
+	return left, nil
+}
+
+// parseAnd and := not ('&&' not)*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		tok := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right, pos: tok.pos}
+	}
+	return left, nil
+}
+
+// parseNot not := '!' not | cmp
+func (p *parser) parseNot() (node, error) {
+	if p.isOp("!") {
+		tok := p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand, pos: tok.pos}, nil
+	}
+	return p.parseCompare()
+}
+
+// parseCompare cmp := add (cmpOp add)?
+func (p *parser) parseCompare() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.isOp(op) {
+			tok := p.next()
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &compareNode{op: op, left: left, right: right, pos: tok.pos}, nil
+		}
+	}
+
+	// not in
+	if p.isOp("not") {
+		tok := p.next()
+		if !p.isOp("in") {
+			return nil, fmt.Errorf("position %d: expected 'in' after 'not'", tok.pos)
+		}
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: &compareNode{op: "in", left: left, right: right, pos: tok.pos}, pos: tok.pos}, nil
+	}
+
+	return left, nil
+}
+
+// parseAdditive add := mul (('+'|'-') mul)*
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		tok := p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithNode{op: tok.text, left: left, right: right, pos: tok.pos}
+	}
+	return left, nil
+}
+
+// parseMultiplicative mul := unary (('*'|'/') unary)*
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") {
+		tok := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithNode{op: tok.text, left: left, right: right, pos: tok.pos}
+	}
+	return left, nil
+}
+
+// parseUnary unary := '-' unary | primary
+func (p *parser) parseUnary() (node, error) {
+	if p.isOp("-") {
+		tok := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &arithNode{op: "-", left: &literalNode{value: decimal.Zero}, right: operand, pos: tok.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary primary := NUMBER | STRING | true | false | IDENT | '(' expr ')' | '[' list ']'
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		d, err := decimal.NewFromString(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("position %d: invalid number %q", tok.pos, tok.text)
+		}
+		return &literalNode{value: d}, nil
+
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "in", "not":
+			return nil, fmt.Errorf("position %d: unexpected keyword %q", tok.pos, tok.text)
+		}
+		return &identNode{name: tok.text, pos: tok.pos}, nil
+
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+
+	case tokLBracket:
+		list := &listNode{}
+		if p.peek().kind == tokRBracket {
+			p.next()
+			return list, nil
+		}
+		for {
+			item, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, item)
+			if p.peek().kind == tokComma {
+				p.next()
+				continue
+			}
+			if _, err := p.expect(tokRBracket, "']'"); err != nil {
+				return nil, err
+			}
+			return list, nil
+		}
+	}
+
+	return nil, fmt.Errorf("position %d: unexpected %s", tok.pos, describe(tok))
+}
+
+// describe 描述词法单元，用于错误信息
+func describe(tok token) string {
+	if tok.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", tok.text)
+}
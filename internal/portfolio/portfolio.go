@@ -0,0 +1,509 @@
+// Package portfolio 投资人持仓视图。
+// 按结算时间回放投资人的申赎记录与业绩报酬份额扣减，以平均成本法计算持仓成本、已实现与未实现盈亏，
+// 并结合正式净值历史计算时间加权收益率、资金加权收益率（Modified Dietz）及每日投资价值走势。
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	precision  = 8
+	dateLayout = "2006-01-02"
+)
+
+var (
+	// ErrFundNotFound 基金不存在
+	ErrFundNotFound = errors.New("基金不存在")
+	// ErrNotInvested 投资人未投资该基金
+	ErrNotInvested = errors.New("未投资该基金")
+)
+
+// Service 投资人持仓服务
+type Service struct {
+	repo   repository.Repository
+	logger *logger.Logger
+}
+
+// NewService 创建投资人持仓服务
+func NewService(repo repository.Repository, logger *logger.Logger) *Service {
+	return &Service{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// FundItem 已投资基金列表项
+type FundItem struct {
+	FundID             uuid.UUID       `json:"fundId"`
+	FundName           string          `json:"fundName"`
+	VaultAddress       string          `json:"vaultAddress"`
+	CreatedAt          time.Time       `json:"createdAt"`
+	Status             string          `json:"status"`
+	MinimumDeposit     decimal.Decimal `json:"minimumDeposit"`
+	MinimumRedeem      decimal.Decimal `json:"minimumRedeem"`
+	ManagementFeeRate  decimal.Decimal `json:"managementFeeRate"`
+	PerformanceFeeRate decimal.Decimal `json:"performanceFeeRate"`
+	CurrentNav         decimal.Decimal `json:"currentNav"`
+	MaxDrawdownPct     decimal.Decimal `json:"maxDrawdownPct"`
+	HoldingShares      decimal.Decimal `json:"holdingShares"`
+	InvestedAmount     decimal.Decimal `json:"investedAmount"` // 当前份额的持仓成本
+	CurrentValue       decimal.Decimal `json:"currentValue"`
+	ReturnPct          decimal.Decimal `json:"returnPct"` // 未实现收益率
+}
+
+// Summary 投资总览，盈亏含已全部赎回基金的已实现盈亏
+type Summary struct {
+	TotalInvested     decimal.Decimal `json:"totalInvested"` // 当前持仓成本合计
+	CurrentTotalValue decimal.Decimal `json:"currentTotalValue"`
+	CumulativePnl     decimal.Decimal `json:"cumulativePnl"`
+	CumulativePnlPct  decimal.Decimal `json:"cumulativePnlPct"` // 累计盈亏 / 累计申购
+	InvestedFundCount int             `json:"investedFundCount"`
+}
+
+// FundDetail 投资人在单个基金的投资详情
+type FundDetail struct {
+	FundItem
+	TotalDeposits    decimal.Decimal `json:"totalDeposits"`
+	TotalRedeems     decimal.Decimal `json:"totalRedeems"`
+	NetInvested      decimal.Decimal `json:"netInvested"` // 累计申购 - 已赎回份额的本金
+	TotalPnl         decimal.Decimal `json:"totalPnl"`
+	TotalPnlPct      decimal.Decimal `json:"totalPnlPct"` // 累计盈亏 / 累计申购
+	UnrealizedPnl    decimal.Decimal `json:"unrealizedPnl"`
+	UnrealizedPnlPct decimal.Decimal `json:"unrealizedPnlPct"` // 未实现盈亏 / 持仓成本
+	RealizedPnl      decimal.Decimal `json:"realizedPnl"`
+	RealizedPnlPct   decimal.Decimal `json:"realizedPnlPct"`   // 已实现盈亏 / 已赎回份额的本金
+	TimeWeightedPct  decimal.Decimal `json:"timeWeightedPct"`  // 时间加权收益率
+	MoneyWeightedPct decimal.Decimal `json:"moneyWeightedPct"` // 资金加权收益率（Modified Dietz）
+}
+
+// ValuePoint 投资价值走势数据点
+type ValuePoint struct {
+	Date  string          `json:"date"`
+	Value decimal.Decimal `json:"value"`
+}
+
+// HistoryItem 申赎历史记录：已结算的申购、赎回及业绩报酬份额扣减
+type HistoryItem struct {
+	FundID   uuid.UUID       `json:"fundId"`
+	FundName string          `json:"fundName"`
+	Type     string          `json:"type"`   // DEPOSIT / REDEEM / FEE
+	Shares   decimal.Decimal `json:"shares"` // 份额变动，赎回与扣减为负
+	Amount   decimal.Decimal `json:"amount"` // 申购或赎回金额，业绩报酬扣减为0
+	Nav      decimal.Decimal `json:"nav"`
+	At       time.Time       `json:"at"`
+}
+
+// event 份额变动：申购、赎回或业绩报酬扣减
+type event struct {
+	at     time.Time
+	kind   string // DEPOSIT / REDEEM / FEE
+	shares decimal.Decimal
+	amount decimal.Decimal // 申购或赎回金额，扣减份额时为0
+	nav    decimal.Decimal
+}
+
+// holding 单个基金的回放结果
+type holding struct {
+	fund   *models.Fund
+	shares decimal.Decimal // 当前份额（以份额记录为准）
+	events []event
+
+	cost          decimal.Decimal // 当前份额的持仓成本
+	deposits      decimal.Decimal
+	redeems       decimal.Decimal
+	redeemedCost  decimal.Decimal // 已赎回份额的本金
+	realized      decimal.Decimal
+	timeWeighted  decimal.Decimal
+	moneyWeighted decimal.Decimal
+}
+
+// Funds 投资人当前持有份额的基金，keyword 按基金名称或 Vault 地址过滤
+func (s *Service) Funds(ctx context.Context, investor, keyword string, page, pageSize int) ([]FundItem, int64, error) {
+	holdings, err := s.load(ctx, investor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	var items []FundItem
+	for _, h := range holdings {
+		if !h.shares.IsPositive() {
+			continue
+		}
+		if keyword != "" && !strings.Contains(strings.ToLower(h.fund.Name), keyword) &&
+			!strings.Contains(strings.ToLower(h.fund.VaultAddress), keyword) {
+			continue
+		}
+		item, err := s.item(ctx, h)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+
+	total := int64(len(items))
+	start := (page - 1) * pageSize
+	if start >= len(items) {
+		return []FundItem{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], total, nil
+}
+
+// Summary 投资总览
+func (s *Service) Summary(ctx context.Context, investor string) (*Summary, error) {
+	holdings, err := s.load(ctx, investor)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+	deposits := decimal.Zero
+	for _, h := range holdings {
+		value := h.value()
+		deposits = deposits.Add(h.deposits)
+		summary.CumulativePnl = summary.CumulativePnl.Add(h.realized).Add(value.Sub(h.cost))
+		if h.shares.IsPositive() {
+			summary.InvestedFundCount++
+			summary.TotalInvested = summary.TotalInvested.Add(h.cost)
+			summary.CurrentTotalValue = summary.CurrentTotalValue.Add(value)
+		}
+	}
+	summary.CumulativePnlPct = ratio(summary.CumulativePnl, deposits)
+	return summary, nil
+}
+
+// Detail 投资人在基金的投资详情
+func (s *Service) Detail(ctx context.Context, fundID uuid.UUID, investor string) (*FundDetail, error) {
+	h, err := s.loadFund(ctx, fundID, investor)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.item(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	unrealized := h.value().Sub(h.cost)
+	total := h.realized.Add(unrealized)
+
+	return &FundDetail{
+		FundItem:         item,
+		TotalDeposits:    h.deposits,
+		TotalRedeems:     h.redeems,
+		NetInvested:      h.deposits.Sub(h.redeemedCost),
+		TotalPnl:         total,
+		TotalPnlPct:      ratio(total, h.deposits),
+		UnrealizedPnl:    unrealized,
+		UnrealizedPnlPct: ratio(unrealized, h.cost),
+		RealizedPnl:      h.realized,
+		RealizedPnlPct:   ratio(h.realized, h.redeemedCost),
+		TimeWeightedPct:  h.timeWeighted,
+		MoneyWeightedPct: h.moneyWeighted,
+	}, nil
+}
+
+// ValueTrend 投资价值走势：自首次申购结算日起，每日份额 × 当日最后一次正式净值
+func (s *Service) ValueTrend(ctx context.Context, fundID uuid.UUID, investor string) ([]ValuePoint, error) {
+	h, err := s.loadFund(ctx, fundID, investor)
+	if err != nil {
+		return nil, err
+	}
+
+	firstDay := day(h.events[0].at)
+	history, err := s.repo.GetNavHistory(ctx, fundID, models.NavKindOfficial, firstDay, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("获取净值历史失败: %w", err)
+	}
+
+	// 当日结算的申赎按当日净值成交，计入当日价值
+	var points []ValuePoint
+	shares := decimal.Zero
+	next := 0
+	for i, nav := range history {
+		d := day(nav.RecordedAt)
+		if i+1 < len(history) && day(history[i+1].RecordedAt).Equal(d) {
+			continue
+		}
+		for ; next < len(h.events) && !day(h.events[next].at).After(d); next++ {
+			shares = shares.Add(h.events[next].shares)
+		}
+		points = append(points, ValuePoint{
+			Date:  d.Format(dateLayout),
+			Value: shares.Mul(nav.NavPerShare).Round(precision),
+		})
+	}
+	return points, nil
+}
+
+// History 投资人在各基金已结算的份额变动，按时间倒序分页
+func (s *Service) History(ctx context.Context, investor string, page, pageSize int) ([]HistoryItem, int64, error) {
+	holdings, err := s.load(ctx, investor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var items []HistoryItem
+	for _, h := range holdings {
+		for _, e := range h.events {
+			items = append(items, HistoryItem{
+				FundID:   h.fund.ID,
+				FundName: h.fund.Name,
+				Type:     e.kind,
+				Shares:   e.shares,
+				Amount:   e.amount,
+				Nav:      e.nav,
+				At:       e.at,
+			})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].At.After(items[j].At) })
+
+	total := int64(len(items))
+	start := (page - 1) * pageSize
+	if start >= len(items) {
+		return []HistoryItem{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], total, nil
+}
+
+// load 回放投资人在各基金的份额变动
+func (s *Service) load(ctx context.Context, investor string) ([]*holding, error) {
+	records, err := s.repo.GetInvestorShareHoldings(ctx, investor)
+	if err != nil {
+		return nil, fmt.Errorf("获取份额记录失败: %w", err)
+	}
+	txns, err := s.repo.GetInvestorSettledTransactions(ctx, investor)
+	if err != nil {
+		return nil, fmt.Errorf("获取申赎记录失败: %w", err)
+	}
+	fees, err := s.repo.GetInvestorFeePayments(ctx, investor)
+	if err != nil {
+		return nil, fmt.Errorf("获取费用流水失败: %w", err)
+	}
+
+	byFund := make(map[uuid.UUID]*holding)
+	var holdings []*holding
+	get := func(fundID uuid.UUID) (*holding, error) {
+		if h, ok := byFund[fundID]; ok {
+			return h, nil
+		}
+		fund, err := s.repo.GetFund(ctx, fundID)
+		if err != nil {
+			return nil, fmt.Errorf("获取基金失败: %w", err)
+		}
+		if fund == nil {
+			return nil, nil
+		}
+		h := &holding{fund: fund}
+		byFund[fundID] = h
+		holdings = append(holdings, h)
+		return h, nil
+	}
+
+	for _, r := range records {
+		h, err := get(r.FundID)
+		if err != nil {
+			return nil, err
+		}
+		if h != nil {
+			h.shares = r.Shares
+		}
+	}
+	for _, txn := range txns {
+		h, err := get(txn.FundID)
+		if err != nil {
+			return nil, err
+		}
+		if h == nil || txn.SettledAt == nil {
+			continue
+		}
+		e := event{at: *txn.SettledAt, kind: string(txn.Type), amount: txn.Amount, nav: txn.ExecutedNAV}
+		if txn.Type == models.InvestmentTxDeposit {
+			e.shares = txn.Shares
+		} else {
+			e.shares = txn.Shares.Neg()
+		}
+		h.events = append(h.events, e)
+	}
+	for _, fee := range fees {
+		if h := byFund[fee.FundID]; h != nil {
+			h.events = append(h.events, event{at: fee.PeriodEnd, kind: "FEE", shares: fee.Shares.Neg(), nav: fee.NAV})
+		}
+	}
+
+	now := time.Now()
+	for _, h := range holdings {
+		sort.SliceStable(h.events, func(i, j int) bool { return h.events[i].at.Before(h.events[j].at) })
+		h.replay(now)
+	}
+	return holdings, nil
+}
+
+// loadFund 回放投资人在单个基金的份额变动，未投资时返回 ErrNotInvested
+func (s *Service) loadFund(ctx context.Context, fundID uuid.UUID, investor string) (*holding, error) {
+	fund, err := s.repo.GetFund(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金失败: %w", err)
+	}
+	if fund == nil {
+		return nil, ErrFundNotFound
+	}
+
+	holdings, err := s.load(ctx, investor)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range holdings {
+		if h.fund.ID == fundID && len(h.events) > 0 {
+			return h, nil
+		}
+	}
+	return nil, ErrNotInvested
+}
+
+// replay 按平均成本法回放份额变动：业绩报酬扣减份额不改变持仓成本，计入未实现亏损
+func (h *holding) replay(now time.Time) {
+	shares := decimal.Zero
+	twr := decimal.NewFromInt(1)
+	prevValue := decimal.Zero // 上一次申赎后的投资价值
+
+	for _, e := range h.events {
+		switch e.kind {
+		case "FEE":
+			shares = shares.Add(e.shares)
+			continue
+		case string(models.InvestmentTxDeposit):
+			h.deposits = h.deposits.Add(e.amount)
+			h.cost = h.cost.Add(e.amount)
+		case string(models.InvestmentTxRedeem):
+			h.redeems = h.redeems.Add(e.amount)
+			if shares.IsPositive() {
+				redeemed := e.shares.Neg()
+				cost := h.cost.Mul(redeemed).Div(shares).Round(precision)
+				h.cost = h.cost.Sub(cost)
+				h.redeemedCost = h.redeemedCost.Add(cost)
+				h.realized = h.realized.Add(e.amount.Sub(cost))
+			}
+		}
+
+		// 时间加权：以每次申赎前的价值与上一次申赎后的价值之比链接各子区间收益
+		if prevValue.IsPositive() {
+			twr = twr.Mul(shares.Mul(e.nav).Div(prevValue))
+		}
+		shares = shares.Add(e.shares)
+		prevValue = shares.Mul(e.nav)
+	}
+	if shares.IsZero() {
+		h.cost = decimal.Zero
+	}
+	if prevValue.IsPositive() && h.shares.IsPositive() {
+		twr = twr.Mul(h.value().Div(prevValue))
+	}
+	h.timeWeighted = twr.Sub(decimal.NewFromInt(1)).Round(precision)
+	h.moneyWeighted = h.modifiedDietz(now)
+}
+
+// modifiedDietz 资金加权收益率：(期末价值 - 净流入) / 按持有时间加权的流入资金，期初价值为0
+func (h *holding) modifiedDietz(now time.Time) decimal.Decimal {
+	if len(h.events) == 0 {
+		return decimal.Zero
+	}
+	start := h.events[0].at
+	period := now.Sub(start).Seconds()
+	if period <= 0 {
+		return decimal.Zero
+	}
+
+	netFlow, weighted := decimal.Zero, decimal.Zero
+	for _, e := range h.events {
+		var flow decimal.Decimal
+		switch e.kind {
+		case string(models.InvestmentTxDeposit):
+			flow = e.amount
+		case string(models.InvestmentTxRedeem):
+			flow = e.amount.Neg()
+		default:
+			continue
+		}
+		weight := decimal.NewFromFloat(now.Sub(e.at).Seconds() / period)
+		netFlow = netFlow.Add(flow)
+		weighted = weighted.Add(flow.Mul(weight))
+	}
+	return ratio(h.value().Sub(netFlow), weighted)
+}
+
+// value 当前投资价值：份额 × 基金最新正式单位净值
+func (h *holding) value() decimal.Decimal {
+	return h.shares.Mul(h.fund.CurrentNAV).Round(precision)
+}
+
+// item 构建列表项，最大回撤取业绩指标缓存
+func (s *Service) item(ctx context.Context, h *holding) (FundItem, error) {
+	perf, err := s.repo.GetFundPerformance(ctx, h.fund.ID)
+	if err != nil {
+		return FundItem{}, fmt.Errorf("获取业绩指标失败: %w", err)
+	}
+	maxDrawdown := decimal.Zero
+	if perf != nil {
+		maxDrawdown = perf.MaxDrawdown
+	}
+
+	value := h.value()
+	return FundItem{
+		FundID:             h.fund.ID,
+		FundName:           h.fund.Name,
+		VaultAddress:       h.fund.VaultAddress,
+		CreatedAt:          h.fund.CreatedAt,
+		Status:             fundStatus(h.fund.Status),
+		MinimumDeposit:     h.fund.MinimumDeposit,
+		MinimumRedeem:      h.fund.MinimumRedeem,
+		ManagementFeeRate:  h.fund.ManagementFeeRate,
+		PerformanceFeeRate: h.fund.PerformanceFeeRate,
+		CurrentNav:         h.fund.CurrentNAV,
+		MaxDrawdownPct:     maxDrawdown,
+		HoldingShares:      h.shares,
+		InvestedAmount:     h.cost,
+		CurrentValue:       value,
+		ReturnPct:          ratio(value.Sub(h.cost), h.cost),
+	}, nil
+}
+
+// fundStatus 对外展示的基金状态：正常运营为 RUNNING，其余为 STOPPED
+func fundStatus(status string) string {
+	if status == models.FundStatusActive {
+		return "RUNNING"
+	}
+	return "STOPPED"
+}
+
+func ratio(a, b decimal.Decimal) decimal.Decimal {
+	if !b.IsPositive() {
+		return decimal.Zero
+	}
+	return a.Div(b).Round(precision)
+}
+
+func day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
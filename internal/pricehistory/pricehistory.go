@@ -0,0 +1,283 @@
+// Package pricehistory 结果代币价格历史。
+// 数据聚合任务将市场缓存中有更新的结果代币价格写入采样表，同时汇总为 1m/1h/1d K线；
+// 采样与K线按各自保留期定期清理。K线供前端行情图与风控波动率计算使用。
+package pricehistory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// 波动率按最近24根小时K线计算
+const (
+	volatilityInterval = models.CandleInterval1h
+	volatilityPeriods  = 24
+)
+
+var (
+	// ErrMarketNotFound 市场不存在
+	ErrMarketNotFound = errors.New("市场不存在")
+	// ErrOutcomeNotFound 结果代币不属于该市场
+	ErrOutcomeNotFound = errors.New("结果不存在")
+	// ErrInvalidInterval 不支持的K线周期
+	ErrInvalidInterval = errors.New("不支持的K线周期")
+)
+
+// QuoteSource 实时盘口（由 marketdata.Feed 实现）
+type QuoteSource interface {
+	TopOfBook(tokenID string) (bid, ask decimal.Decimal, ok bool)
+}
+
+// Config 价格历史保留配置，0表示永久保留
+type Config struct {
+	SampleRetention time.Duration
+	CandleRetention map[models.CandleInterval]time.Duration
+}
+
+// Service 价格历史服务
+type Service struct {
+	repo   repository.Repository
+	quotes QuoteSource
+	logger *logger.Logger
+	config Config
+
+	mu          sync.Mutex
+	lastSampled time.Time
+	lastVolume  map[string]decimal.Decimal // 市场上次采样时的累计成交量
+}
+
+// NewService 创建价格历史服务
+func NewService(repo repository.Repository, logger *logger.Logger, config Config) *Service {
+	return &Service{
+		repo:       repo,
+		logger:     logger,
+		config:     config,
+		lastVolume: make(map[string]decimal.Decimal),
+	}
+}
+
+// SetQuoteSource 设置实时盘口，设置后中间价取实时最优买卖价的均值
+func (s *Service) SetQuoteSource(quotes QuoteSource) {
+	s.quotes = quotes
+}
+
+// Sample 采样上次采样后有更新的市场的结果代币价格，并合并到各周期K线
+func (s *Service) Sample(ctx context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := s.lastSampled
+	if since.IsZero() {
+		since = now.Add(-time.Minute)
+	}
+	markets, err := s.repo.GetMarketsUpdatedSince(ctx, since)
+	if err != nil {
+		return fmt.Errorf("获取市场数据失败: %w", err)
+	}
+
+	var samples []models.PriceSample
+	var candles []models.Candle
+	for _, m := range markets {
+		if m.Resolved {
+			continue
+		}
+
+		// 成交量为市场累计值，按与上次采样的差值计入K线；首次采样或累计值回落时记0
+		volume := decimal.Zero
+		if prev, ok := s.lastVolume[m.ID]; ok && m.Volume.GreaterThan(prev) {
+			volume = m.Volume.Sub(prev)
+		}
+		s.lastVolume[m.ID] = m.Volume
+
+		for _, o := range m.Outcomes {
+			sample := models.PriceSample{
+				TokenID:   o.TokenID,
+				MarketID:  m.ID,
+				Mid:       s.mid(o),
+				Last:      o.Price,
+				Volume:    m.Volume,
+				SampledAt: now,
+			}
+			price := sample.Mid
+			if !price.IsPositive() {
+				price = sample.Last
+			}
+			if !price.IsPositive() {
+				continue
+			}
+
+			samples = append(samples, sample)
+			for _, interval := range models.CandleIntervals {
+				candles = append(candles, models.Candle{
+					TokenID:   o.TokenID,
+					Interval:  interval,
+					OpenTime:  now.UTC().Truncate(interval.Duration()),
+					MarketID:  m.ID,
+					Open:      price,
+					High:      price,
+					Low:       price,
+					Close:     price,
+					Volume:    volume,
+					Samples:   1,
+					UpdatedAt: now,
+				})
+			}
+		}
+	}
+
+	if err := s.repo.SavePriceSamples(ctx, samples, candles); err != nil {
+		return fmt.Errorf("保存价格采样失败: %w", err)
+	}
+	s.lastSampled = now
+	return nil
+}
+
+// mid 中间价：优先实时盘口，否则取市场缓存中的结果价格
+func (s *Service) mid(o models.MarketOutcome) decimal.Decimal {
+	if s.quotes != nil {
+		if bid, ask, ok := s.quotes.TopOfBook(o.TokenID); ok && bid.IsPositive() && ask.IsPositive() {
+			return bid.Add(ask).Div(decimal.NewFromInt(2))
+		}
+	}
+	return o.Price
+}
+
+// Prune 按保留期清理采样与K线
+func (s *Service) Prune(ctx context.Context, now time.Time) error {
+	var samplesBefore time.Time
+	if s.config.SampleRetention > 0 {
+		samplesBefore = now.Add(-s.config.SampleRetention)
+	}
+	candlesBefore := make(map[models.CandleInterval]time.Time)
+	for interval, retention := range s.config.CandleRetention {
+		if retention > 0 {
+			candlesBefore[interval] = now.Add(-retention)
+		}
+	}
+
+	deleted, err := s.repo.PrunePriceHistory(ctx, samplesBefore, candlesBefore)
+	if err != nil {
+		return fmt.Errorf("清理价格历史失败: %w", err)
+	}
+	if deleted > 0 {
+		s.logger.Info("已清理过期价格历史", zap.Int64("rows", deleted))
+	}
+	return nil
+}
+
+// CandlePoint K线数据点
+type CandlePoint struct {
+	Time   time.Time       `json:"time"` // 开盘时间
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume decimal.Decimal `json:"volume"`
+}
+
+// CandleSeries 结果代币K线
+type CandleSeries struct {
+	MarketID string                `json:"marketId"`
+	TokenID  string                `json:"tokenId"`
+	Outcome  string                `json:"outcome"`
+	Interval models.CandleInterval `json:"interval"`
+	Candles  []CandlePoint         `json:"candles"`
+}
+
+// Candles 查询市场某一结果的K线，tokenID 为空时取市场的第一个结果
+func (s *Service) Candles(ctx context.Context, marketID, tokenID string, interval models.CandleInterval,
+	from, to time.Time, limit int) (*CandleSeries, error) {
+
+	if interval.Duration() == 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	market, err := s.repo.GetMarketData(ctx, marketID)
+	if err != nil {
+		return nil, fmt.Errorf("获取市场数据失败: %w", err)
+	}
+	if market == nil {
+		return nil, ErrMarketNotFound
+	}
+
+	var outcome *models.MarketOutcome
+	for i := range market.Outcomes {
+		if tokenID == "" || market.Outcomes[i].TokenID == tokenID {
+			outcome = &market.Outcomes[i]
+			break
+		}
+	}
+	if outcome == nil {
+		return nil, ErrOutcomeNotFound
+	}
+
+	candles, err := s.repo.GetCandles(ctx, outcome.TokenID, interval, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取K线失败: %w", err)
+	}
+
+	series := &CandleSeries{
+		MarketID: market.ID,
+		TokenID:  outcome.TokenID,
+		Outcome:  outcome.Name,
+		Interval: interval,
+		Candles:  make([]CandlePoint, 0, len(candles)),
+	}
+	for _, c := range candles {
+		series.Candles = append(series.Candles, CandlePoint{
+			Time:   c.OpenTime,
+			Open:   c.Open,
+			High:   c.High,
+			Low:    c.Low,
+			Close:  c.Close,
+			Volume: c.Volume,
+		})
+	}
+	return series, nil
+}
+
+// Volatility 结果代币近24小时的价格波动率：小时K线收盘价对数收益率的标准差，
+// 有效K线不足3根时返回0
+func (s *Service) Volatility(ctx context.Context, tokenID string) (decimal.Decimal, error) {
+	candles, err := s.repo.GetCandles(ctx, tokenID, volatilityInterval, time.Time{}, time.Time{}, volatilityPeriods+1)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("获取K线失败: %w", err)
+	}
+
+	var returns []float64
+	for i := 1; i < len(candles); i++ {
+		prev, cur := candles[i-1].Close.InexactFloat64(), candles[i].Close.InexactFloat64()
+		if prev <= 0 || cur <= 0 {
+			continue
+		}
+		returns = append(returns, math.Log(cur/prev))
+	}
+	if len(returns) < 2 {
+		return decimal.Zero, nil
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+
+	return decimal.NewFromFloat(math.Sqrt(variance)).Round(6), nil
+}
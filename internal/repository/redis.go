@@ -22,9 +22,32 @@ type RedisRepository interface {
 	SetNonce(ctx context.Context, address string, nonce string, expiration time.Duration) error
 	GetNonce(ctx context.Context, address string) (string, error)
 	DeleteNonce(ctx context.Context, address string) error
+
+	// 熔断开关缓存（hash，字段为开关范围）
+	GetKillSwitches(ctx context.Context, fields ...string) (map[string]string, error)
+	SetKillSwitch(ctx context.Context, field string, value string) error
+	DeleteKillSwitch(ctx context.Context, field string) error
+	ReplaceKillSwitches(ctx context.Context, values map[string]string) error
+
+	// 排行榜（sorted set，成员为投资人地址）
+	ReplaceLeaderboard(ctx context.Context, key string, scores map[string]float64) error
+	UnionLeaderboards(ctx context.Context, dest string, keys []string) error
+	GetLeaderboard(ctx context.Context, key string, offset, limit int64) ([]LeaderboardEntry, int64, error)
+	GetLeaderboardScores(ctx context.Context, key string) (map[string]float64, error)
+	RemoveFromLeaderboards(ctx context.Context, member string, keys ...string) error
+	GetLeaderboardWatermarks(ctx context.Context) (map[string]string, error)
+	SetLeaderboardWatermark(ctx context.Context, field string, value string) error
+	DeleteLeaderboardWatermarks(ctx context.Context, fields ...string) error
+
 	Close() error
 }
 
+// LeaderboardEntry 排行榜成员及分值
+type LeaderboardEntry struct {
+	Member string
+	Score  float64
+}
+
 type redisRepo struct {
 	client *redis.Client
 }
@@ -71,6 +94,143 @@ func (r *redisRepo) DeleteNonce(ctx context.Context, address string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// killSwitchKey 熔断开关缓存 key
+const killSwitchKey = "killswitch"
+
+// GetKillSwitches 批量读取熔断开关字段，不存在的字段不返回
+func (r *redisRepo) GetKillSwitches(ctx context.Context, fields ...string) (map[string]string, error) {
+	values, err := r.client.HMGet(ctx, killSwitchKey, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(fields))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			result[fields[i]] = s
+		}
+	}
+	return result, nil
+}
+
+// SetKillSwitch 写入熔断开关
+func (r *redisRepo) SetKillSwitch(ctx context.Context, field string, value string) error {
+	return r.client.HSet(ctx, killSwitchKey, field, value).Err()
+}
+
+// DeleteKillSwitch 删除熔断开关
+func (r *redisRepo) DeleteKillSwitch(ctx context.Context, field string) error {
+	return r.client.HDel(ctx, killSwitchKey, field).Err()
+}
+
+// ReplaceKillSwitches 以给定内容整体替换熔断开关缓存
+func (r *redisRepo) ReplaceKillSwitches(ctx context.Context, values map[string]string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, killSwitchKey)
+		if len(values) > 0 {
+			pipe.HSet(ctx, killSwitchKey, values)
+		}
+		return nil
+	})
+	return err
+}
+
+// leaderboardWatermarkKey 排行榜各基金最近一次计算时的数据版本（hash，字段为基金ID）
+const leaderboardWatermarkKey = "leaderboard:watermark"
+
+// ReplaceLeaderboard 以给定分值整体替换排行榜
+func (r *redisRepo) ReplaceLeaderboard(ctx context.Context, key string, scores map[string]float64) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(scores) > 0 {
+			members := make([]redis.Z, 0, len(scores))
+			for member, score := range scores {
+				members = append(members, redis.Z{Score: score, Member: member})
+			}
+			pipe.ZAdd(ctx, key, members...)
+		}
+		return nil
+	})
+	return err
+}
+
+// UnionLeaderboards 将多个排行榜按成员分值求和写入 dest，keys 为空时清空 dest
+func (r *redisRepo) UnionLeaderboards(ctx context.Context, dest string, keys []string) error {
+	if len(keys) == 0 {
+		return r.client.Del(ctx, dest).Err()
+	}
+	return r.client.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys, Aggregate: "SUM"}).Err()
+}
+
+// GetLeaderboard 按分值从高到低分页读取排行榜，同时返回成员总数
+func (r *redisRepo) GetLeaderboard(ctx context.Context, key string, offset, limit int64) ([]LeaderboardEntry, int64, error) {
+	var (
+		rangeCmd *redis.ZSliceCmd
+		cardCmd  *redis.IntCmd
+	)
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		rangeCmd = pipe.ZRevRangeWithScores(ctx, key, offset, offset+limit-1)
+		cardCmd = pipe.ZCard(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(rangeCmd.Val()))
+	for _, z := range rangeCmd.Val() {
+		if member, ok := z.Member.(string); ok {
+			entries = append(entries, LeaderboardEntry{Member: member, Score: z.Score})
+		}
+	}
+	return entries, cardCmd.Val(), nil
+}
+
+// GetLeaderboardScores 读取排行榜全部成员分值
+func (r *redisRepo) GetLeaderboardScores(ctx context.Context, key string) (map[string]float64, error) {
+	values, err := r.client.ZRangeWithScores(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]float64, len(values))
+	for _, z := range values {
+		if member, ok := z.Member.(string); ok {
+			scores[member] = z.Score
+		}
+	}
+	return scores, nil
+}
+
+// RemoveFromLeaderboards 从多个排行榜移除成员
+func (r *redisRepo) RemoveFromLeaderboards(ctx context.Context, member string, keys ...string) error {
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.ZRem(ctx, key, member)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetLeaderboardWatermarks 读取各基金排行榜的数据版本
+func (r *redisRepo) GetLeaderboardWatermarks(ctx context.Context) (map[string]string, error) {
+	return r.client.HGetAll(ctx, leaderboardWatermarkKey).Result()
+}
+
+// SetLeaderboardWatermark 记录基金排行榜的数据版本
+func (r *redisRepo) SetLeaderboardWatermark(ctx context.Context, field string, value string) error {
+	return r.client.HSet(ctx, leaderboardWatermarkKey, field, value).Err()
+}
+
+// DeleteLeaderboardWatermarks 删除基金排行榜的数据版本，使下次计算时重新生成
+func (r *redisRepo) DeleteLeaderboardWatermarks(ctx context.Context, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return r.client.HDel(ctx, leaderboardWatermarkKey, fields...).Err()
+}
+
 // Close 关闭连接池
 func (r *redisRepo) Close() error {
 	return r.client.Close()
@@ -3,14 +3,17 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"polyagent-backend/configs"
 	models "polyagent-backend/internal/models"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
 )
@@ -20,6 +23,13 @@ const (
 	RoleManager
 )
 
+var (
+	// ErrInsufficientShares 扣减份额时投资人持有份额不足
+	ErrInsufficientShares = errors.New("份额不足")
+	// ErrRuleVersionClosed 规则版本已被其他修改或停用结束
+	ErrRuleVersionClosed = errors.New("规则版本已结束")
+)
+
 type User struct {
 	gorm.Model
 	Username   string `gorm:"uniqueIndex;not null"`
@@ -57,7 +67,17 @@ type Repository interface {
 	// Fund operations
 	GetFund(ctx context.Context, id uuid.UUID) (*models.Fund, error)
 	GetActiveFunds(ctx context.Context) ([]models.Fund, error)
+	GetVaultFunds(ctx context.Context) ([]models.Fund, error)
 	UpdateFund(ctx context.Context, fund *models.Fund) error
+	UpdateFundHighWaterMark(ctx context.Context, fundID uuid.UUID, highWaterMark decimal.Decimal) error
+	UpdateFundStatus(ctx context.Context, fundID uuid.UUID, from, to string) (bool, error)
+	ResumeLiquidatingFund(ctx context.Context, fundID uuid.UUID, highWaterMark decimal.Decimal) (bool, error)
+	RecordCashEntries(ctx context.Context, entries []models.CashEntry) error
+
+	// NAV operations
+	SaveFundValuation(ctx context.Context, history *models.NavHistory) error
+	GetNavHistory(ctx context.Context, fundID uuid.UUID, kind models.NavKind, from, to time.Time) ([]models.NavHistory, error)
+	GetLatestNavHistory(ctx context.Context, fundID uuid.UUID, kind models.NavKind) (*models.NavHistory, error)
 
 	// Trade intent operations
 	CreateTradeIntent(ctx context.Context, intent *models.TradeIntent) error
@@ -65,21 +85,114 @@ type Repository interface {
 	GetPendingIntents(ctx context.Context, limit int) ([]models.TradeIntent, error)
 	GetStaleApprovedIntents(ctx context.Context, staleTime time.Duration, limit int) ([]models.TradeIntent, error)
 	UpdateTradeIntent(ctx context.Context, intent *models.TradeIntent) error
+	CompleteIntentReview(ctx context.Context, intent *models.TradeIntent) (bool, error)
+	GetIntentsByStatus(ctx context.Context, status models.IntentStatus, limit int) ([]models.TradeIntent, error)
+	GetOverdueReviewIntents(ctx context.Context, now time.Time, limit int) ([]models.TradeIntent, error)
+	GetSettledFundIntents(ctx context.Context, fundID uuid.UUID, statuses []models.IntentStatus, beforeAt time.Time, beforeID uuid.UUID, limit int) ([]models.TradeIntent, error)
+	GetOpenIntentOutcomes(ctx context.Context) ([]string, error)
 
 	// Position operations
 	GetFundPositions(ctx context.Context, fundID uuid.UUID) ([]models.Position, error)
 	GetPosition(ctx context.Context, fundID uuid.UUID, marketID, outcomeID string) (*models.Position, error)
 	SavePosition(ctx context.Context, position *models.Position) error
 	GetAllPositions(ctx context.Context) ([]models.Position, error)
+	GetUnresolvedPositions(ctx context.Context) ([]models.Position, error)
+	UpdatePositionTriggerState(ctx context.Context, id uuid.UUID, state models.PositionTriggerState, at time.Time) error
+	UpdatePositionPeakPrice(ctx context.Context, id uuid.UUID, peak decimal.Decimal) error
+	UpdatePositionMark(ctx context.Context, id uuid.UUID, price, unrealizedPnL decimal.Decimal, at time.Time) error
 
 	// Risk operations
 	GetActiveRiskRules(ctx context.Context, fundID uuid.UUID) ([]models.RiskRule, error)
 	GetRiskRulesByType(ctx context.Context, fundID uuid.UUID, ruleType models.RiskRuleType) ([]models.RiskRule, error)
+	GetRiskRulesAt(ctx context.Context, fundID uuid.UUID, at time.Time) ([]models.RiskRule, error)
+	GetRiskRuleVersions(ctx context.Context, fundID, ruleID uuid.UUID) ([]models.RiskRule, error)
+	SaveRiskRuleVersion(ctx context.Context, prev, next *models.RiskRule) error
 	CreateRiskEvent(ctx context.Context, event *models.RiskEvent) error
 	CreateAuditLog(ctx context.Context, log *models.AuditLog) error
+	GetIntentAuditLogs(ctx context.Context, intentID uuid.UUID) ([]models.AuditLog, error)
+
+	// Execution record operations
+	CreateExecutionRecord(ctx context.Context, record *models.ExecutionRecord) error
+	GetFundFills(ctx context.Context, fundID uuid.UUID) ([]models.ExecutionRecord, error)
+
+	// Audit chain operations
+	GetChainRecord(ctx context.Context, entry models.ChainEntry) (models.ChainRecord, error)
+	GetChainEntries(ctx context.Context, fundID uuid.UUID, afterSeq int64, limit int) ([]models.ChainEntry, error)
+	GetChainHead(ctx context.Context, fundID uuid.UUID) (*models.ChainEntry, error)
+	GetChainFunds(ctx context.Context) ([]uuid.UUID, error)
+	CreateChainHeadSnapshot(ctx context.Context, snapshot *models.ChainHeadSnapshot) error
+	GetLatestChainHeadSnapshot(ctx context.Context, fundID uuid.UUID) (*models.ChainHeadSnapshot, error)
 
 	// Market operations
 	GetActiveMarkets(ctx context.Context) ([]models.MarketData, error)
+	SettleMarket(ctx context.Context, market *models.MarketData, positions []models.Position, tasks []models.RedemptionTask) error
+	UpsertMarkets(ctx context.Context, markets []models.MarketData) error
+	CloseStaleMarkets(ctx context.Context, syncedBefore time.Time) (int64, error)
+	GetMarketOutcomes(ctx context.Context, tokenIDs []string) ([]models.MarketOutcome, error)
+	GetMarketData(ctx context.Context, id string) (*models.MarketData, error)
+	GetMarketsUpdatedSince(ctx context.Context, since time.Time) ([]models.MarketData, error)
+
+	// Price history operations
+	SavePriceSamples(ctx context.Context, samples []models.PriceSample, candles []models.Candle) error
+	GetCandles(ctx context.Context, tokenID string, interval models.CandleInterval, from, to time.Time, limit int) ([]models.Candle, error)
+	PrunePriceHistory(ctx context.Context, samplesBefore time.Time, candlesBefore map[models.CandleInterval]time.Time) (int64, error)
+
+	// Kill switch operations
+	SaveKillSwitch(ctx context.Context, ks *models.KillSwitch) error
+	GetActiveKillSwitches(ctx context.Context) ([]models.KillSwitch, error)
+	GetKillSwitchToggles(ctx context.Context, limit int) ([]models.KillSwitchToggle, error)
+
+	// Investment ledger operations
+	CreateInvestmentTransaction(ctx context.Context, txn *models.InvestmentTransaction) error
+	GetInvestmentTransaction(ctx context.Context, id uuid.UUID) (*models.InvestmentTransaction, error)
+	GetInvestmentTransactions(ctx context.Context, fundID uuid.UUID, investor string, statuses []models.InvestmentTxStatus, offset, limit int) ([]models.InvestmentTransaction, int64, error)
+	GetPendingInvestmentTransactions(ctx context.Context, fundID uuid.UUID, before time.Time) ([]models.InvestmentTransaction, error)
+	GetPendingRedeemShares(ctx context.Context, fundID uuid.UUID, investor string) (decimal.Decimal, error)
+	IsDepositTxUsed(ctx context.Context, txHash string) (bool, error)
+	CancelInvestmentTransaction(ctx context.Context, txn *models.InvestmentTransaction) (bool, error)
+	SettleInvestmentTransaction(ctx context.Context, txn *models.InvestmentTransaction) (bool, error)
+	GetShareHolding(ctx context.Context, fundID uuid.UUID, investor string) (*models.ShareHolding, error)
+	GetFundShareHoldings(ctx context.Context, fundID uuid.UUID) ([]models.ShareHolding, error)
+	GetInvestorShareHoldings(ctx context.Context, investor string) ([]models.ShareHolding, error)
+	GetInvestorSettledTransactions(ctx context.Context, investor string) ([]models.InvestmentTransaction, error)
+	GetFundSettledTransactions(ctx context.Context, fundID uuid.UUID) ([]models.InvestmentTransaction, error)
+
+	// Fee operations
+	SaveFeeAccrual(ctx context.Context, fund *models.Fund, entries []models.FeeEntry) error
+	CrystallizeFees(ctx context.Context, fund *models.Fund, entries []models.FeeEntry) error
+	GetFeeEntries(ctx context.Context, fundID uuid.UUID, offset, limit int) ([]models.FeeEntry, int64, error)
+	GetFeeTotals(ctx context.Context, fundID uuid.UUID) ([]models.FeeTotal, error)
+	GetInvestorFeePayments(ctx context.Context, investor string) ([]models.FeeEntry, error)
+	GetFundInvestorFeePayments(ctx context.Context, fundID uuid.UUID) ([]models.FeeEntry, error)
+
+	// Vault indexer operations
+	GetVaultCheckpoint(ctx context.Context, fundID uuid.UUID) (*models.VaultCheckpoint, error)
+	SaveVaultEvents(ctx context.Context, checkpoint *models.VaultCheckpoint, events []models.VaultEvent) error
+	RollbackVaultEvents(ctx context.Context, checkpoint *models.VaultCheckpoint) (int64, error)
+	GetConfirmedVaultDeposit(ctx context.Context, fundID uuid.UUID, txHash string) (*models.VaultEvent, error)
+
+	// Cash operations
+	CreateCashSnapshots(ctx context.Context, snapshots []models.CashSnapshot) error
+	GetLatestCashSnapshot(ctx context.Context, fundID uuid.UUID, account models.CashAccount) (*models.CashSnapshot, error)
+	GetCommittedBuyNotional(ctx context.Context, fundID uuid.UUID, excludeIntentID uuid.UUID) (decimal.Decimal, error)
+
+	// Performance operations
+	SaveFundPerformance(ctx context.Context, perf *models.FundPerformance) error
+	GetFundPerformance(ctx context.Context, fundID uuid.UUID) (*models.FundPerformance, error)
+
+	// Leaderboard operations
+	GetLeaderboardOptOuts(ctx context.Context) ([]string, error)
+	IsLeaderboardOptOut(ctx context.Context, investor string) (bool, error)
+	SetLeaderboardOptOut(ctx context.Context, investor string, optOut bool) error
+
+	// Manager dashboard operations
+	GetManagedFunds(ctx context.Context) ([]models.Fund, error)
+	GetManagerFunds(ctx context.Context, manager, keyword string, offset, limit int) ([]models.Fund, int64, error)
+	CountFundIntentsByStatus(ctx context.Context, fundID uuid.UUID, since time.Time) (map[models.IntentStatus]int64, error)
+	GetFundRiskEvents(ctx context.Context, fundID uuid.UUID, since time.Time, limit int) ([]models.RiskEvent, error)
+	CountInvestors(ctx context.Context, fundIDs []uuid.UUID) (int64, error)
+	SaveFundHealth(ctx context.Context, health *models.FundHealth) error
+	GetFundHealths(ctx context.Context, fundIDs []uuid.UUID) ([]models.FundHealth, error)
 
 	// Close database connection
 	Close() error
@@ -150,6 +263,26 @@ func NewPostgresRepository(cfg configs.DatabaseConfig) (Repository, error) {
 		&models.RiskEvent{},
 		&models.AuditLog{},
 		&models.MarketData{},
+		&models.MarketOutcome{},
+		&models.PriceSample{},
+		&models.Candle{},
+		&models.KillSwitch{},
+		&models.KillSwitchToggle{},
+		&models.ExecutionRecord{},
+		&models.ChainEntry{},
+		&models.ChainHeadSnapshot{},
+		&models.InvestmentTransaction{},
+		&models.ShareHolding{},
+		&models.NavHistory{},
+		&models.FeeEntry{},
+		&models.VaultEvent{},
+		&models.VaultCheckpoint{},
+		&models.CashEntry{},
+		&models.CashSnapshot{},
+		&models.RedemptionTask{},
+		&models.FundPerformance{},
+		&models.LeaderboardOptOut{},
+		&models.FundHealth{},
 	); err != nil {
 		return nil, fmt.Errorf("数据库迁移失败: %w", err)
 	}
@@ -163,29 +296,170 @@ type postgresRepository struct {
 }
 
 // TODO
+// GetFund 查询基金，不存在时返回 nil
 func (p postgresRepository) GetFund(ctx context.Context, id uuid.UUID) (*models.Fund, error) {
-	//TODO implement me
-	panic("implement me")
+	var fund models.Fund
+	result := p.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&fund)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &fund, nil
 }
 
+// GetActiveFunds 查询运营中的基金，含清盘中的基金：清盘期间仍需估值、结算申赎并执行平仓
 func (p postgresRepository) GetActiveFunds(ctx context.Context) ([]models.Fund, error) {
-	//TODO implement me
-	panic("implement me")
+	var funds []models.Fund
+	err := p.db.WithContext(ctx).
+		Where("status IN ?", []string{models.FundStatusActive, models.FundStatusLiquidating}).
+		Find(&funds).Error
+	return funds, err
 }
 
+// GetVaultFunds 查询已配置链上 Vault 地址的基金（含清盘中的基金）
+func (p postgresRepository) GetVaultFunds(ctx context.Context) ([]models.Fund, error) {
+	var funds []models.Fund
+	err := p.db.WithContext(ctx).Where("vault_address <> ''").Find(&funds).Error
+	return funds, err
+}
+
+// UpdateFund 保存基金配置与状态
+// 份额、现金及费用由申赎结算、费用计提等专用方法原子更新，此处不覆盖
 func (p postgresRepository) UpdateFund(ctx context.Context, fund *models.Fund) error {
-	//TODO implement me
-	panic("implement me")
+	return p.db.WithContext(ctx).
+		Omit("total_shares", "vault_cash", "execution_cash", "accrued_fees", "accrued_performance_fee",
+			"performance_hwm", "fees_accrued_at", "fees_crystallized_at").
+		Save(fund).Error
+}
+
+// UpdateFundHighWaterMark 更新基金单位净值历史高点
+func (p postgresRepository) UpdateFundHighWaterMark(ctx context.Context, fundID uuid.UUID, highWaterMark decimal.Decimal) error {
+	return p.db.WithContext(ctx).Model(&models.Fund{}).
+		Where("id = ?", fundID).
+		Update("high_water_mark", highWaterMark).Error
+}
+
+// UpdateFundStatus 基金状态由 from 切换为 to，当前状态不是 from 时返回 false
+func (p postgresRepository) UpdateFundStatus(ctx context.Context, fundID uuid.UUID, from, to string) (bool, error) {
+	result := p.db.WithContext(ctx).Model(&models.Fund{}).
+		Where("id = ? AND status = ?", fundID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ResumeLiquidatingFund 解除清仓模式：恢复为正常运营并以给定单位净值重置历史高点，基金不处于清仓中时返回 false
+func (p postgresRepository) ResumeLiquidatingFund(ctx context.Context, fundID uuid.UUID, highWaterMark decimal.Decimal) (bool, error) {
+	result := p.db.WithContext(ctx).Model(&models.Fund{}).
+		Where("id = ? AND status = ?", fundID, models.FundStatusLiquidating).
+		Updates(map[string]interface{}{
+			"status":          models.FundStatusActive,
+			"high_water_mark": highWaterMark,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordCashEntries 写入现金流水并增减对应基金账户余额，在同一事务内完成
+func (p postgresRepository) RecordCashEntries(ctx context.Context, entries []models.CashEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return applyCashEntries(tx, entries)
+	})
 }
 
+// applyCashEntries 在事务内写入现金流水并更新基金现金余额
+func applyCashEntries(tx *gorm.DB, entries []models.CashEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := tx.Create(&entries).Error; err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		column := "vault_cash"
+		if entry.Account == models.CashAccountExecution {
+			column = "execution_cash"
+		}
+		if err := tx.Model(&models.Fund{}).Where("id = ?", entry.FundID).
+			Update(column, gorm.Expr("COALESCE("+column+", 0) + ?", entry.Amount)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveFundValuation 写入净值历史，正式净值同时更新基金单位净值与规模
+func (p postgresRepository) SaveFundValuation(ctx context.Context, history *models.NavHistory) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+		if history.Kind != models.NavKindOfficial {
+			return nil
+		}
+		return tx.Model(&models.Fund{}).Where("id = ?", history.FundID).
+			Updates(map[string]interface{}{
+				"current_nav": history.NavPerShare,
+				"total_aum":   history.TotalAUM,
+			}).Error
+	})
+}
+
+// GetNavHistory 查询时间区间内的净值历史，按时间升序
+func (p postgresRepository) GetNavHistory(ctx context.Context, fundID uuid.UUID, kind models.NavKind, from, to time.Time) ([]models.NavHistory, error) {
+	var history []models.NavHistory
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND kind = ? AND recorded_at >= ? AND recorded_at <= ?", fundID, kind, from, to).
+		Order("recorded_at ASC").
+		Find(&history).Error
+	return history, err
+}
+
+// GetLatestNavHistory 查询最近一条净值记录，不存在时返回 nil
+func (p postgresRepository) GetLatestNavHistory(ctx context.Context, fundID uuid.UUID, kind models.NavKind) (*models.NavHistory, error) {
+	var history models.NavHistory
+	result := p.db.WithContext(ctx).
+		Where("fund_id = ? AND kind = ?", fundID, kind).
+		Order("recorded_at DESC").
+		Limit(1).
+		Find(&history)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &history, nil
+}
+
+// CreateTradeIntent 创建交易意图，未指定 ID 时自动生成
 func (p postgresRepository) CreateTradeIntent(ctx context.Context, intent *models.TradeIntent) error {
-	//TODO implement me
-	panic("implement me")
+	if intent.ID == uuid.Nil {
+		intent.ID = uuid.New()
+	}
+	return p.db.WithContext(ctx).Create(intent).Error
 }
 
+// GetTradeIntent 查询交易意图，不存在时返回 nil
 func (p postgresRepository) GetTradeIntent(ctx context.Context, id uuid.UUID) (*models.TradeIntent, error) {
-	//TODO implement me
-	panic("implement me")
+	var intent models.TradeIntent
+	result := p.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&intent)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &intent, nil
 }
 
 func (p postgresRepository) GetPendingIntents(ctx context.Context, limit int) ([]models.TradeIntent, error) {
@@ -198,54 +472,1226 @@ func (p postgresRepository) GetStaleApprovedIntents(ctx context.Context, staleTi
 	panic("implement me")
 }
 
+// UpdateTradeIntent 保存交易意图
 func (p postgresRepository) UpdateTradeIntent(ctx context.Context, intent *models.TradeIntent) error {
-	//TODO implement me
-	panic("implement me")
+	return p.db.WithContext(ctx).Save(intent).Error
+}
+
+// CompleteIntentReview 记录人工复核（或超时）结论，仅当意图仍处于待复核状态时生效，已被其他复核处理时返回 false
+func (p postgresRepository) CompleteIntentReview(ctx context.Context, intent *models.TradeIntent) (bool, error) {
+	result := p.db.WithContext(ctx).Model(&models.TradeIntent{}).
+		Where("id = ? AND status = ?", intent.ID, models.IntentStatusManualReview).
+		Updates(map[string]interface{}{
+			"status":        intent.Status,
+			"reject_reason": intent.RejectReason,
+			"reviewed_by":   intent.ReviewedBy,
+			"reviewed_at":   intent.ReviewedAt,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetIntentsByStatus 按状态查询意图，按创建时间升序
+func (p postgresRepository) GetIntentsByStatus(ctx context.Context, status models.IntentStatus, limit int) ([]models.TradeIntent, error) {
+	var intents []models.TradeIntent
+	err := p.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&intents).Error
+	return intents, err
+}
+
+// GetOverdueReviewIntents 查询超过复核截止时间仍未处理的意图
+func (p postgresRepository) GetOverdueReviewIntents(ctx context.Context, now time.Time, limit int) ([]models.TradeIntent, error) {
+	var intents []models.TradeIntent
+	err := p.db.WithContext(ctx).
+		Where("status = ? AND review_due_at IS NOT NULL AND review_due_at <= ?", models.IntentStatusManualReview, now).
+		Order("review_due_at ASC").
+		Limit(limit).
+		Find(&intents).Error
+	return intents, err
+}
+
+// GetSettledFundIntents 按 (更新时间, ID) 游标倒序查询基金已终结的意图，beforeID 为 uuid.Nil 时仅按时间截止
+func (p postgresRepository) GetSettledFundIntents(ctx context.Context, fundID uuid.UUID,
+	statuses []models.IntentStatus, beforeAt time.Time, beforeID uuid.UUID, limit int) ([]models.TradeIntent, error) {
+	var intents []models.TradeIntent
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND status IN ? AND (updated_at, id) < (?, ?)", fundID, statuses, beforeAt, beforeID).
+		Order("updated_at DESC, id DESC").
+		Limit(limit).
+		Find(&intents).Error
+	return intents, err
 }
 
 func (p postgresRepository) GetFundPositions(ctx context.Context, fundID uuid.UUID) ([]models.Position, error) {
-	//TODO implement me
-	panic("implement me")
+	var positions []models.Position
+	err := p.db.WithContext(ctx).Where("fund_id = ?", fundID).Find(&positions).Error
+	return positions, err
 }
 
+// GetPosition 查询基金在某市场结果上的持仓，不存在时返回 nil
 func (p postgresRepository) GetPosition(ctx context.Context, fundID uuid.UUID, marketID, outcomeID string) (*models.Position, error) {
-	//TODO implement me
-	panic("implement me")
+	var position models.Position
+	result := p.db.WithContext(ctx).
+		Where("fund_id = ? AND market_id = ? AND outcome_id = ?", fundID, marketID, outcomeID).
+		Limit(1).Find(&position)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &position, nil
 }
 
 func (p postgresRepository) SavePosition(ctx context.Context, position *models.Position) error {
-	//TODO implement me
-	panic("implement me")
+	return p.db.WithContext(ctx).Save(position).Error
 }
 
 func (p postgresRepository) GetAllPositions(ctx context.Context) ([]models.Position, error) {
-	//TODO implement me
-	panic("implement me")
+	var positions []models.Position
+	err := p.db.WithContext(ctx).Find(&positions).Error
+	return positions, err
 }
 
+// GetUnresolvedPositions 所属市场尚未结算的非零持仓
+func (p postgresRepository) GetUnresolvedPositions(ctx context.Context) ([]models.Position, error) {
+	var positions []models.Position
+	err := p.db.WithContext(ctx).
+		Where("size <> 0 AND resolved_at IS NULL").
+		Order("market_id").
+		Find(&positions).Error
+	return positions, err
+}
+
+// UpdatePositionTriggerState 仅更新触发状态字段，避免覆盖执行器写入的持仓数量
+func (p postgresRepository) UpdatePositionTriggerState(ctx context.Context, id uuid.UUID,
+	state models.PositionTriggerState, at time.Time) error {
+	return p.db.WithContext(ctx).Model(&models.Position{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"trigger_state": state,
+			"triggered_at":  at,
+		}).Error
+}
+
+// UpdatePositionPeakPrice 仅更新持仓最有利价格，避免以内存中的行情价与数量覆盖持仓
+func (p postgresRepository) UpdatePositionPeakPrice(ctx context.Context, id uuid.UUID, peak decimal.Decimal) error {
+	return p.db.WithContext(ctx).Model(&models.Position{}).
+		Where("id = ?", id).
+		Update("peak_price", peak).Error
+}
+
+// UpdatePositionMark 仅更新未结算持仓的现价与未实现盈亏，不覆盖触发状态、结算等由其他流程写入的字段
+func (p postgresRepository) UpdatePositionMark(ctx context.Context, id uuid.UUID,
+	price, unrealizedPnL decimal.Decimal, at time.Time) error {
+	return p.db.WithContext(ctx).Model(&models.Position{}).
+		Where("id = ? AND resolved_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"current_price":  price,
+			"unrealized_pnl": unrealizedPnL,
+			"last_updated":   at,
+		}).Error
+}
+
+// GetActiveRiskRules 查询当前生效的规则版本
 func (p postgresRepository) GetActiveRiskRules(ctx context.Context, fundID uuid.UUID) ([]models.RiskRule, error) {
-	//TODO implement me
-	panic("implement me")
+	return p.GetRiskRulesAt(ctx, fundID, time.Now())
 }
 
+// GetRiskRulesByType 查询当前生效的指定类型规则版本
 func (p postgresRepository) GetRiskRulesByType(ctx context.Context, fundID uuid.UUID, ruleType models.RiskRuleType) ([]models.RiskRule, error) {
-	//TODO implement me
-	panic("implement me")
+	var rules []models.RiskRule
+	err := effectiveRules(p.db.WithContext(ctx), fundID, time.Now()).
+		Where("rule_type = ?", ruleType).
+		Find(&rules).Error
+	return rules, err
+}
+
+// GetRiskRulesAt 查询指定时间点生效的规则版本
+func (p postgresRepository) GetRiskRulesAt(ctx context.Context, fundID uuid.UUID, at time.Time) ([]models.RiskRule, error) {
+	var rules []models.RiskRule
+	err := effectiveRules(p.db.WithContext(ctx), fundID, at).
+		Order("created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// GetRiskRuleVersions 查询规则全部版本，按版本号升序
+func (p postgresRepository) GetRiskRuleVersions(ctx context.Context, fundID, ruleID uuid.UUID) ([]models.RiskRule, error) {
+	var rules []models.RiskRule
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND (rule_id = ? OR id = ?)", fundID, ruleID, ruleID).
+		Order("version ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// SaveRiskRuleVersion 在同一事务中结束旧版本并写入新版本，任一参数可为 nil；旧版本已结束时返回 ErrRuleVersionClosed
+func (p postgresRepository) SaveRiskRuleVersion(ctx context.Context, prev, next *models.RiskRule) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if prev != nil {
+			// 仅结束仍持续生效的版本，并发修改同一规则时只有一个请求成功
+			result := tx.Model(&models.RiskRule{}).
+				Where("id = ? AND effective_to IS NULL", prev.ID).
+				Update("effective_to", prev.EffectiveTo)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrRuleVersionClosed
+			}
+		}
+		if next != nil {
+			return tx.Create(next).Error
+		}
+		return nil
+	})
+}
+
+// effectiveRules 指定时间点生效的规则条件，兼容未设置生效时间的历史数据
+func effectiveRules(db *gorm.DB, fundID uuid.UUID, at time.Time) *gorm.DB {
+	return db.Where("fund_id = ? AND is_active = ?", fundID, true).
+		Where("effective_from IS NULL OR effective_from <= ?", at).
+		Where("effective_to IS NULL OR effective_to > ?", at)
 }
 
+// CreateRiskEvent 写入风控事件并追加到基金哈希链
 func (p postgresRepository) CreateRiskEvent(ctx context.Context, event *models.RiskEvent) error {
-	//TODO implement me
-	panic("implement me")
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	event.TriggeredAt = models.ChainTime(event.TriggeredAt)
+	return p.createChained(ctx, event)
 }
 
+// CreateAuditLog 写入审计日志并追加到基金哈希链
 func (p postgresRepository) CreateAuditLog(ctx context.Context, log *models.AuditLog) error {
-	//TODO implement me
-	panic("implement me")
+	if log.ID == uuid.Nil {
+		log.ID = uuid.New()
+	}
+	log.CheckedAt = models.ChainTime(log.CheckedAt)
+	return p.createChained(ctx, log)
+}
+
+// GetIntentAuditLogs 查询意图的审计日志，按检查时间升序
+func (p postgresRepository) GetIntentAuditLogs(ctx context.Context, intentID uuid.UUID) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := p.db.WithContext(ctx).
+		Where("intent_id = ?", intentID).
+		Order("checked_at ASC").
+		Find(&logs).Error
+	return logs, err
+}
+
+// CreateExecutionRecord 写入执行记录并追加到基金哈希链
+func (p postgresRepository) CreateExecutionRecord(ctx context.Context, record *models.ExecutionRecord) error {
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+	record.Size = models.ChainDecimal(record.Size)
+	record.FilledSize = models.ChainDecimal(record.FilledSize)
+	record.AvgPrice = models.ChainDecimal(record.AvgPrice)
+	record.ExecutedAt = models.ChainTime(record.ExecutedAt)
+	return p.createChained(ctx, record)
+}
+
+// GetFundFills 基金有成交的执行记录（含部分成交），按执行时间升序
+func (p postgresRepository) GetFundFills(ctx context.Context, fundID uuid.UUID) ([]models.ExecutionRecord, error) {
+	var records []models.ExecutionRecord
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND filled_size > 0", fundID).
+		Order("executed_at ASC").
+		Find(&records).Error
+	return records, err
+}
+
+// GetChainRecord 按链条目读取原始记录，记录不存在时返回 nil
+func (p postgresRepository) GetChainRecord(ctx context.Context, entry models.ChainEntry) (models.ChainRecord, error) {
+	var record models.ChainRecord
+	switch entry.RecordType {
+	case models.ChainRecordAudit:
+		record = &models.AuditLog{}
+	case models.ChainRecordRisk:
+		record = &models.RiskEvent{}
+	case models.ChainRecordExecution:
+		record = &models.ExecutionRecord{}
+	default:
+		return nil, fmt.Errorf("未知的链记录类型: %s", entry.RecordType)
+	}
+
+	result := p.db.WithContext(ctx).Where("id = ?", entry.RecordID).Limit(1).Find(record)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// GetChainEntries 按序号分页读取基金哈希链
+func (p postgresRepository) GetChainEntries(ctx context.Context, fundID uuid.UUID, afterSeq int64, limit int) ([]models.ChainEntry, error) {
+	var entries []models.ChainEntry
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND seq > ?", fundID, afterSeq).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetChainHead 基金哈希链最新条目，链为空时返回 nil
+func (p postgresRepository) GetChainHead(ctx context.Context, fundID uuid.UUID) (*models.ChainEntry, error) {
+	var head models.ChainEntry
+	result := p.db.WithContext(ctx).Where("fund_id = ?", fundID).Order("seq DESC").Limit(1).Find(&head)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &head, nil
+}
+
+// GetChainFunds 拥有哈希链的基金ID（全局事件使用零值ID）
+func (p postgresRepository) GetChainFunds(ctx context.Context) ([]uuid.UUID, error) {
+	var fundIDs []uuid.UUID
+	err := p.db.WithContext(ctx).Model(&models.ChainEntry{}).Distinct("fund_id").Pluck("fund_id", &fundIDs).Error
+	return fundIDs, err
+}
+
+// CreateChainHeadSnapshot 保存链头导出快照
+func (p postgresRepository) CreateChainHeadSnapshot(ctx context.Context, snapshot *models.ChainHeadSnapshot) error {
+	return p.db.WithContext(ctx).Create(snapshot).Error
+}
+
+// GetLatestChainHeadSnapshot 基金最近一次链头快照，不存在时返回 nil
+func (p postgresRepository) GetLatestChainHeadSnapshot(ctx context.Context, fundID uuid.UUID) (*models.ChainHeadSnapshot, error) {
+	var snapshot models.ChainHeadSnapshot
+	result := p.db.WithContext(ctx).Where("fund_id = ?", fundID).Order("exported_at DESC").Limit(1).Find(&snapshot)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
+
+// createChained 在同一事务中写入记录并追加哈希链条目
+// 以基金维度的事务级 advisory lock 串行化同一基金的追加
+func (p postgresRepository) createChained(ctx context.Context, record models.ChainRecord) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+
+		fundID := record.ChainFundID()
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "audit_chain:"+fundID.String()).Error; err != nil {
+			return err
+		}
+
+		var head models.ChainEntry
+		if err := tx.Where("fund_id = ?", fundID).Order("seq DESC").Limit(1).Find(&head).Error; err != nil {
+			return err
+		}
+
+		seq := head.Seq + 1
+		contentHash := models.ChainContentHash(record)
+		entry := &models.ChainEntry{
+			FundID:      fundID,
+			Seq:         seq,
+			RecordType:  record.ChainRecordType(),
+			RecordID:    record.ChainRecordID(),
+			ContentHash: contentHash,
+			PrevHash:    head.Hash,
+			Hash: models.ChainLinkHash(head.Hash, fundID, seq,
+				record.ChainRecordType(), record.ChainRecordID(), contentHash),
+		}
+		return tx.Create(entry).Error
+	})
 }
 
+// GetActiveMarkets 未关闭且未结算的市场
 func (p postgresRepository) GetActiveMarkets(ctx context.Context) ([]models.MarketData, error) {
-	//TODO implement me
-	panic("implement me")
+	var markets []models.MarketData
+	err := p.db.WithContext(ctx).
+		Where("active = ? AND closed = ? AND resolved = ?", true, false, false).
+		Find(&markets).Error
+	return markets, err
+}
+
+// SettleMarket 在同一事务中标记市场已结算、按结算价更新持仓并创建兑付任务。
+// 兑付任务按基金与市场唯一，重复结算时不重复创建
+func (p postgresRepository) SettleMarket(ctx context.Context, market *models.MarketData,
+	positions []models.Position, tasks []models.RedemptionTask) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"active", "closed", "resolved", "winning_outcome", "resolved_at", "updated_at"}),
+		}).Create(market).Error; err != nil {
+			return err
+		}
+
+		for i := range positions {
+			if err := tx.Model(&positions[i]).
+				Select("current_price", "unrealized_pnl", "settlement_price", "realized_pnl",
+					"resolved_at", "trigger_state", "last_updated").
+				Updates(&positions[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(tasks) > 0 {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tasks).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UpsertMarkets 写入市场目录及其结果代币。
+// 结算相关字段由 SettleMarket 维护，目录同步不覆盖
+func (p postgresRepository) UpsertMarkets(ctx context.Context, markets []models.MarketData) error {
+	if len(markets) == 0 {
+		return nil
+	}
+
+	var outcomes []models.MarketOutcome
+	for _, m := range markets {
+		outcomes = append(outcomes, m.Outcomes...)
+	}
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 显式选择全部字段，避免带默认值的零值字段（如 active=false）被跳过
+		if err := tx.Select("*").Omit("Outcomes").Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"question", "description", "slug", "event_id", "category", "tags", "end_date",
+				"active", "closed", "neg_risk", "tick_size", "min_order_size",
+				"best_bid", "best_ask", "last_price", "volume", "liquidity", "synced_at", "updated_at",
+			}),
+		}).Create(&markets).Error; err != nil {
+			return err
+		}
+
+		if len(outcomes) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "token_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"market_id", "name", "index", "price", "updated_at"}),
+		}).Create(&outcomes).Error
+	})
+}
+
+// CloseStaleMarkets 将完整同步后未再出现在目录中的市场标记为已关闭
+func (p postgresRepository) CloseStaleMarkets(ctx context.Context, syncedBefore time.Time) (int64, error) {
+	result := p.db.WithContext(ctx).Model(&models.MarketData{}).
+		Where("closed = ? AND (synced_at IS NULL OR synced_at < ?)", false, syncedBefore).
+		Updates(map[string]interface{}{
+			"closed":     true,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetMarketOutcomes 按代币ID查询市场结果
+func (p postgresRepository) GetMarketOutcomes(ctx context.Context, tokenIDs []string) ([]models.MarketOutcome, error) {
+	var outcomes []models.MarketOutcome
+	if len(tokenIDs) == 0 {
+		return outcomes, nil
+	}
+	err := p.db.WithContext(ctx).Where("token_id IN ?", tokenIDs).Find(&outcomes).Error
+	return outcomes, err
+}
+
+// GetMarketData 查询市场缓存及其结果代币，不存在时返回 nil
+func (p postgresRepository) GetMarketData(ctx context.Context, id string) (*models.MarketData, error) {
+	var market models.MarketData
+	result := p.db.WithContext(ctx).
+		Preload("Outcomes", func(db *gorm.DB) *gorm.DB {
+			return db.Order(clause.OrderByColumn{Column: clause.Column{Name: "index"}})
+		}).
+		Where("id = ?", id).
+		Limit(1).Find(&market)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &market, nil
+}
+
+// GetMarketsUpdatedSince 指定时间后有更新的市场及其结果代币
+func (p postgresRepository) GetMarketsUpdatedSince(ctx context.Context, since time.Time) ([]models.MarketData, error) {
+	var markets []models.MarketData
+	err := p.db.WithContext(ctx).
+		Preload("Outcomes").
+		Where("updated_at > ?", since).
+		Find(&markets).Error
+	return markets, err
+}
+
+// SavePriceSamples 写入价格采样并合并到各周期K线：
+// 同一周期内保留首次开盘价，更新最高、最低、收盘价并累加成交量
+func (p postgresRepository) SavePriceSamples(ctx context.Context, samples []models.PriceSample, candles []models.Candle) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&samples).Error; err != nil {
+			return err
+		}
+		if len(candles) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "token_id"}, {Name: "period"}, {Name: "open_time"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"high":       gorm.Expr("GREATEST(candles.high, excluded.high)"),
+				"low":        gorm.Expr("LEAST(candles.low, excluded.low)"),
+				"close":      gorm.Expr("excluded.close"),
+				"volume":     gorm.Expr("candles.volume + excluded.volume"),
+				"samples":    gorm.Expr("candles.samples + excluded.samples"),
+				"updated_at": gorm.Expr("excluded.updated_at"),
+			}),
+		}).Create(&candles).Error
+	})
+}
+
+// GetCandles 查询区间内的K线（按开盘时间升序），limit 大于0时仅返回最近的 limit 根
+func (p postgresRepository) GetCandles(ctx context.Context, tokenID string, interval models.CandleInterval,
+	from, to time.Time, limit int) ([]models.Candle, error) {
+	query := p.db.WithContext(ctx).
+		Where("token_id = ? AND period = ?", tokenID, interval)
+	if !from.IsZero() {
+		query = query.Where("open_time >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("open_time < ?", to)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var candles []models.Candle
+	if err := query.Order("open_time DESC").Find(&candles).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(candles)-1; i < j; i, j = i+1, j-1 {
+		candles[i], candles[j] = candles[j], candles[i]
+	}
+	return candles, nil
+}
+
+// PrunePriceHistory 按保留期删除价格采样与各周期K线，零值时间表示不清理，返回删除的行数
+func (p postgresRepository) PrunePriceHistory(ctx context.Context, samplesBefore time.Time,
+	candlesBefore map[models.CandleInterval]time.Time) (int64, error) {
+	var deleted int64
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if !samplesBefore.IsZero() {
+			result := tx.Where("sampled_at < ?", samplesBefore).Delete(&models.PriceSample{})
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted += result.RowsAffected
+		}
+		for interval, before := range candlesBefore {
+			if before.IsZero() {
+				continue
+			}
+			result := tx.Where("period = ? AND open_time < ?", interval, before).Delete(&models.Candle{})
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted += result.RowsAffected
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// SaveKillSwitch 更新熔断开关状态并追加切换记录
+func (p postgresRepository) SaveKillSwitch(ctx context.Context, ks *models.KillSwitch) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 全局开关 ScopeID 为空，使用 upsert 而非 Save
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(ks).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.KillSwitchToggle{
+			Scope:         ks.Scope,
+			ScopeID:       ks.ScopeID,
+			Active:        ks.Active,
+			AllowStopLoss: ks.AllowStopLoss,
+			Reason:        ks.Reason,
+			Operator:      ks.Operator,
+			CreatedAt:     ks.UpdatedAt,
+		}).Error
+	})
+}
+
+// GetActiveKillSwitches 查询当前生效的熔断开关
+func (p postgresRepository) GetActiveKillSwitches(ctx context.Context) ([]models.KillSwitch, error) {
+	var switches []models.KillSwitch
+	err := p.db.WithContext(ctx).Where("active = ?", true).Find(&switches).Error
+	return switches, err
+}
+
+// GetKillSwitchToggles 查询最近的熔断开关切换记录
+func (p postgresRepository) GetKillSwitchToggles(ctx context.Context, limit int) ([]models.KillSwitchToggle, error) {
+	var toggles []models.KillSwitchToggle
+	err := p.db.WithContext(ctx).Order("created_at DESC").Limit(limit).Find(&toggles).Error
+	return toggles, err
+}
+
+// CreateInvestmentTransaction 写入申赎请求
+func (p postgresRepository) CreateInvestmentTransaction(ctx context.Context, txn *models.InvestmentTransaction) error {
+	return p.db.WithContext(ctx).Create(txn).Error
+}
+
+// GetInvestmentTransaction 查询申赎记录，不存在时返回 nil
+func (p postgresRepository) GetInvestmentTransaction(ctx context.Context, id uuid.UUID) (*models.InvestmentTransaction, error) {
+	var txn models.InvestmentTransaction
+	result := p.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&txn)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &txn, nil
+}
+
+// GetInvestmentTransactions 分页查询申赎记录，investor 为空时查询基金全部记录，按创建时间倒序
+func (p postgresRepository) GetInvestmentTransactions(ctx context.Context, fundID uuid.UUID, investor string,
+	statuses []models.InvestmentTxStatus, offset, limit int) ([]models.InvestmentTransaction, int64, error) {
+	query := p.db.WithContext(ctx).Model(&models.InvestmentTransaction{}).Where("fund_id = ?", fundID)
+	if investor != "" {
+		query = query.Where("investor = ?", investor)
+	}
+	if len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var txns []models.InvestmentTransaction
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&txns).Error
+	return txns, total, err
+}
+
+// GetPendingInvestmentTransactions 查询指定时间前提交的待结算申赎请求，按提交顺序
+func (p postgresRepository) GetPendingInvestmentTransactions(ctx context.Context, fundID uuid.UUID, before time.Time) ([]models.InvestmentTransaction, error) {
+	var txns []models.InvestmentTransaction
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND status = ? AND created_at <= ?", fundID, models.InvestmentTxPending, before).
+		Order("created_at ASC").
+		Find(&txns).Error
+	return txns, err
+}
+
+// GetPendingRedeemShares 投资人待结算赎回的份额合计
+func (p postgresRepository) GetPendingRedeemShares(ctx context.Context, fundID uuid.UUID, investor string) (decimal.Decimal, error) {
+	var total decimal.NullDecimal
+	err := p.db.WithContext(ctx).Model(&models.InvestmentTransaction{}).
+		Select("SUM(shares)").
+		Where("fund_id = ? AND investor = ? AND type = ? AND status = ?",
+			fundID, investor, models.InvestmentTxRedeem, models.InvestmentTxPending).
+		Scan(&total).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !total.Valid {
+		return decimal.Zero, nil
+	}
+	return total.Decimal, nil
+}
+
+// IsDepositTxUsed 入金交易哈希是否已被未取消的申购请求使用
+func (p postgresRepository) IsDepositTxUsed(ctx context.Context, txHash string) (bool, error) {
+	var count int64
+	err := p.db.WithContext(ctx).Model(&models.InvestmentTransaction{}).
+		Where("tx_hash = ? AND type = ? AND status <> ?",
+			txHash, models.InvestmentTxDeposit, models.InvestmentTxCanceled).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CancelInvestmentTransaction 取消待结算的申赎请求，记录已不是待结算状态时返回 false
+func (p postgresRepository) CancelInvestmentTransaction(ctx context.Context, txn *models.InvestmentTransaction) (bool, error) {
+	result := p.db.WithContext(ctx).Model(&models.InvestmentTransaction{}).
+		Where("id = ? AND status = ?", txn.ID, models.InvestmentTxPending).
+		Updates(map[string]interface{}{
+			"status":      models.InvestmentTxCanceled,
+			"note":        txn.Note,
+			"canceled_at": txn.CanceledAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SettleInvestmentTransaction 结算申赎请求：更新记录、投资人份额及基金总份额与规模，在同一事务内完成
+// 记录已不是待结算状态（如已被取消）时返回 false；
+// 申购须对应已确认且未被其他申购结算过的 Vault 入金事件，否则保持待结算并返回 false
+func (p postgresRepository) SettleInvestmentTransaction(ctx context.Context, txn *models.InvestmentTransaction) (bool, error) {
+	settled := false
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.InvestmentTransaction{}).
+			Where("id = ? AND status = ?", txn.ID, models.InvestmentTxPending)
+		if txn.Type == models.InvestmentTxDeposit {
+			query = query.
+				Where("EXISTS (SELECT 1 FROM vault_events WHERE fund_id = ? AND tx_hash = ? AND type = ? AND status = ?)",
+					txn.FundID, txn.TxHash, models.VaultEventDeposit, models.VaultEventConfirmed).
+				Where("NOT EXISTS (SELECT 1 FROM investment_transactions s WHERE s.tx_hash = ? AND s.status = ?)",
+					txn.TxHash, models.InvestmentTxSettled)
+		}
+		result := query.Updates(map[string]interface{}{
+			"status":       models.InvestmentTxSettled,
+			"amount":       txn.Amount,
+			"shares":       txn.Shares,
+			"executed_nav": txn.ExecutedNAV,
+			"settled_at":   txn.SettledAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		shares, amount := txn.Shares, txn.Amount
+		switch txn.Type {
+		case models.InvestmentTxDeposit:
+			holding := models.ShareHolding{
+				FundID:        txn.FundID,
+				Investor:      txn.Investor,
+				Shares:        shares,
+				HighWaterMark: txn.ExecutedNAV,
+				UpdatedAt:     *txn.SettledAt,
+			}
+			// 追加申购时高水位按份额加权
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "fund_id"}, {Name: "investor"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"high_water_mark": gorm.Expr(
+						"(share_holdings.shares * COALESCE(share_holdings.high_water_mark, 0) + ? * ?) / NULLIF(share_holdings.shares + ?, 0)",
+						shares, txn.ExecutedNAV, shares),
+					"shares":     gorm.Expr("share_holdings.shares + ?", shares),
+					"updated_at": holding.UpdatedAt,
+				}),
+			}).Create(&holding).Error; err != nil {
+				return err
+			}
+		case models.InvestmentTxRedeem:
+			result := tx.Model(&models.ShareHolding{}).
+				Where("fund_id = ? AND investor = ? AND shares >= ?", txn.FundID, txn.Investor, shares).
+				Updates(map[string]interface{}{
+					"shares":     gorm.Expr("shares - ?", shares),
+					"updated_at": *txn.SettledAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: 投资人 %s", ErrInsufficientShares, txn.Investor)
+			}
+			shares, amount = shares.Neg(), amount.Neg()
+		default:
+			return fmt.Errorf("未知的申赎类型: %s", txn.Type)
+		}
+
+		settled = true
+		if err := tx.Model(&models.Fund{}).Where("id = ?", txn.FundID).
+			Updates(map[string]interface{}{
+				"total_shares": gorm.Expr("COALESCE(total_shares, 0) + ?", shares),
+				"total_aum":    gorm.Expr("COALESCE(total_aum, 0) + ?", amount),
+			}).Error; err != nil {
+			return err
+		}
+
+		// 申赎资金进出 Vault
+		kind := models.CashEntryDeposit
+		if txn.Type == models.InvestmentTxRedeem {
+			kind = models.CashEntryRedeem
+		}
+		return applyCashEntries(tx, []models.CashEntry{{
+			FundID:    txn.FundID,
+			Account:   models.CashAccountVault,
+			Kind:      kind,
+			Amount:    amount,
+			Reference: &txn.ID,
+			CreatedAt: *txn.SettledAt,
+		}})
+	})
+	if err != nil {
+		return false, err
+	}
+	return settled, nil
+}
+
+// GetShareHolding 查询投资人持有份额，未持有时返回 nil
+func (p postgresRepository) GetShareHolding(ctx context.Context, fundID uuid.UUID, investor string) (*models.ShareHolding, error) {
+	var holding models.ShareHolding
+	result := p.db.WithContext(ctx).Where("fund_id = ? AND investor = ?", fundID, investor).Limit(1).Find(&holding)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &holding, nil
+}
+
+// GetFundShareHoldings 查询基金全部持有份额大于0的投资人
+func (p postgresRepository) GetFundShareHoldings(ctx context.Context, fundID uuid.UUID) ([]models.ShareHolding, error) {
+	var holdings []models.ShareHolding
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND shares > 0", fundID).
+		Order("investor ASC").
+		Find(&holdings).Error
+	return holdings, err
+}
+
+// GetInvestorShareHoldings 查询投资人在各基金的份额记录（含已全部赎回的基金）
+func (p postgresRepository) GetInvestorShareHoldings(ctx context.Context, investor string) ([]models.ShareHolding, error) {
+	var holdings []models.ShareHolding
+	err := p.db.WithContext(ctx).
+		Where("investor = ?", investor).
+		Order("fund_id ASC").
+		Find(&holdings).Error
+	return holdings, err
+}
+
+// GetInvestorSettledTransactions 查询投资人在各基金已结算的申赎记录，按结算时间升序
+func (p postgresRepository) GetInvestorSettledTransactions(ctx context.Context, investor string) ([]models.InvestmentTransaction, error) {
+	var txns []models.InvestmentTransaction
+	err := p.db.WithContext(ctx).
+		Where("investor = ? AND status = ?", investor, models.InvestmentTxSettled).
+		Order("settled_at ASC").
+		Find(&txns).Error
+	return txns, err
+}
+
+// GetFundSettledTransactions 查询基金全部已结算的申赎记录，按结算时间升序
+func (p postgresRepository) GetFundSettledTransactions(ctx context.Context, fundID uuid.UUID) ([]models.InvestmentTransaction, error) {
+	var txns []models.InvestmentTransaction
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND status = ?", fundID, models.InvestmentTxSettled).
+		Order("settled_at ASC").
+		Find(&txns).Error
+	return txns, err
+}
+
+// SaveFeeAccrual 写入费用计提流水并累加基金应计费用，同时更新计提截至时间
+func (p postgresRepository) SaveFeeAccrual(ctx context.Context, fund *models.Fund, entries []models.FeeEntry) error {
+	total, performance := decimal.Zero, decimal.Zero
+	for _, entry := range entries {
+		total = total.Add(entry.Amount)
+		if entry.Type == models.FeeTypePerformance {
+			performance = performance.Add(entry.Amount)
+		}
+	}
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(entries) > 0 {
+			if err := tx.Create(&entries).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Fund{}).Where("id = ?", fund.ID).
+			Updates(map[string]interface{}{
+				"accrued_fees":            gorm.Expr("COALESCE(accrued_fees, 0) + ?", total),
+				"accrued_performance_fee": gorm.Expr("COALESCE(accrued_performance_fee, 0) + ?", performance),
+				"fees_accrued_at":         fund.FeesAccruedAt,
+			}).Error
+	})
+}
+
+// CrystallizeFees 写入费用结晶流水，在同一事务内完成：
+// 基金层面费用冲减应计费用；投资人层面业绩报酬扣减投资人份额并重置其高水位；支付金额记入 Vault 现金流水
+func (p postgresRepository) CrystallizeFees(ctx context.Context, fund *models.Fund, entries []models.FeeEntry) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		accrued, performance, burned, investorPaid := decimal.Zero, decimal.Zero, decimal.Zero, decimal.Zero
+		for _, entry := range entries {
+			if entry.Investor == "" {
+				accrued = accrued.Add(entry.Amount)
+				if entry.Type == models.FeeTypePerformance {
+					performance = performance.Add(entry.Amount)
+				}
+				continue
+			}
+
+			result := tx.Model(&models.ShareHolding{}).
+				Where("fund_id = ? AND investor = ? AND shares >= ?", entry.FundID, entry.Investor, entry.Shares).
+				Updates(map[string]interface{}{
+					"shares":          gorm.Expr("shares - ?", entry.Shares),
+					"high_water_mark": entry.NAV,
+					"updated_at":      entry.PeriodEnd,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("%w: 投资人 %s", ErrInsufficientShares, entry.Investor)
+			}
+			burned = burned.Add(entry.Shares)
+			investorPaid = investorPaid.Add(entry.Amount)
+		}
+
+		if len(entries) == 0 {
+			return tx.Model(&models.Fund{}).Where("id = ?", fund.ID).
+				Updates(map[string]interface{}{
+					"performance_hwm":      fund.PerformanceHWM,
+					"fees_crystallized_at": fund.FeesCrystallizedAt,
+				}).Error
+		}
+
+		if err := tx.Create(&entries).Error; err != nil {
+			return err
+		}
+
+		// 费用由 Vault 支付
+		payments := make([]models.CashEntry, 0, len(entries))
+		for i := range entries {
+			payments = append(payments, models.CashEntry{
+				FundID:    fund.ID,
+				Account:   models.CashAccountVault,
+				Kind:      models.CashEntryFee,
+				Amount:    entries[i].Amount.Neg(),
+				Reference: &entries[i].ID,
+			})
+		}
+		if err := applyCashEntries(tx, payments); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Fund{}).Where("id = ?", fund.ID).
+			Updates(map[string]interface{}{
+				"accrued_fees":            gorm.Expr("COALESCE(accrued_fees, 0) - ?", accrued),
+				"accrued_performance_fee": gorm.Expr("COALESCE(accrued_performance_fee, 0) - ?", performance),
+				"total_shares":            gorm.Expr("COALESCE(total_shares, 0) - ?", burned),
+				"total_aum":               gorm.Expr("COALESCE(total_aum, 0) - ?", investorPaid),
+				"performance_hwm":         fund.PerformanceHWM,
+				"fees_crystallized_at":    fund.FeesCrystallizedAt,
+			}).Error
+	})
+}
+
+// GetFeeEntries 分页查询费用流水，按时间倒序
+func (p postgresRepository) GetFeeEntries(ctx context.Context, fundID uuid.UUID, offset, limit int) ([]models.FeeEntry, int64, error) {
+	var (
+		entries []models.FeeEntry
+		total   int64
+	)
+	query := p.db.WithContext(ctx).Model(&models.FeeEntry{}).Where("fund_id = ?", fundID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+// GetInvestorFeePayments 查询按投资人高水位结晶、扣减投资人份额的业绩报酬流水，按时间升序
+func (p postgresRepository) GetInvestorFeePayments(ctx context.Context, investor string) ([]models.FeeEntry, error) {
+	var entries []models.FeeEntry
+	err := p.db.WithContext(ctx).
+		Where("investor = ? AND kind = ?", investor, models.FeeKindPayment).
+		Order("period_end ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetFundInvestorFeePayments 查询基金按投资人高水位结晶、扣减投资人份额的业绩报酬流水，按时间升序
+func (p postgresRepository) GetFundInvestorFeePayments(ctx context.Context, fundID uuid.UUID) ([]models.FeeEntry, error) {
+	var entries []models.FeeEntry
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND kind = ? AND investor <> ''", fundID, models.FeeKindPayment).
+		Order("period_end ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetFeeTotals 按费用类型与流水类型汇总基金费用
+func (p postgresRepository) GetFeeTotals(ctx context.Context, fundID uuid.UUID) ([]models.FeeTotal, error) {
+	var totals []models.FeeTotal
+	err := p.db.WithContext(ctx).Model(&models.FeeEntry{}).
+		Select("type, kind, SUM(amount) AS amount").
+		Where("fund_id = ?", fundID).
+		Group("type, kind").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// GetVaultCheckpoint 查询基金 Vault 索引进度，尚未索引时返回 nil
+func (p postgresRepository) GetVaultCheckpoint(ctx context.Context, fundID uuid.UUID) (*models.VaultCheckpoint, error) {
+	var checkpoint models.VaultCheckpoint
+	result := p.db.WithContext(ctx).Where("fund_id = ?", fundID).Limit(1).Find(&checkpoint)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+// SaveVaultEvents 写入链上事件并推进索引进度，在同一事务内完成
+// 重组后重新出现在主链上的事件按交易哈希与日志序号覆盖，恢复为已确认
+func (p postgresRepository) SaveVaultEvents(ctx context.Context, checkpoint *models.VaultCheckpoint, events []models.VaultEvent) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(events) > 0 {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "tx_hash"}, {Name: "log_index"}},
+				DoUpdates: clause.AssignmentColumns([]string{
+					"block_number", "block_hash", "block_time", "status", "updated_at",
+				}),
+			}).Create(&events).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(checkpoint).Error
+	})
+}
+
+// RollbackVaultEvents 回退索引进度，将其后区块中的事件标记为孤块事件，返回标记的事件数
+func (p postgresRepository) RollbackVaultEvents(ctx context.Context, checkpoint *models.VaultCheckpoint) (int64, error) {
+	var orphaned int64
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.VaultEvent{}).
+			Where("fund_id = ? AND block_number > ? AND status = ?",
+				checkpoint.FundID, checkpoint.BlockNumber, models.VaultEventConfirmed).
+			Update("status", models.VaultEventOrphaned)
+		if result.Error != nil {
+			return result.Error
+		}
+		orphaned = result.RowsAffected
+		return tx.Save(checkpoint).Error
+	})
+	return orphaned, err
+}
+
+// GetConfirmedVaultDeposit 按交易哈希查询基金已确认的 Vault 入金事件，不存在时返回 nil
+func (p postgresRepository) GetConfirmedVaultDeposit(ctx context.Context, fundID uuid.UUID, txHash string) (*models.VaultEvent, error) {
+	var event models.VaultEvent
+	result := p.db.WithContext(ctx).
+		Where("fund_id = ? AND tx_hash = ? AND type = ? AND status = ?",
+			fundID, txHash, models.VaultEventDeposit, models.VaultEventConfirmed).
+		Order("log_index").
+		Limit(1).
+		Find(&event)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &event, nil
+}
+
+// CreateCashSnapshots 写入链上余额快照
+func (p postgresRepository) CreateCashSnapshots(ctx context.Context, snapshots []models.CashSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return p.db.WithContext(ctx).Create(&snapshots).Error
+}
+
+// GetLatestCashSnapshot 查询账户最近一次链上余额快照，不存在时返回 nil
+func (p postgresRepository) GetLatestCashSnapshot(ctx context.Context, fundID uuid.UUID, account models.CashAccount) (*models.CashSnapshot, error) {
+	var snapshot models.CashSnapshot
+	result := p.db.WithContext(ctx).
+		Where("fund_id = ? AND account = ?", fundID, account).
+		Order("recorded_at DESC").
+		Limit(1).
+		Find(&snapshot)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
+
+// GetCommittedBuyNotional 已通过审计或待复核、尚未成交的买入意图占用金额（数量 × 目标价格）
+func (p postgresRepository) GetCommittedBuyNotional(ctx context.Context, fundID uuid.UUID, excludeIntentID uuid.UUID) (decimal.Decimal, error) {
+	var total decimal.NullDecimal
+	err := p.db.WithContext(ctx).Model(&models.TradeIntent{}).
+		Select("SUM(size * price)").
+		Where("fund_id = ? AND id <> ? AND side = ? AND status IN ?", fundID, excludeIntentID, models.TradeSideBuy,
+			[]models.IntentStatus{models.IntentStatusApproved, models.IntentStatusExecuting, models.IntentStatusManualReview}).
+		Scan(&total).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !total.Valid {
+		return decimal.Zero, nil
+	}
+	return total.Decimal, nil
+}
+
+// GetOpenIntentOutcomes 未终结交易意图涉及的结果代币ID，去重
+func (p postgresRepository) GetOpenIntentOutcomes(ctx context.Context) ([]string, error) {
+	var tokenIDs []string
+	err := p.db.WithContext(ctx).Model(&models.TradeIntent{}).
+		Distinct("outcome_id").
+		Where("status IN ?", []models.IntentStatus{
+			models.IntentStatusPending, models.IntentStatusAuditing, models.IntentStatusApproved,
+			models.IntentStatusExecuting, models.IntentStatusManualReview,
+		}).
+		Pluck("outcome_id", &tokenIDs).Error
+	return tokenIDs, err
+}
+
+// SaveFundPerformance 保存基金业绩指标，覆盖上次计算结果
+func (p postgresRepository) SaveFundPerformance(ctx context.Context, perf *models.FundPerformance) error {
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "fund_id"}},
+			UpdateAll: true,
+		}).
+		Create(perf).Error
+}
+
+// GetFundPerformance 查询基金业绩指标，尚未计算时返回 nil
+func (p postgresRepository) GetFundPerformance(ctx context.Context, fundID uuid.UUID) (*models.FundPerformance, error) {
+	var perf models.FundPerformance
+	result := p.db.WithContext(ctx).Where("fund_id = ?", fundID).Limit(1).Find(&perf)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &perf, nil
+}
+
+// GetLeaderboardOptOuts 查询选择退出收益榜单的投资人地址
+func (p postgresRepository) GetLeaderboardOptOuts(ctx context.Context) ([]string, error) {
+	var investors []string
+	err := p.db.WithContext(ctx).Model(&models.LeaderboardOptOut{}).Pluck("investor", &investors).Error
+	return investors, err
+}
+
+// IsLeaderboardOptOut 查询投资人是否已退出收益榜单
+func (p postgresRepository) IsLeaderboardOptOut(ctx context.Context, investor string) (bool, error) {
+	var count int64
+	err := p.db.WithContext(ctx).Model(&models.LeaderboardOptOut{}).Where("investor = ?", investor).Count(&count).Error
+	return count > 0, err
+}
+
+// SetLeaderboardOptOut 设置投资人是否退出收益榜单
+func (p postgresRepository) SetLeaderboardOptOut(ctx context.Context, investor string, optOut bool) error {
+	if !optOut {
+		return p.db.WithContext(ctx).Where("investor = ?", investor).Delete(&models.LeaderboardOptOut{}).Error
+	}
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.LeaderboardOptOut{Investor: investor}).Error
+}
+
+// GetManagedFunds 查询已关联基金经理地址的基金
+func (p postgresRepository) GetManagedFunds(ctx context.Context) ([]models.Fund, error) {
+	var funds []models.Fund
+	err := p.db.WithContext(ctx).Where("manager_address <> ''").Find(&funds).Error
+	return funds, err
+}
+
+// GetManagerFunds 分页查询基金经理管理的基金，keyword 按基金名称或基金ID检索，按创建时间倒序
+func (p postgresRepository) GetManagerFunds(ctx context.Context, manager, keyword string, offset, limit int) ([]models.Fund, int64, error) {
+	var (
+		funds []models.Fund
+		total int64
+	)
+	query := p.db.WithContext(ctx).Model(&models.Fund{}).Where("LOWER(manager_address) = LOWER(?)", manager)
+	if keyword != "" {
+		query = query.Where("name ILIKE ? OR CAST(id AS TEXT) = ?", "%"+keyword+"%", keyword)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&funds).Error
+	return funds, total, err
+}
+
+// CountFundIntentsByStatus 按状态统计基金在指定时间之后创建的交易意图数
+func (p postgresRepository) CountFundIntentsByStatus(ctx context.Context, fundID uuid.UUID, since time.Time) (map[models.IntentStatus]int64, error) {
+	var rows []struct {
+		Status models.IntentStatus
+		Count  int64
+	}
+	err := p.db.WithContext(ctx).Model(&models.TradeIntent{}).
+		Select("status, COUNT(*) AS count").
+		Where("fund_id = ? AND created_at >= ?", fundID, since).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[models.IntentStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// GetFundRiskEvents 查询基金在指定时间之后触发的风控事件，按时间倒序
+func (p postgresRepository) GetFundRiskEvents(ctx context.Context, fundID uuid.UUID, since time.Time, limit int) ([]models.RiskEvent, error) {
+	var events []models.RiskEvent
+	err := p.db.WithContext(ctx).
+		Where("fund_id = ? AND triggered_at >= ?", fundID, since).
+		Order("triggered_at DESC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// CountInvestors 统计在指定基金中持有份额的投资人数（同一投资人只计一次）
+func (p postgresRepository) CountInvestors(ctx context.Context, fundIDs []uuid.UUID) (int64, error) {
+	var count int64
+	if len(fundIDs) == 0 {
+		return 0, nil
+	}
+	err := p.db.WithContext(ctx).Model(&models.ShareHolding{}).
+		Where("fund_id IN ? AND shares > 0", fundIDs).
+		Distinct("investor").
+		Count(&count).Error
+	return count, err
+}
+
+// SaveFundHealth 保存基金健康度，覆盖上次计算结果
+func (p postgresRepository) SaveFundHealth(ctx context.Context, health *models.FundHealth) error {
+	return p.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "fund_id"}},
+			UpdateAll: true,
+		}).
+		Create(health).Error
+}
+
+// GetFundHealths 查询基金健康度，尚未计算的基金不返回
+func (p postgresRepository) GetFundHealths(ctx context.Context, fundIDs []uuid.UUID) ([]models.FundHealth, error) {
+	var healths []models.FundHealth
+	if len(fundIDs) == 0 {
+		return healths, nil
+	}
+	err := p.db.WithContext(ctx).Where("fund_id IN ?", fundIDs).Find(&healths).Error
+	return healths, err
 }
 
 func (p postgresRepository) Close() error {
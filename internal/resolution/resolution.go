@@ -0,0 +1,170 @@
+// Package resolution 市场结算处理。
+// 数据聚合任务定期检查持仓所在的市场，市场公布结果后按获胜结果以1、其余结果以0结算持仓，
+// 记录已实现盈亏，并为执行钱包持有的获胜条件代币创建兑付任务。
+package resolution
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// MarketProvider 市场信息接口（由 executor.PolymarketClient 实现）
+type MarketProvider interface {
+	GetMarket(ctx context.Context, marketID string) (*executor.Market, error)
+}
+
+// Service 市场结算服务
+type Service struct {
+	repo    repository.Repository
+	markets MarketProvider
+	logger  *logger.Logger
+}
+
+// NewService 创建市场结算服务
+func NewService(repo repository.Repository, markets MarketProvider, logger *logger.Logger) *Service {
+	return &Service{
+		repo:    repo,
+		markets: markets,
+		logger:  logger,
+	}
+}
+
+// Run 检查所有未结算持仓所在的市场并结算已公布结果的市场，返回本次结算的市场数。
+// 单个市场查询或结算失败不影响其他市场，下次运行时重试
+func (s *Service) Run(ctx context.Context) (int, error) {
+	positions, err := s.repo.GetUnresolvedPositions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("获取未结算持仓失败: %w", err)
+	}
+
+	var marketIDs []string
+	byMarket := make(map[string][]models.Position)
+	for _, pos := range positions {
+		if _, ok := byMarket[pos.MarketID]; !ok {
+			marketIDs = append(marketIDs, pos.MarketID)
+		}
+		byMarket[pos.MarketID] = append(byMarket[pos.MarketID], pos)
+	}
+
+	settled := 0
+	for _, marketID := range marketIDs {
+		market, err := s.markets.GetMarket(ctx, marketID)
+		if err != nil {
+			s.logger.Error("获取市场信息失败",
+				zap.String("market_id", marketID),
+				zap.Error(err))
+			continue
+		}
+
+		winner, ok := market.WinningOutcome()
+		if !ok {
+			continue
+		}
+
+		if err := s.settle(ctx, market, winner, byMarket[marketID]); err != nil {
+			s.logger.Error("市场结算失败",
+				zap.String("market_id", marketID),
+				zap.Error(err))
+			continue
+		}
+		settled++
+	}
+	return settled, nil
+}
+
+// settle 按获胜结果结算市场内的持仓，并按基金汇总获胜代币生成兑付任务
+func (s *Service) settle(ctx context.Context, market *executor.Market, winner *executor.Outcome,
+	positions []models.Position) error {
+
+	now := time.Now()
+	one := decimal.NewFromInt(1)
+
+	var tasks []models.RedemptionTask
+	taskIndex := make(map[uuid.UUID]int)
+	for i := range positions {
+		pos := &positions[i]
+
+		price := decimal.Zero
+		if pos.OutcomeID == winner.ID {
+			price = one
+		}
+
+		realized := price.Sub(pos.EntryPrice).Mul(pos.Size)
+		pos.RealizedPnL = pos.RealizedPnL.Add(realized)
+		pos.UnrealizedPnL = decimal.Zero
+		pos.CurrentPrice = price
+		pos.SettlementPrice = price
+		pos.ResolvedAt = &now
+		pos.TriggerState = models.PositionTriggerSettled
+		pos.LastUpdated = now
+
+		s.logger.Info("持仓已结算",
+			zap.String("fund_id", pos.FundID.String()),
+			zap.String("market_id", pos.MarketID),
+			zap.String("outcome_id", pos.OutcomeID),
+			zap.String("settlement_price", price.String()),
+			zap.String("realized_pnl", realized.String()))
+
+		// 仅多头持有的获胜代币可兑付
+		if !pos.Size.IsPositive() || !price.IsPositive() {
+			continue
+		}
+
+		idx, ok := taskIndex[pos.FundID]
+		if !ok {
+			fund, err := s.repo.GetFund(ctx, pos.FundID)
+			if err != nil {
+				return fmt.Errorf("获取基金失败: %w", err)
+			}
+			task := models.RedemptionTask{
+				FundID:         pos.FundID,
+				MarketID:       market.ID,
+				WinningOutcome: winner.ID,
+				Status:         models.RedemptionPending,
+			}
+			if fund != nil {
+				task.Address = fund.ExecutionAddress
+			}
+			tasks = append(tasks, task)
+			idx = len(tasks) - 1
+			taskIndex[pos.FundID] = idx
+		}
+		tasks[idx].Size = tasks[idx].Size.Add(pos.Size)
+		tasks[idx].Payout = tasks[idx].Payout.Add(pos.Size.Mul(price))
+	}
+
+	data := &models.MarketData{
+		ID:             market.ID,
+		Question:       market.Question,
+		Description:    market.Description,
+		EndDate:        market.EndDate,
+		Active:         market.Active,
+		Closed:         true,
+		Resolved:       true,
+		WinningOutcome: winner.ID,
+		ResolvedAt:     &now,
+		LastPrice:      market.LastPrice,
+		Volume:         market.Volume,
+		Liquidity:      market.Liquidity,
+	}
+	if err := s.repo.SettleMarket(ctx, data, positions, tasks); err != nil {
+		return err
+	}
+
+	s.logger.Info("市场已结算",
+		zap.String("market_id", market.ID),
+		zap.String("winning_outcome", winner.ID),
+		zap.Int("positions", len(positions)),
+		zap.Int("redemption_tasks", len(tasks)))
+	return nil
+}
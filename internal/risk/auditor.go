@@ -3,20 +3,45 @@ package risk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/killswitch"
 	"polyagent-backend/internal/models"
 	"polyagent-backend/internal/pkg/logger"
 	"polyagent-backend/internal/repository"
 )
 
+// MarketDataProvider 市场数据接口（由 executor.PolymarketClient 实现）
+type MarketDataProvider interface {
+	GetMarket(ctx context.Context, marketID string) (*executor.Market, error)
+	GetOrderBook(ctx context.Context, tokenID string) (*executor.OrderBook, error)
+}
+
+// VolatilitySource 结果代币价格波动率（由 pricehistory.Service 实现）
+type VolatilitySource interface {
+	Volatility(ctx context.Context, tokenID string) (decimal.Decimal, error)
+}
+
+// ErrFundNotFound 交易意图所属基金不存在
+var ErrFundNotFound = errors.New("基金不存在")
+
+// ErrFundNotLiquidating 基金不处于清仓模式
+var ErrFundNotLiquidating = errors.New("基金不处于清仓模式")
+
 // Auditor 风控审计器
 type Auditor struct {
-	repo   repository.Repository
-	logger *logger.Logger
+	repo       repository.Repository
+	marketData MarketDataProvider
+	quotes     executor.QuoteSource
+	killSwitch *killswitch.Service
+	volatility VolatilitySource
+	logger     *logger.Logger
 }
 
 // AuditResult 审计结果
@@ -24,24 +49,44 @@ type AuditResult struct {
 	Passed         bool              `json:"passed"`
 	Checks         []RuleCheckResult `json:"checks"`
 	TotalRiskScore int               `json:"total_risk_score"`
+	Decision       AuditDecision     `json:"decision"`
+	Halted         bool              `json:"halted,omitempty"` // 交易已熔断，直接拒绝
 }
 
 // RuleCheckResult 单规则检查结果
 type RuleCheckResult struct {
-	RuleType models.RiskRuleType `json:"rule_type"`
-	Passed   bool                `json:"passed"`
-	Score    int                 `json:"score"` // 0-100, 越高越危险
-	Message  string              `json:"message"`
+	RuleType    models.RiskRuleType `json:"rule_type"`
+	RiskRuleID  *uuid.UUID          `json:"risk_rule_id,omitempty"` // 评估的规则版本ID
+	RuleVersion int                 `json:"rule_version,omitempty"`
+	Passed      bool                `json:"passed"`
+	Score       int                 `json:"score"` // 0-100, 越高越危险
+	Message     string              `json:"message"`
 }
 
 // NewAuditor 创建审计器
-func NewAuditor(repo repository.Repository, logger *logger.Logger) *Auditor {
+func NewAuditor(repo repository.Repository, marketData MarketDataProvider, logger *logger.Logger) *Auditor {
 	return &Auditor{
-		repo:   repo,
-		logger: logger,
+		repo:       repo,
+		marketData: marketData,
+		logger:     logger,
 	}
 }
 
+// SetKillSwitch 设置熔断开关，未设置时不检查熔断
+func (a *Auditor) SetKillSwitch(ks *killswitch.Service) {
+	a.killSwitch = ks
+}
+
+// SetQuoteSource 设置实时盘口，未设置或盘口未就绪时按结果代币订单簿取最优价
+func (a *Auditor) SetQuoteSource(quotes executor.QuoteSource) {
+	a.quotes = quotes
+}
+
+// SetVolatilitySource 设置波动率来源，未设置时波动率按 0 计算
+func (a *Auditor) SetVolatilitySource(vs VolatilitySource) {
+	a.volatility = vs
+}
+
 // AuditIntent 审计交易意图
 func (a *Auditor) AuditIntent(ctx context.Context, intent *models.TradeIntent) (*AuditResult, error) {
 	a.logger.Info("开始风控审计",
@@ -49,10 +94,65 @@ func (a *Auditor) AuditIntent(ctx context.Context, intent *models.TradeIntent) (
 		zap.String("fund_id", intent.FundID.String()),
 		zap.String("market_id", intent.MarketID))
 
+	result, rc, err := a.evaluate(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录审计日志
+	for _, checkResult := range result.Checks {
+		auditLog := &models.AuditLog{
+			IntentID:    intent.ID,
+			FundID:      intent.FundID,
+			RuleType:    checkResult.RuleType,
+			RiskRuleID:  checkResult.RiskRuleID,
+			RuleVersion: checkResult.RuleVersion,
+			Result:      map[bool]string{true: "PASS", false: "FAIL"}[checkResult.Passed],
+			Details:     checkResult.Message,
+			CheckedAt:   time.Now(),
+		}
+		if err := a.repo.CreateAuditLog(ctx, auditLog); err != nil {
+			a.logger.Error("记录审计日志失败", zap.Error(err))
+		}
+	}
+
+	// 按基金复核阈值给出结论并更新意图状态
+	thresholds := a.reviewThresholds(rc.Fund)
+	result.Decision = thresholds.Decide(result)
+	switch result.Decision {
+	case DecisionApprove:
+		intent.Status = models.IntentStatusApproved
+		intent.AuditResult = a.serializeResult(result)
+	case DecisionReview:
+		dueAt := time.Now().Add(thresholds.SLA())
+		intent.Status = models.IntentStatusManualReview
+		intent.ReviewDueAt = &dueAt
+		intent.AuditResult = a.serializeResult(result)
+	default:
+		intent.Status = models.IntentStatusRejected
+		intent.RejectReason = a.formatRejectReason(result)
+		intent.AuditResult = a.serializeResult(result)
+	}
+
+	if err := a.repo.UpdateTradeIntent(ctx, intent); err != nil {
+		return nil, fmt.Errorf("更新意图状态失败: %w", err)
+	}
+
+	a.logger.Info("风控审计完成",
+		zap.String("intent_id", intent.ID.String()),
+		zap.Bool("passed", result.Passed),
+		zap.String("decision", string(result.Decision)),
+		zap.Int("risk_score", result.TotalRiskScore))
+
+	return result, nil
+}
+
+// evaluate 执行全部风控规则检查，只读取数据，不写入任何记录
+func (a *Auditor) evaluate(ctx context.Context, intent *models.TradeIntent) (*AuditResult, *RuleContext, error) {
 	// 获取基金风控规则
 	rules, err := a.repo.GetActiveRiskRules(ctx, intent.FundID)
 	if err != nil {
-		return nil, fmt.Errorf("获取风控规则失败: %w", err)
+		return nil, nil, fmt.Errorf("获取风控规则失败: %w", err)
 	}
 
 	result := &AuditResult{
@@ -63,96 +163,128 @@ func (a *Auditor) AuditIntent(ctx context.Context, intent *models.TradeIntent) (
 	// 获取当前持仓和基金信息
 	positions, err := a.repo.GetFundPositions(ctx, intent.FundID)
 	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
+		return nil, nil, fmt.Errorf("获取持仓失败: %w", err)
 	}
 
 	fund, err := a.repo.GetFund(ctx, intent.FundID)
 	if err != nil {
-		return nil, fmt.Errorf("获取基金信息失败: %w", err)
+		return nil, nil, fmt.Errorf("获取基金信息失败: %w", err)
+	}
+	if fund == nil {
+		return nil, nil, ErrFundNotFound
 	}
 
-	// 获取当前市场价格（模拟，实际应从Polymarket API获取）
-	currentPrice := decimal.NewFromFloat(0.5) // 示例价格
+	// 获取当前市场信息，失败时依赖行情的规则将按不通过处理
+	market, err := a.marketData.GetMarket(ctx, intent.MarketID)
+	if err != nil {
+		a.logger.Error("获取市场信息失败",
+			zap.String("market_id", intent.MarketID),
+			zap.Error(err))
+	}
+	// 价格类检查均按意图交易的结果代币盘口，而非市场级（YES）报价
+	bestBid, bestAsk := a.topOfBook(ctx, intent.OutcomeID)
+	rc := &RuleContext{
+		Intent:       intent,
+		Positions:    unresolvedPositions(positions),
+		Fund:         fund,
+		Market:       market,
+		BestBid:      bestBid,
+		BestAsk:      bestAsk,
+		CurrentPrice: outcomePrice(market, intent.OutcomeID, bestBid, bestAsk),
+		MarketData:   a.marketData,
+		auditor:      a,
+	}
+	if a.volatility != nil {
+		volatility, err := a.volatility.Volatility(ctx, intent.OutcomeID)
+		if err != nil {
+			a.logger.Error("计算价格波动率失败",
+				zap.String("outcome_id", intent.OutcomeID),
+				zap.Error(err))
+		}
+		rc.Volatility = volatility
+	}
+
+	// 熔断时仍执行其余规则，便于预审展示完整结果
+	if a.killSwitch != nil {
+		if err := a.killSwitch.Halted(ctx, intent.FundID, intent.MarketID, false); err != nil {
+			result.Passed = false
+			result.Halted = true
+			result.TotalRiskScore += 100
+			result.Checks = append(result.Checks, RuleCheckResult{
+				RuleType: models.RiskRuleTypeKillSwitch,
+				Passed:   false,
+				Score:    100,
+				Message:  err.Error(),
+			})
+		}
+	}
+
+	// 已结算市场始终拒绝交易
+	if check := checkMarketResolved(market); check != nil {
+		result.Passed = false
+		result.TotalRiskScore += check.Score
+		result.Checks = append(result.Checks, *check)
+	}
+
+	// 买入意图始终检查可用现金
+	if check := a.checkAvailableCash(ctx, rc); check != nil {
+		result.Checks = append(result.Checks, *check)
+		result.TotalRiskScore += check.Score
+		if !check.Passed {
+			result.Passed = false
+		}
+	}
 
 	// 执行各项规则检查
 	for _, rule := range rules {
-		checkResult := a.checkRule(ctx, rule, intent, positions, fund, currentPrice)
+		checkResult := a.checkRule(ctx, rule, rc)
 		result.Checks = append(result.Checks, checkResult)
 		result.TotalRiskScore += checkResult.Score
 
 		if !checkResult.Passed {
 			result.Passed = false
 		}
-
-		// 记录审计日志
-		auditLog := &models.AuditLog{
-			IntentID:  intent.ID,
-			RuleType:  rule.RuleType,
-			Result:    map[bool]string{true: "PASS", false: "FAIL"}[checkResult.Passed],
-			Details:   checkResult.Message,
-			CheckedAt: time.Now(),
-		}
-		if err := a.repo.CreateAuditLog(ctx, auditLog); err != nil {
-			a.logger.Error("记录审计日志失败", zap.Error(err))
-		}
-	}
-
-	// 更新意图状态
-	if result.Passed {
-		intent.Status = models.IntentStatusApproved
-		intent.AuditResult = a.serializeResult(result)
-	} else {
-		intent.Status = models.IntentStatusRejected
-		intent.RejectReason = a.formatRejectReason(result.Checks)
-		intent.AuditResult = a.serializeResult(result)
-	}
-
-	if err := a.repo.UpdateTradeIntent(ctx, intent); err != nil {
-		return nil, fmt.Errorf("更新意图状态失败: %w", err)
 	}
 
-	a.logger.Info("风控审计完成",
-		zap.String("intent_id", intent.ID.String()),
-		zap.Bool("passed", result.Passed),
-		zap.Int("risk_score", result.TotalRiskScore))
-
-	return result, nil
+	return result, rc, nil
 }
 
-// checkRule 执行单条规则检查
-func (a *Auditor) checkRule(ctx context.Context, rule models.RiskRule,
-	intent *models.TradeIntent, positions []models.Position,
-	fund *models.Fund, currentPrice decimal.Decimal) RuleCheckResult {
+// checkRule 执行单条规则检查，结果记录所评估的规则版本
+func (a *Auditor) checkRule(ctx context.Context, rule models.RiskRule, rc *RuleContext) RuleCheckResult {
+	result := a.evaluateRule(ctx, rule, rc)
+	ruleVersionID := rule.ID
+	result.RiskRuleID = &ruleVersionID
+	result.RuleVersion = rule.Version
+	return result
+}
 
-	params, err := ParseRuleParams(rule.RuleType, rule.Params)
-	if err != nil {
+// evaluateRule 按规则表查找评估器并执行
+func (a *Auditor) evaluateRule(ctx context.Context, rule models.RiskRule, rc *RuleContext) RuleCheckResult {
+	evaluator, ok := lookupRule(rule.RuleType)
+	if !ok {
 		return RuleCheckResult{
 			RuleType: rule.RuleType,
 			Passed:   false,
-			Score:    100,
-			Message:  fmt.Sprintf("规则参数解析失败: %v", err),
+			Score:    50,
+			Message:  "未知的规则类型",
 		}
 	}
 
-	switch rule.RuleType {
-	case models.RiskRuleTypePositionLimit:
-		return a.checkPositionLimit(params.(PositionLimitParams), intent, positions, fund)
-	case models.RiskRuleTypeDailyLossLimit:
-		return a.checkDailyLossLimit(params.(DailyLossLimitParams), fund)
-	case models.RiskRuleTypePriceDeviation:
-		return a.checkPriceDeviation(params.(PriceDeviationParams), intent, currentPrice)
-	case models.RiskRuleTypeConcentration:
-		return a.checkConcentration(params.(ConcentrationParams), intent, positions, fund)
-	case models.RiskRuleTypeStopLoss:
-		return a.checkStopLoss(params.(StopLossParams), positions)
-	default:
+	params, err := evaluator.ParseParams(rule.Params)
+	if err != nil {
 		return RuleCheckResult{
 			RuleType: rule.RuleType,
 			Passed:   false,
-			Score:    50,
-			Message:  "未知的规则类型",
+			Score:    100,
+			Message:  fmt.Sprintf("规则参数解析失败: %v", err),
 		}
 	}
+
+	result := evaluator.Evaluate(ctx, params, rc)
+	if result.RuleType == "" {
+		result.RuleType = rule.RuleType
+	}
+	return result
 }
 
 // checkPositionLimit 检查仓位限制
@@ -254,6 +386,15 @@ func (a *Auditor) checkPriceDeviation(params PriceDeviationParams,
 		}
 	}
 
+	if currentPrice.IsZero() {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypePriceDeviation,
+			Passed:   false,
+			Score:    100,
+			Message:  "无法获取当前市场价格",
+		}
+	}
+
 	deviation := intent.Price.Sub(currentPrice).Abs().Div(currentPrice).Mul(decimal.NewFromInt(100))
 	maxDeviation := params.MaxDeviationPercent
 
@@ -353,6 +494,50 @@ func (a *Auditor) checkStopLoss(params StopLossParams, positions []models.Positi
 	}
 }
 
+// topOfBook 结果代币的最优买卖价，优先取实时盘口，其次查询订单簿；无法获取时为 0
+func (a *Auditor) topOfBook(ctx context.Context, tokenID string) (bid, ask decimal.Decimal) {
+	if a.quotes != nil {
+		if bid, ask, ok := a.quotes.TopOfBook(tokenID); ok {
+			return bid, ask
+		}
+	}
+	book, err := a.marketData.GetOrderBook(ctx, tokenID)
+	if err != nil {
+		a.logger.Error("获取订单簿失败",
+			zap.String("outcome_id", tokenID),
+			zap.Error(err))
+		return decimal.Zero, decimal.Zero
+	}
+	return book.BestBid(), book.BestAsk()
+}
+
+// outcomePrice 取结果代币参考价：优先买卖中间价，其次市场信息中该结果的价格
+func outcomePrice(market *executor.Market, outcomeID string, bid, ask decimal.Decimal) decimal.Decimal {
+	if bid.IsPositive() && ask.IsPositive() {
+		return bid.Add(ask).Div(decimal.NewFromInt(2))
+	}
+	if market == nil {
+		return decimal.Zero
+	}
+	for _, o := range market.Outcomes {
+		if o.ID == outcomeID {
+			return o.Price
+		}
+	}
+	return decimal.Zero
+}
+
+// unresolvedPositions 过滤已结算市场的持仓，已结算持仓不再承担市场风险
+func unresolvedPositions(positions []models.Position) []models.Position {
+	open := make([]models.Position, 0, len(positions))
+	for _, pos := range positions {
+		if pos.ResolvedAt == nil {
+			open = append(open, pos)
+		}
+	}
+	return open
+}
+
 // calculateTodayLoss 计算今日亏损（简化实现）
 func (a *Auditor) calculateTodayLoss(fundID interface{}) decimal.Decimal {
 	// 实际应从数据库查询今日交易盈亏
@@ -366,11 +551,14 @@ func (a *Auditor) serializeResult(result *AuditResult) string {
 }
 
 // formatRejectReason 格式化拒绝原因
-func (a *Auditor) formatRejectReason(checks []RuleCheckResult) string {
-	for _, check := range checks {
+func (a *Auditor) formatRejectReason(result *AuditResult) string {
+	for _, check := range result.Checks {
 		if !check.Passed {
 			return fmt.Sprintf("[%s] %s", check.RuleType, check.Message)
 		}
 	}
+	if result.Passed {
+		return fmt.Sprintf("风险总分 %d 超过自动拒绝阈值", result.TotalRiskScore)
+	}
 	return "未知原因"
 }
@@ -0,0 +1,77 @@
+package risk
+
+import (
+	"context"
+
+	"polyagent-backend/internal/models"
+)
+
+// 注册内置规则
+func init() {
+	RegisterRule(models.RiskRuleTypePositionLimit, RuleFunc[PositionLimitParams](
+		func(ctx context.Context, p PositionLimitParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkPositionLimit(p, rc.Intent, rc.Positions, rc.Fund)
+		}))
+	RegisterRule(models.RiskRuleTypeDailyLossLimit, RuleFunc[DailyLossLimitParams](
+		func(ctx context.Context, p DailyLossLimitParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkDailyLossLimit(p, rc.Fund)
+		}))
+	RegisterRule(models.RiskRuleTypePriceDeviation, RuleFunc[PriceDeviationParams](
+		func(ctx context.Context, p PriceDeviationParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkPriceDeviation(p, rc.Intent, rc.CurrentPrice)
+		}))
+	RegisterRule(models.RiskRuleTypeConcentration, RuleFunc[ConcentrationParams](
+		func(ctx context.Context, p ConcentrationParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkConcentration(p, rc.Intent, rc.Positions, rc.Fund)
+		}))
+	RegisterRule(models.RiskRuleTypeStopLoss, RuleFunc[StopLossParams](
+		func(ctx context.Context, p StopLossParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkStopLoss(p, rc.Positions)
+		}))
+	RegisterRule(models.RiskRuleTypeMinLiquidity, RuleFunc[MinLiquidityParams](
+		func(ctx context.Context, p MinLiquidityParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkMinLiquidity(p, rc.Market)
+		}))
+	RegisterRule(models.RiskRuleTypeMaxSpread, RuleFunc[MaxSpreadParams](
+		func(ctx context.Context, p MaxSpreadParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkMaxSpread(p, rc.BestBid, rc.BestAsk)
+		}))
+	RegisterRule(models.RiskRuleTypeMaxSlippage, RuleFunc[MaxSlippageParams](
+		func(ctx context.Context, p MaxSlippageParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkMaxSlippage(ctx, p, rc.Intent, rc.Fund)
+		}))
+	RegisterRule(models.RiskRuleTypeMarketStatus, RuleFunc[MarketStatusParams](
+		func(ctx context.Context, p MarketStatusParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkMarketStatus(p, rc.Market, rc.Intent, rc.Positions)
+		}))
+	RegisterRule(models.RiskRuleTypeMaxDrawdown, RuleFunc[MaxDrawdownParams](
+		func(ctx context.Context, p MaxDrawdownParams, rc *RuleContext) RuleCheckResult {
+			return rc.auditor.checkMaxDrawdown(p, rc.Intent, rc.Positions, rc.Fund)
+		}))
+
+	// 离场规则由实时风控引擎执行，不影响开仓审计
+	RegisterRule(models.RiskRuleTypeTrailingStop, RuleFunc[TrailingStopParams](
+		func(ctx context.Context, p TrailingStopParams, rc *RuleContext) RuleCheckResult {
+			return exitRulePassed(models.RiskRuleTypeTrailingStop)
+		}))
+	RegisterRule(models.RiskRuleTypeTakeProfit, RuleFunc[TakeProfitParams](
+		func(ctx context.Context, p TakeProfitParams, rc *RuleContext) RuleCheckResult {
+			return exitRulePassed(models.RiskRuleTypeTakeProfit)
+		}))
+	RegisterRule(models.RiskRuleTypeTimeExit, RuleFunc[TimeExitParams](
+		func(ctx context.Context, p TimeExitParams, rc *RuleContext) RuleCheckResult {
+			return exitRulePassed(models.RiskRuleTypeTimeExit)
+		}))
+
+	RegisterRule(models.RiskRuleTypeCustomExpr, customExprRule{})
+}
+
+// exitRulePassed 离场规则在审计阶段的结果
+func exitRulePassed(ruleType models.RiskRuleType) RuleCheckResult {
+	return RuleCheckResult{
+		RuleType: ruleType,
+		Passed:   true,
+		Score:    0,
+		Message:  "离场规则，由实时风控执行",
+	}
+}
@@ -0,0 +1,65 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	"polyagent-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// checkAvailableCash 检查买入金额不超过可用现金
+// 可用现金 = Vault 现金 + 执行钱包现金 - 应计费用 - 其他已通过审计或待复核的买入意图占用金额
+func (a *Auditor) checkAvailableCash(ctx context.Context, rc *RuleContext) *RuleCheckResult {
+	intent := rc.Intent
+	if intent.Side != models.TradeSideBuy {
+		return nil
+	}
+
+	fail := func(msg string) *RuleCheckResult {
+		return &RuleCheckResult{
+			RuleType: models.RiskRuleTypeCashBalance,
+			Passed:   false,
+			Score:    100,
+			Message:  msg,
+		}
+	}
+
+	if rc.Fund == nil {
+		return fail("无法获取基金信息")
+	}
+
+	// 市价单按市场参考价估算
+	price := intent.Price
+	if !price.IsPositive() {
+		price = rc.CurrentPrice
+	}
+	if !price.IsPositive() {
+		return fail("无法确定买入价格，无法校验可用现金")
+	}
+	cost := intent.Size.Mul(price)
+
+	committed, err := a.repo.GetCommittedBuyNotional(ctx, intent.FundID, intent.ID)
+	if err != nil {
+		return fail(fmt.Sprintf("获取已占用现金失败: %v", err))
+	}
+
+	fund := rc.Fund
+	available := fund.VaultCash.Add(fund.ExecutionCash).Sub(fund.AccruedFees).Sub(committed)
+	if available.IsNegative() {
+		available = decimal.Zero
+	}
+
+	if cost.GreaterThan(available) {
+		return fail(fmt.Sprintf("买入金额 %s 超过可用现金 %s USDC（已占用 %s）",
+			cost.StringFixed(2), available.StringFixed(2), committed.StringFixed(2)))
+	}
+
+	return &RuleCheckResult{
+		RuleType: models.RiskRuleTypeCashBalance,
+		Passed:   true,
+		Score:    0,
+		Message:  fmt.Sprintf("买入金额 %s，可用现金 %s USDC", cost.StringFixed(2), available.StringFixed(2)),
+	}
+}
@@ -0,0 +1,104 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// triggerCloseOut 按持仓触发状态执行平仓：
+// ARMED -> TRIGGERED（每次触发只记录一次风控事件）-> CLOSING -> CLOSED。
+// 平仓进行中或完成后的冷却期内不重复触发；平仓失败时告警并退回 TRIGGERED，冷却期后重试
+func (r *RealtimeRiskEngine) triggerCloseOut(ctx context.Context, fund models.Fund,
+	pos *models.Position, ruleType models.RiskRuleType, severity, description string) error {
+
+	now := time.Now()
+	inCooldown := pos.TriggeredAt != nil && now.Sub(*pos.TriggeredAt) < r.closeOutCooldown
+
+	newTrigger := false
+	switch pos.TriggerState {
+	case models.PositionTriggerTriggered, models.PositionTriggerClosing:
+		if inCooldown {
+			return nil // 平仓进行中
+		}
+		// 冷却期已过仍未平仓完成，重试平仓，不重复记录触发事件
+	case models.PositionTriggerClosed:
+		if inCooldown {
+			return nil
+		}
+		newTrigger = true // 平仓后仍有剩余持仓，重新触发
+	default:
+		newTrigger = true
+	}
+
+	if newTrigger {
+		if err := r.setTriggerState(ctx, pos, models.PositionTriggerTriggered, now); err != nil {
+			return err
+		}
+
+		r.logger.Warn("触发平仓",
+			zap.String("fund_id", fund.ID.String()),
+			zap.String("market_id", pos.MarketID),
+			zap.String("rule_type", string(ruleType)),
+			zap.String("reason", description))
+
+		event := &models.RiskEvent{
+			FundID:      fund.ID,
+			RuleType:    ruleType,
+			Severity:    severity,
+			MarketID:    pos.MarketID,
+			Description: description,
+			TriggeredAt: now,
+		}
+		if err := r.repo.CreateRiskEvent(ctx, event); err != nil {
+			r.logger.Error("记录风控事件失败", zap.Error(err))
+		}
+	}
+
+	if r.stopLossExecutor == nil {
+		return nil
+	}
+
+	if err := r.setTriggerState(ctx, pos, models.PositionTriggerClosing, now); err != nil {
+		return err
+	}
+
+	if err := r.stopLossExecutor(ctx, *pos); err != nil {
+		// 平仓失败告警
+		alert := &models.RiskEvent{
+			FundID:   fund.ID,
+			RuleType: ruleType,
+			Severity: "CRITICAL",
+			MarketID: pos.MarketID,
+			Description: fmt.Sprintf("平仓失败，将在 %s 后重试: %v",
+				r.closeOutCooldown, err),
+			TriggeredAt: time.Now(),
+		}
+		if alertErr := r.repo.CreateRiskEvent(ctx, alert); alertErr != nil {
+			r.logger.Error("记录平仓失败告警失败", zap.Error(alertErr))
+		}
+
+		if stateErr := r.setTriggerState(ctx, pos, models.PositionTriggerTriggered, now); stateErr != nil {
+			r.logger.Error("回退触发状态失败", zap.Error(stateErr))
+		}
+		return fmt.Errorf("持仓 %s 平仓失败: %w", pos.ID, err)
+	}
+
+	return r.setTriggerState(ctx, pos, models.PositionTriggerClosed, now)
+}
+
+// setTriggerState 持久化持仓触发状态
+func (r *RealtimeRiskEngine) setTriggerState(ctx context.Context, pos *models.Position,
+	state models.PositionTriggerState, at time.Time) error {
+
+	if err := r.repo.UpdatePositionTriggerState(ctx, pos.ID, state, at); err != nil {
+		return fmt.Errorf("更新持仓 %s 触发状态失败: %w", pos.ID, err)
+	}
+	pos.TriggerState = state
+	pos.TriggeredAt = &at
+	return nil
+}
@@ -0,0 +1,240 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/expr"
+
+	"github.com/shopspring/decimal"
+)
+
+// customExprVariables 自定义表达式可引用的变量及说明
+var customExprVariables = map[string]string{
+	"intent.side":         "交易方向 BUY / SELL",
+	"intent.size":         "交易数量",
+	"intent.price":        "目标价格，市价单为 0",
+	"intent.notional":     "交易金额（数量 × 目标价，市价单按市场参考价）",
+	"intent.market_id":    "市场ID",
+	"intent.outcome_id":   "预测结果ID",
+	"intent.order_type":   "订单类型",
+	"fund.aum":            "基金资产管理规模",
+	"fund.nav":            "基金单位净值",
+	"fund.status":         "基金状态",
+	"fund.exposure":       "当前持仓总市值",
+	"fund.position_count": "当前持仓数量",
+	"position.size":       "该 outcome 当前持仓数量",
+	"position.value":      "该 outcome 当前持仓市值",
+	"market.category":     "市场类别",
+	"market.price":        "交易结果代币参考价",
+	"market.best_bid":     "交易结果代币最优买价",
+	"market.best_ask":     "交易结果代币最优卖价",
+	"market.spread":       "交易结果代币买卖价差",
+	"market.last_price":   "最新成交价",
+	"market.volume":       "成交量",
+	"market.liquidity":    "流动性",
+	"market.active":       "是否激活",
+	"market.closed":       "是否已关闭",
+	"market.hours_to_end": "距结算小时数",
+	"market.volatility":   "近24小时价格波动率（小时K线对数收益率标准差）",
+}
+
+// CustomExprParams 自定义表达式规则参数
+// 表达式结果为 true 表示通过，例如：
+//
+//	intent.notional < fund.aum * 0.05 && market.category in ["Politics"]
+type CustomExprParams struct {
+	Expression string `json:"expression"` // 规则表达式
+	Message    string `json:"message"`    // 不通过时的提示信息
+	Score      int    `json:"score"`      // 不通过时的风险分数，默认80
+
+	compiled *expr.Expr
+}
+
+// Validate 实现 RuleParams 接口：编译表达式并检查变量名
+func (p CustomExprParams) Validate() error {
+	if p.Score < 0 || p.Score > 100 {
+		return fmt.Errorf("score must be in [0, 100]")
+	}
+	if p.compiled != nil {
+		return nil
+	}
+	_, err := compileCustomExpr(p.Expression)
+	return err
+}
+
+// compileCustomExpr 编译表达式并校验引用的变量
+func compileCustomExpr(src string) (*expr.Expr, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, fmt.Errorf("expression is required")
+	}
+
+	compiled, err := expr.Compile(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %w", err)
+	}
+
+	var unknown []string
+	for _, name := range compiled.Identifiers() {
+		if _, ok := customExprVariables[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown variables %s, available: %s",
+			strings.Join(unknown, ", "), strings.Join(CustomExprVariableNames(), ", "))
+	}
+
+	return compiled, nil
+}
+
+// CustomExprVariableNames 自定义表达式可用变量名（排序）
+func CustomExprVariableNames() []string {
+	names := make([]string, 0, len(customExprVariables))
+	for name := range customExprVariables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// customExprRule 自定义表达式规则评估器
+type customExprRule struct{}
+
+// ParseParams 实现 RuleEvaluator 接口
+func (customExprRule) ParseParams(data string) (RuleParams, error) {
+	var params CustomExprParams
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		return nil, err
+	}
+
+	compiled, err := compileCustomExpr(params.Expression)
+	if err != nil {
+		return nil, err
+	}
+	params.compiled = compiled
+
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if params.Score == 0 {
+		params.Score = 80
+	}
+	return params, nil
+}
+
+// Evaluate 实现 RuleEvaluator 接口
+func (customExprRule) Evaluate(ctx context.Context, params RuleParams, rc *RuleContext) RuleCheckResult {
+	p := params.(CustomExprParams)
+
+	// 引用了市场变量但行情不可用（波动率来自价格历史，不依赖行情）
+	if rc.Market == nil {
+		for _, name := range p.compiled.Identifiers() {
+			if strings.HasPrefix(name, "market.") && name != "market.volatility" {
+				return RuleCheckResult{
+					RuleType: models.RiskRuleTypeCustomExpr,
+					Passed:   false,
+					Score:    100,
+					Message:  fmt.Sprintf("无法获取市场信息，无法计算 %s", name),
+				}
+			}
+		}
+	}
+
+	passed, err := p.compiled.EvalBool(customExprEnv(rc))
+	if err != nil {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeCustomExpr,
+			Passed:   false,
+			Score:    100,
+			Message:  fmt.Sprintf("表达式 %q 求值失败: %v", p.Expression, err),
+		}
+	}
+
+	if !passed {
+		message := p.Message
+		if message == "" {
+			message = fmt.Sprintf("不满足自定义规则 %s", p.Expression)
+		}
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeCustomExpr,
+			Passed:   false,
+			Score:    p.Score,
+			Message:  message,
+		}
+	}
+
+	return RuleCheckResult{
+		RuleType: models.RiskRuleTypeCustomExpr,
+		Passed:   true,
+		Score:    0,
+		Message:  fmt.Sprintf("满足自定义规则 %s", p.Expression),
+	}
+}
+
+// customExprEnv 构建表达式求值环境
+func customExprEnv(rc *RuleContext) expr.Env {
+	intent := rc.Intent
+
+	price := intent.Price
+	if price.IsZero() {
+		price = rc.CurrentPrice
+	}
+
+	var exposure, positionSize, positionValue decimal.Decimal
+	positionCount := 0
+	for _, pos := range rc.Positions {
+		if pos.Size.IsZero() {
+			continue
+		}
+		positionCount++
+		exposure = exposure.Add(pos.Size.Mul(pos.CurrentPrice))
+		if pos.MarketID == intent.MarketID && pos.OutcomeID == intent.OutcomeID {
+			positionSize = positionSize.Add(pos.Size)
+			positionValue = positionValue.Add(pos.Size.Mul(pos.CurrentPrice))
+		}
+	}
+
+	env := expr.Env{
+		"intent.side":         string(intent.Side),
+		"intent.size":         intent.Size,
+		"intent.price":        intent.Price,
+		"intent.notional":     intent.Size.Mul(price),
+		"intent.market_id":    intent.MarketID,
+		"intent.outcome_id":   intent.OutcomeID,
+		"intent.order_type":   intent.OrderType,
+		"fund.aum":            rc.Fund.TotalAUM,
+		"fund.nav":            rc.Fund.CurrentNAV,
+		"fund.status":         rc.Fund.Status,
+		"fund.exposure":       exposure,
+		"fund.position_count": positionCount,
+		"position.size":       positionSize,
+		"position.value":      positionValue,
+		"market.volatility":   rc.Volatility,
+	}
+
+	if market := rc.Market; market != nil {
+		hoursToEnd := decimal.Zero
+		if !market.EndDate.IsZero() {
+			hoursToEnd = decimal.NewFromFloat(time.Until(market.EndDate).Hours()).Round(2)
+		}
+		env["market.category"] = market.Category
+		env["market.price"] = rc.CurrentPrice
+		env["market.best_bid"] = rc.BestBid
+		env["market.best_ask"] = rc.BestAsk
+		env["market.spread"] = rc.BestAsk.Sub(rc.BestBid)
+		env["market.last_price"] = market.LastPrice
+		env["market.volume"] = market.Volume
+		env["market.liquidity"] = market.Liquidity
+		env["market.active"] = market.Active
+		env["market.closed"] = market.Closed
+		env["market.hours_to_end"] = hoursToEnd
+	}
+
+	return env
+}
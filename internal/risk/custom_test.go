@@ -0,0 +1,61 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+
+	"polyagent-backend/internal/models"
+)
+
+func TestValidateRuleCustomExpr(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		wantErr string // 为空表示校验通过
+	}{
+		{
+			name:   "合法表达式",
+			params: `{"expression": "intent.notional < fund.aum * 0.05 && market.category in [\"Politics\"]", "score": 60}`,
+		},
+		{
+			name:    "缺少表达式",
+			params:  `{"message": "no expression"}`,
+			wantErr: "expression is required",
+		},
+		{
+			name:    "语法错误",
+			params:  `{"expression": "(intent.size > 1"}`,
+			wantErr: "invalid expression",
+		},
+		{
+			name:    "未知变量",
+			params:  `{"expression": "intent.notional < fund.cash"}`,
+			wantErr: "unknown variables fund.cash",
+		},
+		{
+			name:    "风险分数越界",
+			params:  `{"expression": "intent.size > 0", "score": 101}`,
+			wantErr: "score must be in [0, 100]",
+		},
+		{
+			name:    "参数非JSON",
+			params:  `expression`,
+			wantErr: "invalid CUSTOM_EXPR rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRule(models.RiskRule{RuleType: models.RiskRuleTypeCustomExpr, Params: tt.params})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateRule() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateRule() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// checkMaxDrawdown 检查基金净值回撤，触发后仅允许减仓
+func (a *Auditor) checkMaxDrawdown(params MaxDrawdownParams, intent *models.TradeIntent,
+	positions []models.Position, fund *models.Fund) RuleCheckResult {
+
+	drawdown := fundDrawdownPercent(fund)
+	score := int(drawdown.Div(params.MaxDrawdownPercent).Mul(decimal.NewFromInt(100)).IntPart())
+	if score > 100 {
+		score = 100
+	}
+
+	breached := fund.Status == models.FundStatusLiquidating ||
+		drawdown.GreaterThan(params.MaxDrawdownPercent)
+	if !breached {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxDrawdown,
+			Passed:   true,
+			Score:    score,
+			Message: fmt.Sprintf("净值回撤 %s%%，限制 %s%%",
+				drawdown.StringFixed(2), params.MaxDrawdownPercent.StringFixed(2)),
+		}
+	}
+
+	if increasesExposure(intent, positions) {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxDrawdown,
+			Passed:   false,
+			Score:    100,
+			Message: fmt.Sprintf("净值回撤 %s%% 已触发限制 %s%%，禁止增加风险敞口",
+				drawdown.StringFixed(2), params.MaxDrawdownPercent.StringFixed(2)),
+		}
+	}
+
+	return RuleCheckResult{
+		RuleType: models.RiskRuleTypeMaxDrawdown,
+		Passed:   true,
+		Score:    score,
+		Message: fmt.Sprintf("净值回撤 %s%% 已触发限制 %s%%，允许减仓",
+			drawdown.StringFixed(2), params.MaxDrawdownPercent.StringFixed(2)),
+	}
+}
+
+// checkDrawdown 实时检查基金净值回撤，维护历史高点并在触发时告警/清仓
+func (r *RealtimeRiskEngine) checkDrawdown(ctx context.Context, fund *models.Fund,
+	positions []models.Position) error {
+
+	rules, err := r.repo.GetRiskRulesByType(ctx, fund.ID, models.RiskRuleTypeMaxDrawdown)
+	if err != nil || len(rules) == 0 {
+		return nil
+	}
+
+	parsed, err := ParseRuleParams(models.RiskRuleTypeMaxDrawdown, rules[0].Params)
+	if err != nil {
+		return fmt.Errorf("解析回撤参数失败: %w", err)
+	}
+	params := parsed.(MaxDrawdownParams)
+
+	if !fund.CurrentNAV.IsPositive() {
+		return nil // 尚未计算净值
+	}
+
+	key := fund.ID.String()
+
+	// 净值创新高，更新高水位
+	if fund.CurrentNAV.GreaterThan(fund.HighWaterMark) {
+		fund.HighWaterMark = fund.CurrentNAV
+		delete(r.drawdownBreached, key)
+		return r.repo.UpdateFundHighWaterMark(ctx, fund.ID, fund.HighWaterMark)
+	}
+
+	drawdown := fundDrawdownPercent(fund)
+	if !drawdown.GreaterThan(params.MaxDrawdownPercent) {
+		delete(r.drawdownBreached, key)
+		return nil
+	}
+
+	// 同一次回撤只处理一次
+	if _, breached := r.drawdownBreached[key]; breached {
+		return nil
+	}
+	r.drawdownBreached[key] = time.Now()
+
+	r.logger.Warn("触发最大回撤",
+		zap.String("fund_id", key),
+		zap.String("nav", fund.CurrentNAV.String()),
+		zap.String("high_water_mark", fund.HighWaterMark.String()),
+		zap.String("drawdown_percent", drawdown.String()))
+
+	event := &models.RiskEvent{
+		FundID:   fund.ID,
+		RuleType: models.RiskRuleTypeMaxDrawdown,
+		Severity: "CRITICAL",
+		Description: fmt.Sprintf("单位净值 %s 较高点 %s 回撤 %s%%，触发限制 %s%%",
+			fund.CurrentNAV, fund.HighWaterMark, drawdown.StringFixed(2),
+			params.MaxDrawdownPercent.StringFixed(2)),
+		TriggeredAt: time.Now(),
+	}
+	if err := r.repo.CreateRiskEvent(ctx, event); err != nil {
+		r.logger.Error("记录风控事件失败", zap.Error(err))
+	}
+
+	if !params.LiquidateOnBreach || fund.Status == models.FundStatusLiquidating {
+		return nil
+	}
+
+	// 进入清仓模式，可由管理员通过 ResumeFund 解除
+	ok, err := r.repo.UpdateFundStatus(ctx, fund.ID, models.FundStatusActive, models.FundStatusLiquidating)
+	if err != nil {
+		return fmt.Errorf("更新基金清仓状态失败: %w", err)
+	}
+	if !ok {
+		return nil // 基金状态已被变更
+	}
+	fund.Status = models.FundStatusLiquidating
+
+	var errs []error
+	for i := range positions {
+		if positions[i].Size.IsZero() {
+			continue
+		}
+		if err := r.triggerCloseOut(ctx, *fund, &positions[i], models.RiskRuleTypeMaxDrawdown,
+			"CRITICAL", "基金进入清仓模式，平仓全部持仓"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ResumeFund 管理员解除基金清仓模式，恢复正常运营。
+// 以当前单位净值重置历史高点，避免回撤规则按旧高点立即再次触发清仓；操作记录为风控事件
+func (a *Auditor) ResumeFund(ctx context.Context, fundID uuid.UUID, operator, reason string) (*models.Fund, error) {
+	fund, err := a.repo.GetFund(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取基金失败: %w", err)
+	}
+	if fund == nil {
+		return nil, ErrFundNotFound
+	}
+	if fund.Status != models.FundStatusLiquidating {
+		return nil, ErrFundNotLiquidating
+	}
+
+	ok, err := a.repo.ResumeLiquidatingFund(ctx, fund.ID, fund.CurrentNAV)
+	if err != nil {
+		return nil, fmt.Errorf("解除清仓模式失败: %w", err)
+	}
+	if !ok {
+		return nil, ErrFundNotLiquidating
+	}
+	fund.Status = models.FundStatusActive
+	fund.HighWaterMark = fund.CurrentNAV
+
+	event := &models.RiskEvent{
+		FundID:   fund.ID,
+		RuleType: models.RiskRuleTypeMaxDrawdown,
+		Severity: "WARNING",
+		Description: fmt.Sprintf("管理员 %s 解除清仓模式，历史高点重置为 %s: %s",
+			operator, fund.CurrentNAV, reason),
+		TriggeredAt: time.Now(),
+	}
+	if err := a.repo.CreateRiskEvent(ctx, event); err != nil {
+		a.logger.Error("记录风控事件失败", zap.Error(err))
+	}
+
+	a.logger.Warn("解除基金清仓模式",
+		zap.String("fund_id", fund.ID.String()),
+		zap.String("operator", operator),
+		zap.String("high_water_mark", fund.HighWaterMark.String()))
+
+	return fund, nil
+}
+
+// fundDrawdownPercent 单位净值相对历史高点的回撤百分比
+func fundDrawdownPercent(fund *models.Fund) decimal.Decimal {
+	if !fund.HighWaterMark.IsPositive() || !fund.CurrentNAV.LessThan(fund.HighWaterMark) {
+		return decimal.Zero
+	}
+	return fund.HighWaterMark.Sub(fund.CurrentNAV).
+		Div(fund.HighWaterMark).Mul(decimal.NewFromInt(100))
+}
+
+// increasesExposure 判断意图是否增加风险敞口（买入，或卖出超过现有持仓）
+func increasesExposure(intent *models.TradeIntent, positions []models.Position) bool {
+	if intent.Side == models.TradeSideBuy {
+		return true
+	}
+
+	var held decimal.Decimal
+	for _, pos := range positions {
+		if pos.MarketID == intent.MarketID && pos.OutcomeID == intent.OutcomeID {
+			held = held.Add(pos.Size)
+		}
+	}
+	return intent.Size.GreaterThan(held)
+}
@@ -0,0 +1,229 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// exitRules 基金配置的离场规则
+type exitRules struct {
+	trailingStops []TrailingStopParams
+	takeProfits   []TakeProfitParams
+	timeExits     []TimeExitParams
+}
+
+func (e exitRules) empty() bool {
+	return len(e.trailingStops) == 0 && len(e.takeProfits) == 0 && len(e.timeExits) == 0
+}
+
+// exitSignal 离场信号
+type exitSignal struct {
+	ruleType models.RiskRuleType
+	severity string
+	reason   string
+}
+
+// checkExitRules 检查移动止损、止盈与定时离场
+func (r *RealtimeRiskEngine) checkExitRules(ctx context.Context, fund models.Fund,
+	positions []models.Position) error {
+
+	rules, err := r.loadExitRules(ctx, fund.ID)
+	if err != nil {
+		return err
+	}
+	if rules.empty() {
+		return nil
+	}
+
+	var errs []error
+	for i := range positions {
+		pos := &positions[i]
+		if pos.Size.IsZero() || !pos.CurrentPrice.IsPositive() {
+			continue
+		}
+
+		if err := r.updatePeakPrice(ctx, pos, rules.trailingStops); err != nil {
+			r.logger.Error("更新持仓最有利价格失败",
+				zap.String("position_id", pos.ID.String()),
+				zap.Error(err))
+		}
+
+		signal := r.evaluateExit(ctx, *pos, rules)
+		if signal == nil {
+			continue
+		}
+
+		if err := r.triggerCloseOut(ctx, fund, pos, signal.ruleType,
+			signal.severity, signal.reason); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadExitRules 加载并解析基金的离场规则，参数错误的规则跳过
+func (r *RealtimeRiskEngine) loadExitRules(ctx context.Context, fundID uuid.UUID) (exitRules, error) {
+	var rules exitRules
+	for _, ruleType := range []models.RiskRuleType{
+		models.RiskRuleTypeTrailingStop,
+		models.RiskRuleTypeTakeProfit,
+		models.RiskRuleTypeTimeExit,
+	} {
+		records, err := r.repo.GetRiskRulesByType(ctx, fundID, ruleType)
+		if err != nil {
+			return rules, fmt.Errorf("获取离场规则失败: %w", err)
+		}
+
+		for _, record := range records {
+			params, err := ParseRuleParams(ruleType, record.Params)
+			if err != nil {
+				r.logger.Error("解析离场规则参数失败",
+					zap.String("rule_id", record.ID.String()),
+					zap.Error(err))
+				continue
+			}
+
+			switch p := params.(type) {
+			case TrailingStopParams:
+				rules.trailingStops = append(rules.trailingStops, p)
+			case TakeProfitParams:
+				rules.takeProfits = append(rules.takeProfits, p)
+			case TimeExitParams:
+				rules.timeExits = append(rules.timeExits, p)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// updatePeakPrice 更新并持久化持仓最有利价格（多头取最高价，空头取最低价）
+func (r *RealtimeRiskEngine) updatePeakPrice(ctx context.Context, pos *models.Position,
+	trailingStops []TrailingStopParams) error {
+
+	tracked := false
+	for _, p := range trailingStops {
+		if p.Matches(*pos) {
+			tracked = true
+			break
+		}
+	}
+	if !tracked {
+		return nil
+	}
+
+	isLong := pos.Size.IsPositive()
+	if !pos.PeakPrice.IsZero() &&
+		(isLong && !pos.CurrentPrice.GreaterThan(pos.PeakPrice) ||
+			!isLong && !pos.CurrentPrice.LessThan(pos.PeakPrice)) {
+		return nil
+	}
+
+	pos.PeakPrice = pos.CurrentPrice
+	return r.repo.UpdatePositionPeakPrice(ctx, pos.ID, pos.PeakPrice)
+}
+
+// evaluateExit 依次评估移动止损、止盈、定时离场，返回首个触发的信号
+func (r *RealtimeRiskEngine) evaluateExit(ctx context.Context, pos models.Position,
+	rules exitRules) *exitSignal {
+
+	isLong := pos.Size.IsPositive()
+
+	for _, p := range rules.trailingStops {
+		if !p.Matches(pos) || !pos.PeakPrice.IsPositive() {
+			continue
+		}
+		retrace := pos.PeakPrice.Sub(pos.CurrentPrice)
+		if !isLong {
+			retrace = retrace.Neg()
+		}
+		retracePercent := retrace.Div(pos.PeakPrice).Mul(decimal.NewFromInt(100))
+		if retracePercent.GreaterThanOrEqual(p.TrailPercent) {
+			return &exitSignal{
+				ruleType: models.RiskRuleTypeTrailingStop,
+				severity: "CRITICAL",
+				reason: fmt.Sprintf("价格 %s 自最有利价格 %s 回落 %s%%，触发移动止损 %s%%",
+					pos.CurrentPrice, pos.PeakPrice, retracePercent.StringFixed(2),
+					p.TrailPercent.StringFixed(2)),
+			}
+		}
+	}
+
+	for _, p := range rules.takeProfits {
+		if !p.Matches(pos) {
+			continue
+		}
+		profitPercent := r.calculateProfitPercent(pos)
+		if p.TakeProfitPercent.IsPositive() && profitPercent.GreaterThanOrEqual(p.TakeProfitPercent) {
+			return &exitSignal{
+				ruleType: models.RiskRuleTypeTakeProfit,
+				severity: "WARNING",
+				reason: fmt.Sprintf("持仓盈利 %s%%，达到止盈线 %s%%",
+					profitPercent.StringFixed(2), p.TakeProfitPercent.StringFixed(2)),
+			}
+		}
+		if p.TakeProfitPrice.IsPositive() &&
+			(isLong && pos.CurrentPrice.GreaterThanOrEqual(p.TakeProfitPrice) ||
+				!isLong && pos.CurrentPrice.LessThanOrEqual(p.TakeProfitPrice)) {
+			return &exitSignal{
+				ruleType: models.RiskRuleTypeTakeProfit,
+				severity: "WARNING",
+				reason: fmt.Sprintf("价格 %s 达到止盈价 %s",
+					pos.CurrentPrice, p.TakeProfitPrice),
+			}
+		}
+	}
+
+	var endDate time.Time
+	for _, p := range rules.timeExits {
+		if !p.Matches(pos) {
+			continue
+		}
+		if endDate.IsZero() {
+			market, err := r.auditor.marketData.GetMarket(ctx, pos.MarketID)
+			if err != nil {
+				r.logger.Error("获取市场信息失败",
+					zap.String("market_id", pos.MarketID),
+					zap.Error(err))
+				return nil
+			}
+			if market.EndDate.IsZero() {
+				return nil
+			}
+			endDate = market.EndDate
+		}
+
+		remaining := time.Until(endDate)
+		if remaining <= time.Duration(p.MinutesBeforeEnd)*time.Minute {
+			return &exitSignal{
+				ruleType: models.RiskRuleTypeTimeExit,
+				severity: "WARNING",
+				reason: fmt.Sprintf("距市场结算仅剩 %s，触发定时离场（提前 %d 分钟）",
+					remaining.Truncate(time.Second), p.MinutesBeforeEnd),
+			}
+		}
+	}
+
+	return nil
+}
+
+// calculateProfitPercent 计算盈利百分比（亏损时为负）
+func (r *RealtimeRiskEngine) calculateProfitPercent(pos models.Position) decimal.Decimal {
+	if pos.EntryPrice.IsZero() {
+		return decimal.Zero
+	}
+
+	profit := pos.CurrentPrice.Sub(pos.EntryPrice)
+	if pos.Size.IsNegative() {
+		profit = profit.Neg()
+	}
+	return profit.Div(pos.EntryPrice).Mul(decimal.NewFromInt(100))
+}
@@ -0,0 +1,161 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// checkMinLiquidity 检查市场流动性与成交量
+func (a *Auditor) checkMinLiquidity(params MinLiquidityParams, market *executor.Market) RuleCheckResult {
+	if market == nil {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMinLiquidity,
+			Passed:   false,
+			Score:    100,
+			Message:  "无法获取市场信息，跳过交易",
+		}
+	}
+
+	if params.MinLiquidity.IsPositive() && market.Liquidity.LessThan(params.MinLiquidity) {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMinLiquidity,
+			Passed:   false,
+			Score:    80,
+			Message:  fmt.Sprintf("市场流动性 %s 低于下限 %s", market.Liquidity, params.MinLiquidity),
+		}
+	}
+
+	if params.MinVolume.IsPositive() && market.Volume.LessThan(params.MinVolume) {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMinLiquidity,
+			Passed:   false,
+			Score:    70,
+			Message:  fmt.Sprintf("市场成交量 %s 低于下限 %s", market.Volume, params.MinVolume),
+		}
+	}
+
+	return RuleCheckResult{
+		RuleType: models.RiskRuleTypeMinLiquidity,
+		Passed:   true,
+		Score:    0,
+		Message:  fmt.Sprintf("市场流动性 %s，成交量 %s", market.Liquidity, market.Volume),
+	}
+}
+
+// checkMaxSpread 检查意图结果代币的买卖价差
+func (a *Auditor) checkMaxSpread(params MaxSpreadParams, bestBid, bestAsk decimal.Decimal) RuleCheckResult {
+	if !bestBid.IsPositive() || !bestAsk.IsPositive() {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxSpread,
+			Passed:   false,
+			Score:    90,
+			Message:  "结果代币缺少双边报价",
+		}
+	}
+
+	spread := bestAsk.Sub(bestBid)
+	score := int(spread.Div(params.MaxSpread).Mul(decimal.NewFromInt(50)).IntPart())
+	if score > 100 {
+		score = 100
+	}
+
+	if spread.GreaterThan(params.MaxSpread) {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxSpread,
+			Passed:   false,
+			Score:    score,
+			Message:  fmt.Sprintf("买卖价差 %s 超过限制 %s", spread, params.MaxSpread),
+		}
+	}
+
+	return RuleCheckResult{
+		RuleType: models.RiskRuleTypeMaxSpread,
+		Passed:   true,
+		Score:    score,
+		Message:  fmt.Sprintf("买卖价差 %s，限制 %s", spread, params.MaxSpread),
+	}
+}
+
+// checkMaxSlippage 按订单簿深度估算成交均价，检查价格冲击
+func (a *Auditor) checkMaxSlippage(ctx context.Context, params MaxSlippageParams,
+	intent *models.TradeIntent, fund *models.Fund) RuleCheckResult {
+
+	maxSlippage := params.MaxSlippagePercent
+	if maxSlippage.IsZero() {
+		cfg, err := ParseStrategyConfig(fund.StrategyConfig)
+		if err != nil {
+			return RuleCheckResult{
+				RuleType: models.RiskRuleTypeMaxSlippage,
+				Passed:   false,
+				Score:    100,
+				Message:  fmt.Sprintf("策略配置解析失败: %v", err),
+			}
+		}
+		maxSlippage = cfg.MaxSlippagePercent
+	}
+
+	if !maxSlippage.IsPositive() {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxSlippage,
+			Passed:   true,
+			Score:    0,
+			Message:  "未配置最大滑点，跳过滑点检查",
+		}
+	}
+
+	book, err := a.marketData.GetOrderBook(ctx, intent.OutcomeID)
+	if err != nil {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxSlippage,
+			Passed:   false,
+			Score:    100,
+			Message:  fmt.Sprintf("获取订单簿失败: %v", err),
+		}
+	}
+
+	var bestPrice decimal.Decimal
+	if intent.Side == models.TradeSideBuy {
+		bestPrice = book.BestAsk()
+	} else {
+		bestPrice = book.BestBid()
+	}
+
+	avgPrice, filled := book.EstimateFill(string(intent.Side), intent.Size)
+	if bestPrice.IsZero() || filled.LessThan(intent.Size) {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxSlippage,
+			Passed:   false,
+			Score:    100,
+			Message:  fmt.Sprintf("订单簿深度不足，仅可成交 %s / %s", filled, intent.Size),
+		}
+	}
+
+	impact := avgPrice.Sub(bestPrice).Abs().Div(bestPrice).Mul(decimal.NewFromInt(100))
+	score := int(impact.Div(maxSlippage).Mul(decimal.NewFromInt(50)).IntPart())
+	if score > 100 {
+		score = 100
+	}
+
+	if impact.GreaterThan(maxSlippage) {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMaxSlippage,
+			Passed:   false,
+			Score:    score,
+			Message: fmt.Sprintf("预估成交均价 %s，价格冲击 %s%% 超过限制 %s%%",
+				avgPrice.StringFixed(4), impact.StringFixed(2), maxSlippage.StringFixed(2)),
+		}
+	}
+
+	return RuleCheckResult{
+		RuleType: models.RiskRuleTypeMaxSlippage,
+		Passed:   true,
+		Score:    score,
+		Message: fmt.Sprintf("预估成交均价 %s，价格冲击 %s%%，限制 %s%%",
+			avgPrice.StringFixed(4), impact.StringFixed(2), maxSlippage.StringFixed(2)),
+	}
+}
@@ -0,0 +1,171 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/models"
+
+	"go.uber.org/zap"
+)
+
+// checkMarketStatus 检查市场状态与距结算时间。
+// 临近结算的限制只约束增加风险敞口的意图，减仓与平仓不受限制
+func (a *Auditor) checkMarketStatus(params MarketStatusParams, market *executor.Market,
+	intent *models.TradeIntent, positions []models.Position) RuleCheckResult {
+	if market == nil {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMarketStatus,
+			Passed:   false,
+			Score:    100,
+			Message:  "无法获取市场信息，跳过交易",
+		}
+	}
+
+	switch {
+	case market.Closed:
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMarketStatus,
+			Passed:   false,
+			Score:    100,
+			Message:  "市场已关闭",
+		}
+	case !market.Active:
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMarketStatus,
+			Passed:   false,
+			Score:    100,
+			Message:  "市场未激活",
+		}
+	case !market.IsAcceptingOrders():
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMarketStatus,
+			Passed:   false,
+			Score:    100,
+			Message:  "市场已暂停接单",
+		}
+	}
+
+	if market.EndDate.IsZero() {
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMarketStatus,
+			Passed:   true,
+			Score:    0,
+			Message:  "市场状态正常，未设置结算时间",
+		}
+	}
+
+	remaining := time.Until(market.EndDate)
+	cutoff := time.Duration(params.MinMinutesToEnd) * time.Minute
+	if remaining < cutoff {
+		if !increasesExposure(intent, positions) {
+			return RuleCheckResult{
+				RuleType: models.RiskRuleTypeMarketStatus,
+				Passed:   true,
+				Score:    0,
+				Message: fmt.Sprintf("距市场结算仅剩 %s，低于限制 %s，允许减仓",
+					remaining.Truncate(time.Second), cutoff),
+			}
+		}
+		return RuleCheckResult{
+			RuleType: models.RiskRuleTypeMarketStatus,
+			Passed:   false,
+			Score:    90,
+			Message: fmt.Sprintf("距市场结算仅剩 %s，低于限制 %s",
+				remaining.Truncate(time.Second), cutoff),
+		}
+	}
+
+	return RuleCheckResult{
+		RuleType: models.RiskRuleTypeMarketStatus,
+		Passed:   true,
+		Score:    0,
+		Message:  fmt.Sprintf("市场状态正常，距结算 %s", remaining.Truncate(time.Minute)),
+	}
+}
+
+// checkMarketResolved 市场已公布结果时返回不通过的检查结果，与是否配置市场状态规则无关
+func checkMarketResolved(market *executor.Market) *RuleCheckResult {
+	if market == nil {
+		return nil
+	}
+	winner, resolved := market.WinningOutcome()
+	if !resolved {
+		return nil
+	}
+	return &RuleCheckResult{
+		RuleType: models.RiskRuleTypeMarketStatus,
+		Passed:   false,
+		Score:    100,
+		Message:  fmt.Sprintf("市场已结算（获胜结果 %s），不再接受交易", winner.Name),
+	}
+}
+
+// checkMarketResolution 检查持仓所在市场是否临近结算，每个持仓只预警一次
+func (r *RealtimeRiskEngine) checkMarketResolution(ctx context.Context,
+	fund models.Fund, positions []models.Position) {
+
+	rules, err := r.repo.GetRiskRulesByType(ctx, fund.ID, models.RiskRuleTypeMarketStatus)
+	if err != nil || len(rules) == 0 {
+		return
+	}
+
+	params, err := ParseRuleParams(models.RiskRuleTypeMarketStatus, rules[0].Params)
+	if err != nil {
+		r.logger.Error("解析市场状态参数失败", zap.Error(err))
+		return
+	}
+	window := params.(MarketStatusParams).WarnWindow()
+	if window <= 0 {
+		return
+	}
+
+	for _, pos := range positions {
+		if pos.Size.IsZero() {
+			continue
+		}
+
+		key := fund.ID.String() + ":" + pos.MarketID
+		if _, warned := r.resolutionWarned[key]; warned {
+			continue
+		}
+
+		market, err := r.auditor.marketData.GetMarket(ctx, pos.MarketID)
+		if err != nil {
+			r.logger.Error("获取市场信息失败",
+				zap.String("market_id", pos.MarketID),
+				zap.Error(err))
+			continue
+		}
+		if market.EndDate.IsZero() {
+			continue
+		}
+
+		remaining := time.Until(market.EndDate)
+		if remaining > window {
+			continue
+		}
+
+		r.logger.Warn("持仓市场临近结算",
+			zap.String("fund_id", fund.ID.String()),
+			zap.String("market_id", pos.MarketID),
+			zap.Duration("remaining", remaining))
+
+		event := &models.RiskEvent{
+			FundID:   fund.ID,
+			RuleType: models.RiskRuleTypeMarketStatus,
+			Severity: "WARNING",
+			MarketID: pos.MarketID,
+			Description: fmt.Sprintf("持仓 %s 距市场结算仅剩 %s",
+				pos.Size, remaining.Truncate(time.Second)),
+			TriggeredAt: time.Now(),
+		}
+		if err := r.repo.CreateRiskEvent(ctx, event); err != nil {
+			r.logger.Error("记录风控事件失败", zap.Error(err))
+			continue
+		}
+		r.resolutionWarned[key] = time.Now()
+	}
+}
@@ -0,0 +1,86 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+
+	"polyagent-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// PreviewResult 交易意图预审结果，不写入意图状态与审计日志
+type PreviewResult struct {
+	AuditResult
+	EstimatedFillPrice     decimal.Decimal `json:"estimated_fill_price"`          // 按订单簿估算的成交均价
+	EstimatedFilledSize    decimal.Decimal `json:"estimated_filled_size"`         // 订单簿深度可成交数量
+	FillEstimateError      string          `json:"fill_estimate_error,omitempty"` // 无法估算成交时的原因
+	CurrentPosition        decimal.Decimal `json:"current_position"`              // 该 outcome 当前持仓
+	PostTradePosition      decimal.Decimal `json:"post_trade_position"`           // 成交后持仓
+	PostTradeMarketValue   decimal.Decimal `json:"post_trade_market_value"`       // 成交后该市场持仓市值
+	PostTradeConcentration decimal.Decimal `json:"post_trade_concentration"`      // 成交后市场集中度（%），AUM 为零时为 0
+}
+
+// PreviewIntent 以只读方式执行全部规则，预估成交与成交后持仓
+func (a *Auditor) PreviewIntent(ctx context.Context, intent *models.TradeIntent) (*PreviewResult, error) {
+	result, rc, err := a.evaluate(ctx, intent)
+	if err != nil {
+		return nil, err
+	}
+	result.Decision = a.reviewThresholds(rc.Fund).Decide(result)
+
+	preview := &PreviewResult{AuditResult: *result}
+
+	// 估算成交均价，订单簿不可用时退回限价或市场参考价
+	fillPrice := intent.Price
+	if fillPrice.IsZero() {
+		fillPrice = rc.CurrentPrice
+	}
+	book, err := a.marketData.GetOrderBook(ctx, intent.OutcomeID)
+	if err != nil {
+		a.logger.Warn("预审获取订单簿失败",
+			zap.String("outcome_id", intent.OutcomeID),
+			zap.Error(err))
+		preview.FillEstimateError = fmt.Sprintf("获取订单簿失败: %v", err)
+	} else {
+		avgPrice, filled := book.EstimateFill(string(intent.Side), intent.Size)
+		preview.EstimatedFilledSize = filled
+		if filled.IsZero() {
+			preview.FillEstimateError = "订单簿无对手盘"
+		} else {
+			preview.EstimatedFillPrice = avgPrice
+			fillPrice = avgPrice
+			if filled.LessThan(intent.Size) {
+				preview.FillEstimateError = fmt.Sprintf("订单簿深度不足，仅可成交 %s", filled)
+			}
+		}
+	}
+
+	// 成交后持仓与市场集中度
+	var marketValue decimal.Decimal
+	for _, pos := range rc.Positions {
+		if pos.MarketID != intent.MarketID {
+			continue
+		}
+		if pos.OutcomeID == intent.OutcomeID {
+			preview.CurrentPosition = preview.CurrentPosition.Add(pos.Size)
+			continue
+		}
+		marketValue = marketValue.Add(pos.Size.Mul(pos.CurrentPrice))
+	}
+
+	if intent.Side == models.TradeSideBuy {
+		preview.PostTradePosition = preview.CurrentPosition.Add(intent.Size)
+	} else {
+		preview.PostTradePosition = preview.CurrentPosition.Sub(intent.Size)
+	}
+	preview.PostTradeMarketValue = marketValue.Add(preview.PostTradePosition.Mul(fillPrice))
+
+	if rc.Fund.TotalAUM.IsPositive() {
+		preview.PostTradeConcentration = preview.PostTradeMarketValue.Abs().
+			Div(rc.Fund.TotalAUM).Mul(decimal.NewFromInt(100)).Round(2)
+	}
+
+	return preview, nil
+}
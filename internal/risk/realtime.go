@@ -2,6 +2,7 @@ package risk
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -10,10 +11,22 @@ import (
 	"polyagent-backend/internal/pkg/logger"
 	"polyagent-backend/internal/repository"
 
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// 行情变动后触发检查的合并间隔
+const priceReactInterval = time.Second
+
+// PriceFeed 实时行情（由 marketdata.Feed 实现）
+type PriceFeed interface {
+	// Price 结果代币的实时参考价，行情未就绪时 ok 为 false
+	Price(tokenID string) (price decimal.Decimal, ok bool)
+	// Updates 行情发生变动的结果代币ID
+	Updates() <-chan string
+}
+
 // RealtimeRiskEngine 实时风控引擎
 type RealtimeRiskEngine struct {
 	repo    repository.Repository
@@ -27,17 +40,31 @@ type RealtimeRiskEngine struct {
 
 	// 止损执行器回调
 	stopLossExecutor func(ctx context.Context, position models.Position) error
+
+	// 实时行情，未设置时按 checkInterval 使用持仓记录的现价
+	priceFeed PriceFeed
+
+	// 已发出临近结算预警的持仓（fundID:marketID）
+	resolutionWarned map[string]time.Time
+	// 已触发最大回撤的基金，回撤恢复后移除
+	drawdownBreached map[string]time.Time
+
+	// 平仓冷却期：平仓进行中或完成后，在此期间内不重复触发
+	closeOutCooldown time.Duration
 }
 
 // NewRealtimeRiskEngine 创建实时风控引擎
 func NewRealtimeRiskEngine(repo repository.Repository, auditor *Auditor,
 	logger *logger.Logger, checkInterval time.Duration) *RealtimeRiskEngine {
 	return &RealtimeRiskEngine{
-		repo:          repo,
-		auditor:       auditor,
-		logger:        logger,
-		checkInterval: checkInterval,
-		stopCh:        make(chan struct{}),
+		repo:             repo,
+		auditor:          auditor,
+		logger:           logger,
+		checkInterval:    checkInterval,
+		stopCh:           make(chan struct{}),
+		resolutionWarned: make(map[string]time.Time),
+		drawdownBreached: make(map[string]time.Time),
+		closeOutCooldown: 5 * time.Minute,
 	}
 }
 
@@ -46,6 +73,16 @@ func (r *RealtimeRiskEngine) SetStopLossExecutor(executor func(ctx context.Conte
 	r.stopLossExecutor = executor
 }
 
+// SetCloseOutCooldown 设置平仓冷却期
+func (r *RealtimeRiskEngine) SetCloseOutCooldown(cooldown time.Duration) {
+	r.closeOutCooldown = cooldown
+}
+
+// SetPriceFeed 设置实时行情：持仓现价取实时参考价，行情变动后立即检查持有该代币的基金
+func (r *RealtimeRiskEngine) SetPriceFeed(feed PriceFeed) {
+	r.priceFeed = feed
+}
+
 // Start 启动实时风控
 func (r *RealtimeRiskEngine) Start(ctx context.Context) {
 	r.logger.Info("启动实时风控引擎", zap.Duration("interval", r.checkInterval))
@@ -67,6 +104,15 @@ func (r *RealtimeRiskEngine) run(ctx context.Context) {
 	ticker := time.NewTicker(r.checkInterval)
 	defer ticker.Stop()
 
+	// 行情变动的代币按 priceReactInterval 合并后检查
+	var updates <-chan string
+	if r.priceFeed != nil {
+		updates = r.priceFeed.Updates()
+	}
+	react := time.NewTicker(priceReactInterval)
+	defer react.Stop()
+	changed := make(map[string]bool)
+
 	// 立即执行一次
 	r.checkAllFunds(ctx)
 
@@ -78,6 +124,48 @@ func (r *RealtimeRiskEngine) run(ctx context.Context) {
 			return
 		case <-ticker.C:
 			r.checkAllFunds(ctx)
+		case tokenID := <-updates:
+			changed[tokenID] = true
+		case <-react.C:
+			if len(changed) > 0 {
+				r.checkTokens(ctx, changed)
+				changed = make(map[string]bool)
+			}
+		}
+	}
+}
+
+// checkTokens 检查持有行情变动代币的基金
+func (r *RealtimeRiskEngine) checkTokens(ctx context.Context, tokens map[string]bool) {
+	positions, err := r.repo.GetUnresolvedPositions(ctx)
+	if err != nil {
+		r.logger.Error("获取持仓失败", zap.Error(err))
+		return
+	}
+
+	affected := make(map[uuid.UUID]bool)
+	for _, pos := range positions {
+		if tokens[pos.OutcomeID] {
+			affected[pos.FundID] = true
+		}
+	}
+	if len(affected) == 0 {
+		return
+	}
+
+	funds, err := r.repo.GetActiveFunds(ctx)
+	if err != nil {
+		r.logger.Error("获取活跃基金失败", zap.Error(err))
+		return
+	}
+	for _, fund := range funds {
+		if !affected[fund.ID] {
+			continue
+		}
+		if err := r.checkFund(ctx, fund); err != nil {
+			r.logger.Error("检查基金风控失败",
+				zap.String("fund_id", fund.ID.String()),
+				zap.Error(err))
 		}
 	}
 }
@@ -107,6 +195,28 @@ func (r *RealtimeRiskEngine) checkFund(ctx context.Context, fund models.Fund) er
 		return fmt.Errorf("获取持仓失败: %w", err)
 	}
 
+	r.applyLivePrices(positions)
+
+	// 检查基金净值回撤
+	if err := r.checkDrawdown(ctx, &fund, positions); err != nil {
+		r.logger.Error("检查净值回撤失败",
+			zap.String("fund_id", fund.ID.String()),
+			zap.Error(err))
+	}
+
+	// 已结算持仓按结算价计值，仅参与回撤计算，不再止损或离场
+	positions = unresolvedPositions(positions)
+
+	// 检查临近结算的持仓
+	r.checkMarketResolution(ctx, fund, positions)
+
+	// 检查移动止损、止盈与定时离场
+	if err := r.checkExitRules(ctx, fund, positions); err != nil {
+		r.logger.Error("检查离场规则失败",
+			zap.String("fund_id", fund.ID.String()),
+			zap.Error(err))
+	}
+
 	// 获取止损规则
 	rules, err := r.repo.GetRiskRulesByType(ctx, fund.ID, models.RiskRuleTypeStopLoss)
 	if err != nil || len(rules) == 0 {
@@ -122,45 +232,45 @@ func (r *RealtimeRiskEngine) checkFund(ctx context.Context, fund models.Fund) er
 	stopLossParams := params.(StopLossParams)
 
 	// 检查每个持仓
-	for _, pos := range positions {
+	var errs []error
+	for i := range positions {
+		pos := &positions[i]
 		if pos.Size.IsZero() {
 			continue
 		}
 
 		// 计算当前亏损百分比
-		lossPercent := r.calculateLossPercent(pos)
+		lossPercent := r.calculateLossPercent(*pos)
 
 		if lossPercent.GreaterThan(stopLossParams.StopLossPercent) {
-			r.logger.Warn("触发止损",
-				zap.String("fund_id", fund.ID.String()),
-				zap.String("market_id", pos.MarketID),
-				zap.String("loss_percent", lossPercent.String()))
-
-			// 记录风控事件
-			event := &models.RiskEvent{
-				FundID:   fund.ID,
-				RuleType: models.RiskRuleTypeStopLoss,
-				Severity: "CRITICAL",
-				MarketID: pos.MarketID,
-				Description: fmt.Sprintf("持仓亏损 %.2f%%，触发止损线 %.2f%%",
-					lossPercent, stopLossParams.StopLossPercent),
-				TriggeredAt: time.Now(),
-			}
-			if err := r.repo.CreateRiskEvent(ctx, event); err != nil {
-				r.logger.Error("记录风控事件失败", zap.Error(err))
-			}
-
-			// 执行止损平仓
-			if r.stopLossExecutor != nil {
-				if err := r.stopLossExecutor(ctx, pos); err != nil {
-					r.logger.Error("执行止损平仓失败", zap.Error(err))
-					// 继续处理其他持仓
-				}
+			description := fmt.Sprintf("持仓亏损 %s%%，触发止损线 %s%%",
+				lossPercent.StringFixed(2), stopLossParams.StopLossPercent.StringFixed(2))
+			if err := r.triggerCloseOut(ctx, fund, pos, models.RiskRuleTypeStopLoss,
+				"CRITICAL", description); err != nil {
+				// 继续处理其他持仓
+				errs = append(errs, err)
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// applyLivePrices 以实时参考价更新未结算持仓的现价与未实现盈亏（仅用于本次检查）
+func (r *RealtimeRiskEngine) applyLivePrices(positions []models.Position) {
+	if r.priceFeed == nil {
+		return
+	}
+	for i := range positions {
+		pos := &positions[i]
+		if pos.ResolvedAt != nil {
+			continue
+		}
+		if price, ok := r.priceFeed.Price(pos.OutcomeID); ok {
+			pos.CurrentPrice = price
+			pos.UnrealizedPnL = price.Sub(pos.EntryPrice).Mul(pos.Size)
+		}
+	}
 }
 
 // checkStopLossWithDefault 使用默认设置检查止损
@@ -171,30 +281,25 @@ func (r *RealtimeRiskEngine) checkStopLossWithDefault(ctx context.Context,
 		return nil // 未设置止损
 	}
 
-	for _, pos := range positions {
-		lossPercent := r.calculateLossPercent(pos)
+	var errs []error
+	for i := range positions {
+		pos := &positions[i]
+		if pos.Size.IsZero() {
+			continue
+		}
 
-		if lossPercent.GreaterThan(fund.StopLossPercent) {
-			r.logger.Warn("触发默认止损",
-				zap.String("fund_id", fund.ID.String()),
-				zap.String("market_id", pos.MarketID))
-
-			event := &models.RiskEvent{
-				FundID:      fund.ID,
-				RuleType:    models.RiskRuleTypeStopLoss,
-				Severity:    "CRITICAL",
-				MarketID:    pos.MarketID,
-				Description: fmt.Sprintf("触发默认止损线 %.2f%%", fund.StopLossPercent),
-				TriggeredAt: time.Now(),
-			}
-			r.repo.CreateRiskEvent(ctx, event)
+		lossPercent := r.calculateLossPercent(*pos)
 
-			if r.stopLossExecutor != nil {
-				r.stopLossExecutor(ctx, pos)
+		if lossPercent.GreaterThan(fund.StopLossPercent) {
+			description := fmt.Sprintf("持仓亏损 %s%%，触发默认止损线 %s%%",
+				lossPercent.StringFixed(2), fund.StopLossPercent.StringFixed(2))
+			if err := r.triggerCloseOut(ctx, fund, pos, models.RiskRuleTypeStopLoss,
+				"CRITICAL", description); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // calculateLossPercent 计算亏损百分比
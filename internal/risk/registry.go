@@ -0,0 +1,101 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+// RuleContext 规则评估上下文
+type RuleContext struct {
+	Intent       *models.TradeIntent
+	Positions    []models.Position
+	Fund         *models.Fund
+	Market       *executor.Market // 获取失败时为 nil
+	BestBid      decimal.Decimal  // 意图结果代币的最优买价，无法获取时为 0
+	BestAsk      decimal.Decimal  // 意图结果代币的最优卖价，无法获取时为 0
+	CurrentPrice decimal.Decimal  // 意图结果代币参考价，无法获取时为 0
+	Volatility   decimal.Decimal  // 结果代币近24小时价格波动率，无K线数据时为 0
+	MarketData   MarketDataProvider
+
+	auditor *Auditor
+}
+
+// RuleEvaluator 规则评估器，按规则类型注册到规则表
+// 新增规则类型只需实现该接口并调用 RegisterRule，无需修改审计流程
+type RuleEvaluator interface {
+	// ParseParams 解析并校验 RiskRule.Params
+	ParseParams(data string) (RuleParams, error)
+	// Evaluate 针对交易意图执行规则检查
+	Evaluate(ctx context.Context, params RuleParams, rc *RuleContext) RuleCheckResult
+}
+
+// RuleFunc 以函数实现 RuleEvaluator，参数按 JSON 解析为 P 后校验
+type RuleFunc[P RuleParams] func(ctx context.Context, params P, rc *RuleContext) RuleCheckResult
+
+// ParseParams 实现 RuleEvaluator 接口
+func (f RuleFunc[P]) ParseParams(data string) (RuleParams, error) {
+	var params P
+	if err := json.Unmarshal([]byte(data), &params); err != nil {
+		return nil, err
+	}
+	return params, params.Validate()
+}
+
+// Evaluate 实现 RuleEvaluator 接口
+func (f RuleFunc[P]) Evaluate(ctx context.Context, params RuleParams, rc *RuleContext) RuleCheckResult {
+	return f(ctx, params.(P), rc)
+}
+
+var (
+	registryMu   sync.RWMutex
+	ruleRegistry = make(map[models.RiskRuleType]RuleEvaluator)
+)
+
+// RegisterRule 注册规则评估器，重复注册同一类型会 panic
+func RegisterRule(ruleType models.RiskRuleType, evaluator RuleEvaluator) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := ruleRegistry[ruleType]; exists {
+		panic(fmt.Sprintf("risk: rule type %s already registered", ruleType))
+	}
+	ruleRegistry[ruleType] = evaluator
+}
+
+// RegisteredRuleTypes 返回已注册的规则类型
+func RegisteredRuleTypes() []models.RiskRuleType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]models.RiskRuleType, 0, len(ruleRegistry))
+	for ruleType := range ruleRegistry {
+		types = append(types, ruleType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// ValidateRule 保存规则前校验类型与参数
+func ValidateRule(rule models.RiskRule) error {
+	if _, err := ParseRuleParams(rule.RuleType, rule.Params); err != nil {
+		return fmt.Errorf("invalid %s rule: %w", rule.RuleType, err)
+	}
+	return nil
+}
+
+// lookupRule 查找规则评估器
+func lookupRule(ruleType models.RiskRuleType) (RuleEvaluator, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	evaluator, ok := ruleRegistry[ruleType]
+	return evaluator, ok
+}
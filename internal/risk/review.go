@@ -0,0 +1,198 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 默认人工复核时限
+const defaultReviewSLA = 60 * time.Minute
+
+// AuditDecision 审计结论
+type AuditDecision string
+
+const (
+	DecisionApprove AuditDecision = "APPROVE" // 自动通过
+	DecisionReview  AuditDecision = "REVIEW"  // 转人工复核
+	DecisionReject  AuditDecision = "REJECT"  // 自动拒绝
+)
+
+// ErrIntentNotInReview 意图不处于待复核状态
+var ErrIntentNotInReview = errors.New("意图不处于待人工复核状态")
+
+// ReviewThresholds 风险分数复核阈值（对应 StrategyConfig.review）
+// 总分低于 auto_approve_below 自动通过，高于 auto_reject_above 自动拒绝，其余转人工复核；
+// auto_reject_above 为 0 表示未配置，沿用规则全部通过即批准的逻辑
+type ReviewThresholds struct {
+	AutoApproveBelow int `json:"auto_approve_below"` // 自动通过阈值 X
+	AutoRejectAbove  int `json:"auto_reject_above"`  // 自动拒绝阈值 Y
+	SLAMinutes       int `json:"sla_minutes"`        // 复核时限（分钟），超时自动拒绝，默认60
+}
+
+// Validate 校验阈值配置
+func (t ReviewThresholds) Validate() error {
+	if t.AutoApproveBelow < 0 || t.AutoRejectAbove < 0 || t.SLAMinutes < 0 {
+		return fmt.Errorf("review thresholds must not be negative")
+	}
+	if t.Enabled() && t.AutoApproveBelow > t.AutoRejectAbove {
+		return fmt.Errorf("auto_approve_below must not exceed auto_reject_above")
+	}
+	return nil
+}
+
+// Enabled 是否启用分数复核
+func (t ReviewThresholds) Enabled() bool {
+	return t.AutoRejectAbove > 0
+}
+
+// SLA 复核时限
+func (t ReviewThresholds) SLA() time.Duration {
+	if t.SLAMinutes > 0 {
+		return time.Duration(t.SLAMinutes) * time.Minute
+	}
+	return defaultReviewSLA
+}
+
+// Decide 根据审计结果给出结论
+// 存在未通过的规则时不会自动通过，最多进入人工复核；已熔断时直接拒绝
+func (t ReviewThresholds) Decide(result *AuditResult) AuditDecision {
+	if result.Halted {
+		return DecisionReject
+	}
+	if !t.Enabled() {
+		if result.Passed {
+			return DecisionApprove
+		}
+		return DecisionReject
+	}
+
+	switch {
+	case result.TotalRiskScore > t.AutoRejectAbove:
+		return DecisionReject
+	case result.TotalRiskScore < t.AutoApproveBelow && result.Passed:
+		return DecisionApprove
+	default:
+		return DecisionReview
+	}
+}
+
+// reviewThresholds 读取基金复核阈值，配置无效时按未配置处理
+func (a *Auditor) reviewThresholds(fund *models.Fund) ReviewThresholds {
+	cfg, err := ParseStrategyConfig(fund.StrategyConfig)
+	if err != nil {
+		a.logger.Error("解析基金策略配置失败，不启用人工复核",
+			zap.String("fund_id", fund.ID.String()),
+			zap.Error(err))
+		return ReviewThresholds{}
+	}
+	return cfg.Review
+}
+
+// ReviewIntent 人工复核交易意图，记录复核结论与复核人
+func (a *Auditor) ReviewIntent(ctx context.Context, intentID uuid.UUID,
+	reviewer string, approve bool, note string) (*models.TradeIntent, error) {
+
+	intent, err := a.repo.GetTradeIntent(ctx, intentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易意图失败: %w", err)
+	}
+	if intent == nil || intent.Status != models.IntentStatusManualReview {
+		return nil, ErrIntentNotInReview
+	}
+
+	now := time.Now()
+	intent.ReviewedBy = reviewer
+	intent.ReviewedAt = &now
+
+	result := "APPROVE"
+	if approve {
+		intent.Status = models.IntentStatusApproved
+	} else {
+		result = "REJECT"
+		intent.Status = models.IntentStatusRejected
+		intent.RejectReason = "人工复核拒绝"
+		if note != "" {
+			intent.RejectReason = fmt.Sprintf("人工复核拒绝: %s", note)
+		}
+	}
+
+	// 仅当意图仍待复核时落库，并发复核或超时任务已处理时视为不在复核中
+	updated, err := a.repo.CompleteIntentReview(ctx, intent)
+	if err != nil {
+		return nil, fmt.Errorf("更新意图状态失败: %w", err)
+	}
+	if !updated {
+		return nil, ErrIntentNotInReview
+	}
+
+	auditLog := &models.AuditLog{
+		IntentID:  intent.ID,
+		FundID:    intent.FundID,
+		Result:    result,
+		Details:   note,
+		Reviewer:  reviewer,
+		CheckedAt: now,
+	}
+	if err := a.repo.CreateAuditLog(ctx, auditLog); err != nil {
+		a.logger.Error("记录审计日志失败", zap.Error(err))
+	}
+
+	a.logger.Info("人工复核完成",
+		zap.String("intent_id", intent.ID.String()),
+		zap.String("reviewer", reviewer),
+		zap.String("result", result))
+
+	return intent, nil
+}
+
+// ExpireOverdueReviews 超过复核时限的意图自动拒绝
+func (a *Auditor) ExpireOverdueReviews(ctx context.Context, limit int) (int, error) {
+	now := time.Now()
+	intents, err := a.repo.GetOverdueReviewIntents(ctx, now, limit)
+	if err != nil {
+		return 0, fmt.Errorf("获取超时复核意图失败: %w", err)
+	}
+
+	expired := 0
+	for i := range intents {
+		intent := &intents[i]
+		intent.Status = models.IntentStatusRejected
+		intent.RejectReason = fmt.Sprintf("人工复核超时（截止 %s）", intent.ReviewDueAt.Format(time.RFC3339))
+		updated, err := a.repo.CompleteIntentReview(ctx, intent)
+		if err != nil {
+			a.logger.Error("更新超时复核意图失败",
+				zap.String("intent_id", intent.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		if !updated {
+			// 已被人工复核处理
+			continue
+		}
+		expired++
+
+		auditLog := &models.AuditLog{
+			IntentID:  intent.ID,
+			FundID:    intent.FundID,
+			Result:    "EXPIRED",
+			Details:   intent.RejectReason,
+			CheckedAt: now,
+		}
+		if err := a.repo.CreateAuditLog(ctx, auditLog); err != nil {
+			a.logger.Error("记录审计日志失败", zap.Error(err))
+		}
+
+		a.logger.Warn("人工复核超时，自动拒绝",
+			zap.String("intent_id", intent.ID.String()),
+			zap.Time("review_due_at", *intent.ReviewDueAt))
+	}
+
+	return expired, nil
+}
@@ -0,0 +1,241 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrRuleNotFound 规则不存在
+	ErrRuleNotFound = errors.New("风控规则不存在")
+	// ErrRuleAdminOnly 管理员规则，基金经理无权修改
+	ErrRuleAdminOnly = errors.New("管理员规则仅管理员可修改")
+	// ErrRuleRetired 规则已停用
+	ErrRuleRetired = errors.New("风控规则已停用")
+	// ErrInvalidRule 规则内容无效
+	ErrInvalidRule = errors.New("风控规则无效")
+	// ErrRuleConflict 规则已被并发修改或停用
+	ErrRuleConflict = errors.New("风控规则已被修改，请刷新后重试")
+)
+
+// RuleInput 创建或修改规则的内容
+type RuleInput struct {
+	RuleType      models.RiskRuleType
+	Params        string
+	Description   string
+	AdminOnly     bool
+	EffectiveFrom *time.Time // 为空时立即生效，不可早于当前时间
+}
+
+// RuleStore 风控规则版本管理
+// 规则每次修改都生成新版本，旧版本以 effective_to 结束，便于追溯任意时间点生效的规则
+type RuleStore struct {
+	repo   repository.Repository
+	logger *logger.Logger
+}
+
+// NewRuleStore 创建规则版本管理
+func NewRuleStore(repo repository.Repository, logger *logger.Logger) *RuleStore {
+	return &RuleStore{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// RulesAt 查询指定时间点生效的规则
+func (s *RuleStore) RulesAt(ctx context.Context, fundID uuid.UUID, at time.Time) ([]models.RiskRule, error) {
+	rules, err := s.repo.GetRiskRulesAt(ctx, fundID, at)
+	if err != nil {
+		return nil, fmt.Errorf("获取风控规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// Versions 查询规则全部版本
+func (s *RuleStore) Versions(ctx context.Context, fundID, ruleID uuid.UUID) ([]models.RiskRule, error) {
+	versions, err := s.repo.GetRiskRuleVersions(ctx, fundID, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取规则版本失败: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, ErrRuleNotFound
+	}
+	return versions, nil
+}
+
+// Create 创建规则，仅管理员可创建管理员规则
+func (s *RuleStore) Create(ctx context.Context, fundID uuid.UUID, input RuleInput,
+	operator string, isAdmin bool) (*models.RiskRule, error) {
+
+	if input.AdminOnly && !isAdmin {
+		return nil, ErrRuleAdminOnly
+	}
+
+	from, err := effectiveFrom(input.EffectiveFrom, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &models.RiskRule{
+		FundID:        fundID,
+		Version:       1,
+		RuleType:      input.RuleType,
+		Params:        input.Params,
+		IsActive:      true,
+		AdminOnly:     input.AdminOnly,
+		Description:   input.Description,
+		EffectiveFrom: from,
+		CreatedBy:     operator,
+	}
+	if err := ValidateRule(*rule); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	if err := s.repo.SaveRiskRuleVersion(ctx, nil, rule); err != nil {
+		return nil, fmt.Errorf("保存风控规则失败: %w", err)
+	}
+
+	s.logger.Info("创建风控规则",
+		zap.String("fund_id", fundID.String()),
+		zap.String("rule_id", rule.RuleID.String()),
+		zap.String("rule_type", string(rule.RuleType)),
+		zap.String("operator", operator))
+
+	return rule, nil
+}
+
+// Update 修改规则，生成新版本并结束当前版本；规则类型不可修改
+func (s *RuleStore) Update(ctx context.Context, fundID, ruleID uuid.UUID, input RuleInput,
+	operator string, isAdmin bool) (*models.RiskRule, error) {
+
+	latest, err := s.latest(ctx, fundID, ruleID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if input.AdminOnly && !isAdmin {
+		return nil, ErrRuleAdminOnly
+	}
+	if input.RuleType != "" && input.RuleType != latest.RuleType {
+		return nil, fmt.Errorf("%w: rule_type cannot be changed", ErrInvalidRule)
+	}
+
+	from, err := effectiveFrom(input.EffectiveFrom, latest.EffectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	next := &models.RiskRule{
+		RuleID:        ruleIDOf(latest),
+		Version:       latest.Version + 1,
+		FundID:        fundID,
+		RuleType:      latest.RuleType,
+		Params:        input.Params,
+		IsActive:      true,
+		AdminOnly:     input.AdminOnly,
+		Description:   input.Description,
+		EffectiveFrom: from,
+		CreatedBy:     operator,
+	}
+	if err := ValidateRule(*next); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	latest.EffectiveTo = &from
+	if err := s.repo.SaveRiskRuleVersion(ctx, latest, next); err != nil {
+		if errors.Is(err, repository.ErrRuleVersionClosed) {
+			return nil, ErrRuleConflict
+		}
+		return nil, fmt.Errorf("保存风控规则失败: %w", err)
+	}
+
+	s.logger.Info("修改风控规则",
+		zap.String("fund_id", fundID.String()),
+		zap.String("rule_id", next.RuleID.String()),
+		zap.Int("version", next.Version),
+		zap.String("operator", operator))
+
+	return next, nil
+}
+
+// Retire 停用规则，当前版本于指定时间结束
+func (s *RuleStore) Retire(ctx context.Context, fundID, ruleID uuid.UUID, at *time.Time,
+	operator string, isAdmin bool) (*models.RiskRule, error) {
+
+	latest, err := s.latest(ctx, fundID, ruleID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := effectiveFrom(at, latest.EffectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	latest.EffectiveTo = &to
+	if err := s.repo.SaveRiskRuleVersion(ctx, latest, nil); err != nil {
+		if errors.Is(err, repository.ErrRuleVersionClosed) {
+			return nil, ErrRuleConflict
+		}
+		return nil, fmt.Errorf("停用风控规则失败: %w", err)
+	}
+
+	s.logger.Info("停用风控规则",
+		zap.String("fund_id", fundID.String()),
+		zap.String("rule_id", ruleIDOf(latest).String()),
+		zap.Time("effective_to", to),
+		zap.String("operator", operator))
+
+	return latest, nil
+}
+
+// latest 获取规则最新版本并校验可修改
+func (s *RuleStore) latest(ctx context.Context, fundID, ruleID uuid.UUID, isAdmin bool) (*models.RiskRule, error) {
+	versions, err := s.Versions(ctx, fundID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := versions[len(versions)-1]
+	if latest.EffectiveTo != nil || !latest.IsActive {
+		return nil, ErrRuleRetired
+	}
+	if latest.AdminOnly && !isAdmin {
+		return nil, ErrRuleAdminOnly
+	}
+	return &latest, nil
+}
+
+// effectiveFrom 计算生效时间：为空取当前时间，不可早于当前时间及上一版本生效时间
+func effectiveFrom(requested *time.Time, notBefore time.Time) (time.Time, error) {
+	now := time.Now()
+	if requested == nil {
+		if notBefore.After(now) {
+			return notBefore, nil
+		}
+		return now, nil
+	}
+	if requested.Before(now) {
+		return time.Time{}, fmt.Errorf("%w: effective time must not be in the past", ErrInvalidRule)
+	}
+	if requested.Before(notBefore) {
+		return time.Time{}, fmt.Errorf("%w: effective time must not precede the current version", ErrInvalidRule)
+	}
+	return *requested, nil
+}
+
+// ruleIDOf 规则ID，兼容未设置 rule_id 的历史数据
+func ruleIDOf(rule *models.RiskRule) uuid.UUID {
+	if rule.RuleID == uuid.Nil {
+		return rule.ID
+	}
+	return rule.RuleID
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"polyagent-backend/internal/models"
+	"time"
 
 	"github.com/shopspring/decimal"
 )
@@ -82,32 +83,172 @@ func (p StopLossParams) Validate() error {
 	return nil
 }
 
-// ParseRuleParams 解析规则参数
+// MinLiquidityParams 最小流动性参数
+type MinLiquidityParams struct {
+	MinLiquidity decimal.Decimal `json:"min_liquidity"` // 市场最小流动性（USDC），0表示不限制
+	MinVolume    decimal.Decimal `json:"min_volume"`    // 市场最小成交量（USDC），0表示不限制
+}
+
+func (p MinLiquidityParams) Validate() error {
+	if p.MinLiquidity.IsNegative() || p.MinVolume.IsNegative() {
+		return fmt.Errorf("min_liquidity and min_volume must not be negative")
+	}
+	if p.MinLiquidity.IsZero() && p.MinVolume.IsZero() {
+		return fmt.Errorf("at least one of min_liquidity and min_volume must be positive")
+	}
+	return nil
+}
+
+// MaxSpreadParams 最大价差参数
+type MaxSpreadParams struct {
+	MaxSpread decimal.Decimal `json:"max_spread"` // 最优卖价与最优买价之差的上限（价格单位，0-1）
+}
+
+func (p MaxSpreadParams) Validate() error {
+	if p.MaxSpread.LessThanOrEqual(decimal.Zero) || p.MaxSpread.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("max_spread must be in (0, 1]")
+	}
+	return nil
+}
+
+// MaxSlippageParams 最大滑点参数
+type MaxSlippageParams struct {
+	// 最大滑点百分比，为0时使用基金 StrategyConfig 中的 max_slippage_percent
+	MaxSlippagePercent decimal.Decimal `json:"max_slippage_percent"`
+}
+
+func (p MaxSlippageParams) Validate() error {
+	if p.MaxSlippagePercent.IsNegative() || p.MaxSlippagePercent.GreaterThan(decimal.NewFromInt(100)) {
+		return fmt.Errorf("max_slippage_percent must be in [0, 100]")
+	}
+	return nil
+}
+
+// MarketStatusParams 市场状态参数
+type MarketStatusParams struct {
+	MinMinutesToEnd  int `json:"min_minutes_to_end"`  // 距结算不足该分钟数时拒绝开仓
+	WarnMinutesToEnd int `json:"warn_minutes_to_end"` // 持仓距结算不足该分钟数时预警，0表示沿用 min_minutes_to_end
+}
+
+func (p MarketStatusParams) Validate() error {
+	if p.MinMinutesToEnd < 0 || p.WarnMinutesToEnd < 0 {
+		return fmt.Errorf("min_minutes_to_end and warn_minutes_to_end must not be negative")
+	}
+	return nil
+}
+
+// WarnWindow 持仓临近结算的预警窗口
+func (p MarketStatusParams) WarnWindow() time.Duration {
+	if p.WarnMinutesToEnd > 0 {
+		return time.Duration(p.WarnMinutesToEnd) * time.Minute
+	}
+	return time.Duration(p.MinMinutesToEnd) * time.Minute
+}
+
+// MaxDrawdownParams 最大回撤参数
+type MaxDrawdownParams struct {
+	MaxDrawdownPercent decimal.Decimal `json:"max_drawdown_percent"` // 单位净值相对历史高点的最大回撤百分比
+	LiquidateOnBreach  bool            `json:"liquidate_on_breach"`  // 触发后是否进入清仓模式，由管理员通过 /admin/funds/:fundId/resume 解除
+}
+
+func (p MaxDrawdownParams) Validate() error {
+	if p.MaxDrawdownPercent.LessThanOrEqual(decimal.Zero) ||
+		p.MaxDrawdownPercent.GreaterThan(decimal.NewFromInt(100)) {
+		return fmt.Errorf("max_drawdown_percent must be in (0, 100]")
+	}
+	return nil
+}
+
+// PositionScope 离场规则作用范围，均为空时作用于基金全部持仓
+type PositionScope struct {
+	MarketID  string `json:"market_id,omitempty"`
+	OutcomeID string `json:"outcome_id,omitempty"`
+}
+
+// Matches 判断持仓是否在规则范围内
+func (s PositionScope) Matches(pos models.Position) bool {
+	if s.MarketID != "" && s.MarketID != pos.MarketID {
+		return false
+	}
+	if s.OutcomeID != "" && s.OutcomeID != pos.OutcomeID {
+		return false
+	}
+	return true
+}
+
+// TrailingStopParams 移动止损参数
+type TrailingStopParams struct {
+	PositionScope
+	TrailPercent decimal.Decimal `json:"trail_percent"` // 自最有利价格回落的百分比
+}
+
+func (p TrailingStopParams) Validate() error {
+	if p.TrailPercent.LessThanOrEqual(decimal.Zero) ||
+		p.TrailPercent.GreaterThan(decimal.NewFromInt(100)) {
+		return fmt.Errorf("trail_percent must be in (0, 100]")
+	}
+	return nil
+}
+
+// TakeProfitParams 止盈参数
+type TakeProfitParams struct {
+	PositionScope
+	TakeProfitPercent decimal.Decimal `json:"take_profit_percent"` // 相对成本价的盈利百分比，0表示不启用
+	TakeProfitPrice   decimal.Decimal `json:"take_profit_price"`   // 多头达到（空头跌破）该价格止盈，0表示不启用
+}
+
+func (p TakeProfitParams) Validate() error {
+	if p.TakeProfitPercent.IsNegative() || p.TakeProfitPrice.IsNegative() ||
+		p.TakeProfitPrice.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("take_profit_percent must not be negative and take_profit_price must be in [0, 1]")
+	}
+	if p.TakeProfitPercent.IsZero() && p.TakeProfitPrice.IsZero() {
+		return fmt.Errorf("at least one of take_profit_percent and take_profit_price must be positive")
+	}
+	return nil
+}
+
+// TimeExitParams 定时离场参数
+type TimeExitParams struct {
+	PositionScope
+	MinutesBeforeEnd int `json:"minutes_before_end"` // 距市场结算不足该分钟数时平仓
+}
+
+func (p TimeExitParams) Validate() error {
+	if p.MinutesBeforeEnd <= 0 {
+		return fmt.Errorf("minutes_before_end must be positive")
+	}
+	return nil
+}
+
+// StrategyConfig 基金策略配置（对应 Fund.StrategyConfig）
+type StrategyConfig struct {
+	MarketCategories   []string         `json:"market_categories"`    // 允许交易的市场类别
+	MaxSlippagePercent decimal.Decimal  `json:"max_slippage_percent"` // 最大滑点百分比
+	StopLossPercent    decimal.Decimal  `json:"stop_loss_percent"`    // 止损线百分比
+	Review             ReviewThresholds `json:"review"`               // 风险分数复核阈值
+}
+
+// ParseStrategyConfig 解析基金策略配置，空配置返回零值
+func ParseStrategyConfig(data string) (StrategyConfig, error) {
+	var cfg StrategyConfig
+	if data == "" {
+		return cfg, nil
+	}
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid strategy_config: %w", err)
+	}
+	if err := cfg.Review.Validate(); err != nil {
+		return cfg, fmt.Errorf("invalid strategy_config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ParseRuleParams 按规则表中注册的评估器解析并校验规则参数
 func ParseRuleParams(ruleType models.RiskRuleType, data string) (RuleParams, error) {
-	switch ruleType {
-	case models.RiskRuleTypePositionLimit:
-		var params PositionLimitParams
-		if err := json.Unmarshal([]byte(data), &params); err != nil {
-			return nil, err
-		}
-		return params, params.Validate()
-	case models.RiskRuleTypeDailyLossLimit:
-		var params DailyLossLimitParams
-		err := json.Unmarshal([]byte(data), &params)
-		return params, err
-	case models.RiskRuleTypePriceDeviation:
-		var params PriceDeviationParams
-		err := json.Unmarshal([]byte(data), &params)
-		return params, err
-	case models.RiskRuleTypeConcentration:
-		var params ConcentrationParams
-		err := json.Unmarshal([]byte(data), &params)
-		return params, err
-	case models.RiskRuleTypeStopLoss:
-		var params StopLossParams
-		err := json.Unmarshal([]byte(data), &params)
-		return params, err
-	default:
+	evaluator, ok := lookupRule(ruleType)
+	if !ok {
 		return nil, fmt.Errorf("unknown rule type: %s", ruleType)
 	}
+	return evaluator.ParseParams(data)
 }
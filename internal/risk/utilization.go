@@ -0,0 +1,132 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// RuleUtilization 风控规则使用率：当前值与规则上限之比，>=1 表示已触及上限
+type RuleUtilization struct {
+	RuleID      uuid.UUID           `json:"ruleId"`
+	RuleType    models.RiskRuleType `json:"ruleType"`
+	Current     decimal.Decimal     `json:"current"`
+	Limit       decimal.Decimal     `json:"limit"`
+	Utilization decimal.Decimal     `json:"utilization"`
+}
+
+// RuleUtilizations 计算基金当前生效规则的使用率。
+// 仅统计以持仓或净值衡量的规则（仓位、日亏损、集中度、止损、最大回撤），逐笔校验类规则不计算
+func (a *Auditor) RuleUtilizations(ctx context.Context, fund *models.Fund, positions []models.Position) ([]RuleUtilization, error) {
+	rules, err := a.repo.GetRiskRulesAt(ctx, fund.ID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("获取风控规则失败: %w", err)
+	}
+	open := unresolvedPositions(positions)
+
+	var result []RuleUtilization
+	for i := range rules {
+		rule := &rules[i]
+		evaluator, ok := lookupRule(rule.RuleType)
+		if !ok {
+			continue
+		}
+		params, err := evaluator.ParseParams(rule.Params)
+		if err != nil {
+			continue
+		}
+
+		var current, limit decimal.Decimal
+		switch p := params.(type) {
+		case PositionLimitParams:
+			current, limit = positionUtilization(p, open)
+		case DailyLossLimitParams:
+			current, limit = a.calculateTodayLoss(fund.ID), p.MaxDailyLoss
+		case ConcentrationParams:
+			current, limit = maxConcentration(open, fund), p.MaxConcentrationPercent
+		case StopLossParams:
+			current, limit = maxLossPercent(open), p.StopLossPercent
+		case MaxDrawdownParams:
+			current, limit = fundDrawdownPercent(fund), p.MaxDrawdownPercent
+		default:
+			continue
+		}
+
+		utilization := decimal.Zero
+		if limit.IsPositive() && current.IsPositive() {
+			utilization = current.Div(limit).Round(6)
+		}
+		result = append(result, RuleUtilization{
+			RuleID:      ruleIDOf(rule),
+			RuleType:    rule.RuleType,
+			Current:     current.Round(8),
+			Limit:       limit,
+			Utilization: utilization,
+		})
+	}
+	return result, nil
+}
+
+// positionUtilization 单市场最大持仓与总敞口中使用率较高者
+func positionUtilization(params PositionLimitParams, positions []models.Position) (decimal.Decimal, decimal.Decimal) {
+	sizes := make(map[string]decimal.Decimal)
+	exposure := decimal.Zero
+	for _, pos := range positions {
+		key := pos.MarketID + "/" + pos.OutcomeID
+		sizes[key] = sizes[key].Add(pos.Size)
+		exposure = exposure.Add(pos.Size.Mul(pos.CurrentPrice))
+	}
+	maxSize := decimal.Zero
+	for _, size := range sizes {
+		if size.GreaterThan(maxSize) {
+			maxSize = size
+		}
+	}
+
+	if params.MaxTotalExposure.IsPositive() && params.MaxPositionSize.IsPositive() &&
+		exposure.Div(params.MaxTotalExposure).GreaterThan(maxSize.Div(params.MaxPositionSize)) {
+		return exposure, params.MaxTotalExposure
+	}
+	return maxSize, params.MaxPositionSize
+}
+
+// maxConcentration 单市场持仓价值占 AUM 的最大百分比
+func maxConcentration(positions []models.Position, fund *models.Fund) decimal.Decimal {
+	if !fund.TotalAUM.IsPositive() {
+		return decimal.Zero
+	}
+	values := make(map[string]decimal.Decimal)
+	for _, pos := range positions {
+		values[pos.MarketID] = values[pos.MarketID].Add(pos.Size.Mul(pos.CurrentPrice))
+	}
+	maxValue := decimal.Zero
+	for _, value := range values {
+		if value.GreaterThan(maxValue) {
+			maxValue = value
+		}
+	}
+	return maxValue.Div(fund.TotalAUM).Mul(decimal.NewFromInt(100))
+}
+
+// maxLossPercent 持仓中最大的亏损百分比
+func maxLossPercent(positions []models.Position) decimal.Decimal {
+	worst := decimal.Zero
+	for _, pos := range positions {
+		if pos.Size.IsZero() || pos.EntryPrice.IsZero() {
+			continue
+		}
+		loss := pos.EntryPrice.Sub(pos.CurrentPrice).Div(pos.EntryPrice).Mul(decimal.NewFromInt(100))
+		if pos.Size.IsNegative() {
+			loss = loss.Neg()
+		}
+		if loss.GreaterThan(worst) {
+			worst = loss
+		}
+	}
+	return worst
+}
@@ -2,12 +2,24 @@ package scheduler
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"polyagent-backend/internal/analytics"
+	"polyagent-backend/internal/auditchain"
+	"polyagent-backend/internal/cash"
+	"polyagent-backend/internal/catalog"
+	"polyagent-backend/internal/dashboard"
 	"polyagent-backend/internal/executor"
+	"polyagent-backend/internal/fee"
+	"polyagent-backend/internal/leaderboard"
+	"polyagent-backend/internal/ledger"
 	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/nav"
 	"polyagent-backend/internal/pkg/logger"
+	"polyagent-backend/internal/pricehistory"
 	"polyagent-backend/internal/repository"
+	"polyagent-backend/internal/resolution"
 	"polyagent-backend/internal/risk"
 
 	"github.com/go-co-op/gocron/v2"
@@ -23,6 +35,16 @@ type Scheduler struct {
 	auditor   *risk.Auditor
 	executor  *executor.Executor
 	rtEngine  *risk.RealtimeRiskEngine
+	navEngine *nav.Engine
+	ledger    *ledger.Service
+	fees      *fee.Service
+	cash      *cash.Tracker
+	resolver  *resolution.Service
+	catalog   *catalog.Syncer
+	prices    *pricehistory.Service
+	analytics *analytics.Service
+	rankings  *leaderboard.Service
+	dashboard *dashboard.Service
 	logger    *logger.Logger
 
 	// 配置
@@ -47,11 +69,36 @@ type Config struct {
 
 	// 实时风控
 	RealtimeCheckInterval time.Duration
+
+	// 人工复核队列
+	ReviewCheckInterval time.Duration
+
+	// 盘中估算净值，间隔为0时不估算
+	NAVEstimateInterval time.Duration
+
+	// 审计链头导出，间隔为0时不导出
+	ChainExportInterval time.Duration
+	ChainExportDir      string
+
+	// 链上现金余额快照，间隔为0或未设置快照器时不执行
+	CashSnapshotInterval time.Duration
+
+	// 市场目录完整同步，间隔为0或未设置同步器时不执行
+	CatalogSyncInterval time.Duration
+
+	// 价格历史清理，间隔为0或未设置价格历史服务时不清理
+	PriceHistoryPruneInterval time.Duration
+
+	// 投资人收益榜单刷新，间隔为0或未设置榜单服务时不刷新
+	LeaderboardInterval time.Duration
+
+	// 基金健康度刷新，间隔为0或未设置看板服务时不刷新
+	DashboardInterval time.Duration
 }
 
 // NewScheduler 创建调度器
 func NewScheduler(repo repository.Repository, auditor *risk.Auditor,
-	exec *executor.Executor, rtEngine *risk.RealtimeRiskEngine,
+	exec *executor.Executor, rtEngine *risk.RealtimeRiskEngine, navEngine *nav.Engine,
 	logger *logger.Logger, config Config) (*Scheduler, error) {
 
 	s, err := gocron.NewScheduler()
@@ -65,11 +112,49 @@ func NewScheduler(repo repository.Repository, auditor *risk.Auditor,
 		auditor:   auditor,
 		executor:  exec,
 		rtEngine:  rtEngine,
+		navEngine: navEngine,
+		ledger:    ledger.NewService(repo, logger),
+		fees:      fee.NewService(repo, navEngine, logger),
 		logger:    logger,
 		config:    config,
 	}, nil
 }
 
+// SetCashTracker 设置链上现金余额快照器
+func (s *Scheduler) SetCashTracker(tracker *cash.Tracker) {
+	s.cash = tracker
+}
+
+// SetMarketResolver 设置市场结算服务，未设置时数据聚合不检查市场结算
+func (s *Scheduler) SetMarketResolver(resolver *resolution.Service) {
+	s.resolver = resolver
+}
+
+// SetCatalog 设置市场目录同步器，未设置时数据聚合不刷新市场行情
+func (s *Scheduler) SetCatalog(syncer *catalog.Syncer) {
+	s.catalog = syncer
+}
+
+// SetPriceHistory 设置价格历史服务，未设置时数据聚合不记录价格采样
+func (s *Scheduler) SetPriceHistory(prices *pricehistory.Service) {
+	s.prices = prices
+}
+
+// SetAnalytics 设置业绩分析服务，未设置时净值结算后不更新业绩指标
+func (s *Scheduler) SetAnalytics(svc *analytics.Service) {
+	s.analytics = svc
+}
+
+// SetLeaderboard 设置投资人收益榜单服务
+func (s *Scheduler) SetLeaderboard(svc *leaderboard.Service) {
+	s.rankings = svc
+}
+
+// SetDashboard 设置基金经理看板服务
+func (s *Scheduler) SetDashboard(svc *dashboard.Service) {
+	s.dashboard = svc
+}
+
 // Start 启动调度
 func (s *Scheduler) Start(ctx context.Context) error {
 	s.logger.Info("启动定时调度器")
@@ -114,6 +199,101 @@ func (s *Scheduler) Start(ctx context.Context) error {
 		return err
 	}
 
+	// 5. 人工复核任务 - 处理超时复核
+	if _, err := s.scheduler.NewJob(
+		gocron.DurationJob(s.config.ReviewCheckInterval),
+		gocron.NewTask(s.processManualReviews, ctx),
+		gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("manual_review"))),
+		gocron.WithName("人工复核任务"),
+	); err != nil {
+		return err
+	}
+
+	// 6. 盘中估算净值任务
+	if s.config.NAVEstimateInterval > 0 {
+		if _, err := s.scheduler.NewJob(
+			gocron.DurationJob(s.config.NAVEstimateInterval),
+			gocron.NewTask(s.estimateFundNAV, ctx),
+			gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("nav_estimate"))),
+			gocron.WithName("盘中估算净值任务"),
+		); err != nil {
+			return err
+		}
+	}
+
+	// 7. 审计链头导出任务
+	if s.config.ChainExportInterval > 0 {
+		verifier := auditchain.NewVerifier(s.repo, s.logger, s.config.ChainExportDir)
+		if _, err := s.scheduler.NewJob(
+			gocron.DurationJob(s.config.ChainExportInterval),
+			gocron.NewTask(s.exportChainHeads, ctx, verifier),
+			gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("chain_export"))),
+			gocron.WithName("审计链头导出任务"),
+		); err != nil {
+			return err
+		}
+	}
+
+	// 8. 链上现金余额快照任务
+	if s.cash != nil && s.config.CashSnapshotInterval > 0 {
+		if _, err := s.scheduler.NewJob(
+			gocron.DurationJob(s.config.CashSnapshotInterval),
+			gocron.NewTask(s.snapshotCash, ctx),
+			gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("cash_snapshot"))),
+			gocron.WithName("现金余额快照任务"),
+		); err != nil {
+			return err
+		}
+	}
+
+	// 9. 市场目录同步任务
+	if s.catalog != nil && s.config.CatalogSyncInterval > 0 {
+		if _, err := s.scheduler.NewJob(
+			gocron.DurationJob(s.config.CatalogSyncInterval),
+			gocron.NewTask(s.syncCatalog, ctx),
+			gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("market_catalog"))),
+			gocron.WithName("市场目录同步任务"),
+		); err != nil {
+			return err
+		}
+	}
+
+	// 10. 价格历史清理任务
+	if s.prices != nil && s.config.PriceHistoryPruneInterval > 0 {
+		if _, err := s.scheduler.NewJob(
+			gocron.DurationJob(s.config.PriceHistoryPruneInterval),
+			gocron.NewTask(s.prunePriceHistory, ctx),
+			gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("price_history_prune"))),
+			gocron.WithName("价格历史清理任务"),
+		); err != nil {
+			return err
+		}
+	}
+
+	// 11. 投资人收益榜单刷新任务
+	if s.rankings != nil && s.config.LeaderboardInterval > 0 {
+		if _, err := s.scheduler.NewJob(
+			gocron.DurationJob(s.config.LeaderboardInterval),
+			gocron.NewTask(s.refreshLeaderboard, ctx),
+			gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("leaderboard"))),
+			gocron.WithName("收益榜单刷新任务"),
+		); err != nil {
+			return err
+		}
+	}
+
+	// 12. 基金健康度刷新任务
+	if s.dashboard != nil && s.config.DashboardInterval > 0 {
+		if _, err := s.scheduler.NewJob(
+			gocron.DurationJob(s.config.DashboardInterval),
+			gocron.NewTask(s.refreshDashboard, ctx),
+			gocron.WithIdentifier(uuid.NewSHA1(namespace, []byte("fund_health"))),
+			gocron.WithName("基金健康度刷新任务"),
+		); err != nil {
+			return err
+		}
+	}
+
 	// 启动调度器
 	s.scheduler.Start()
 
@@ -169,12 +349,18 @@ func (s *Scheduler) auditPendingIntents(ctx context.Context) {
 			continue
 		}
 
-		if result.Passed {
+		switch result.Decision {
+		case risk.DecisionApprove:
 			s.logger.Info("审计通过，提交执行",
 				zap.String("intent_id", intent.ID.String()))
 			// 提交到执行队列
 			s.executor.SubmitTask(intent.ID)
-		} else {
+		case risk.DecisionReview:
+			s.logger.Warn("风险分数进入复核区间，等待人工复核",
+				zap.String("intent_id", intent.ID.String()),
+				zap.Int("risk_score", result.TotalRiskScore),
+				zap.Timep("review_due_at", intent.ReviewDueAt))
+		default:
 			s.logger.Warn("审计拒绝",
 				zap.String("intent_id", intent.ID.String()),
 				zap.String("reason", intent.RejectReason))
@@ -199,54 +385,150 @@ func (s *Scheduler) executeApprovedIntents(ctx context.Context) {
 	}
 }
 
+// processManualReviews 拒绝超过复核时限的意图
+// 复核通过的意图由复核接口提交执行，未能及时执行的由滞留意图兜底任务重新提交
+func (s *Scheduler) processManualReviews(ctx context.Context) {
+	expired, err := s.auditor.ExpireOverdueReviews(ctx, s.config.AuditBatchSize)
+	if err != nil {
+		s.logger.Error("处理超时复核失败", zap.Error(err))
+		return
+	}
+	if expired > 0 {
+		s.logger.Warn("人工复核超时自动拒绝", zap.Int("count", expired))
+	}
+}
+
+// exportChainHeads 导出审计哈希链头
+func (s *Scheduler) exportChainHeads(ctx context.Context, verifier *auditchain.Verifier) {
+	if _, err := verifier.ExportHeads(ctx); err != nil {
+		s.logger.Error("导出审计链头失败", zap.Error(err))
+	}
+}
+
+// snapshotCash 记录链上现金余额并与账本对账
+func (s *Scheduler) snapshotCash(ctx context.Context) {
+	if err := s.cash.Snapshot(ctx); err != nil {
+		s.logger.Error("记录链上现金余额失败", zap.Error(err))
+	}
+}
+
+// syncCatalog 完整同步市场目录
+func (s *Scheduler) syncCatalog(ctx context.Context) {
+	if err := s.catalog.Sync(ctx); err != nil {
+		s.logger.Error("同步市场目录失败", zap.Error(err))
+	}
+}
+
+// prunePriceHistory 清理超过保留期的价格采样与K线
+func (s *Scheduler) prunePriceHistory(ctx context.Context) {
+	if err := s.prices.Prune(ctx, time.Now()); err != nil {
+		s.logger.Error("清理价格历史失败", zap.Error(err))
+	}
+}
+
+// refreshLeaderboard 增量刷新投资人收益榜单
+func (s *Scheduler) refreshLeaderboard(ctx context.Context) {
+	if err := s.rankings.Refresh(ctx, time.Now()); err != nil {
+		s.logger.Error("刷新收益榜单失败", zap.Error(err))
+	}
+}
+
+// refreshDashboard 刷新基金健康度缓存
+func (s *Scheduler) refreshDashboard(ctx context.Context) {
+	if err := s.dashboard.Refresh(ctx, time.Now()); err != nil {
+		s.logger.Error("刷新基金健康度失败", zap.Error(err))
+	}
+}
+
 // dailySettlement 每日结算
 func (s *Scheduler) dailySettlement(ctx context.Context) {
 	s.logger.Info("执行每日结算")
+	strikeAt := time.Now() // 本次净值结算时点，此前提交的申赎请求参与结算
 
-	// 1. 计算所有基金NAV
+	// 1. 计提费用并计算所有基金NAV，结晶周期到期的基金支付费用
 	funds, err := s.repo.GetActiveFunds(ctx)
 	if err != nil {
 		s.logger.Error("获取基金列表失败", zap.Error(err))
 		return
 	}
 
+	struck := make(map[uuid.UUID]bool, len(funds))
 	for _, fund := range funds {
-		if err := s.calculateFundNAV(ctx, fund); err != nil {
+		if err := s.calculateFundNAV(ctx, fund, strikeAt); err != nil {
 			s.logger.Error("计算NAV失败",
 				zap.String("fund_id", fund.ID.String()),
 				zap.Error(err))
+			continue
+		}
+		struck[fund.ID] = true
+		if s.analytics != nil {
+			if _, err := s.analytics.Recompute(ctx, fund.ID); err != nil {
+				s.logger.Error("更新业绩指标失败",
+					zap.String("fund_id", fund.ID.String()),
+					zap.Error(err))
+			}
 		}
 	}
 
-	// 2. 处理赎回请求
-	if err := s.processRedemptions(ctx); err != nil {
-		s.logger.Error("处理赎回失败", zap.Error(err))
+	// 2. 按最新单位净值结算申赎请求，净值结算失败的基金顺延至下次
+	if err := s.processRedemptions(ctx, strikeAt, struck); err != nil {
+		s.logger.Error("处理申赎失败", zap.Error(err))
 	}
 
 	// 3. 生成日报
 	s.generateDailyReport(ctx)
 }
 
-// calculateFundNAV 计算基金NAV
-func (s *Scheduler) calculateFundNAV(ctx context.Context, fund models.Fund) error {
-	positions, err := s.repo.GetFundPositions(ctx, fund.ID)
-	if err != nil {
+// calculateFundNAV 计提费用后计算基金正式NAV并记录净值历史，随后按结晶周期支付费用
+func (s *Scheduler) calculateFundNAV(ctx context.Context, fund models.Fund, strikeAt time.Time) error {
+	if err := s.fees.Accrue(ctx, &fund, strikeAt); err != nil {
+		return fmt.Errorf("费用计提失败: %w", err)
+	}
+	if _, err := s.navEngine.Strike(ctx, &fund); err != nil {
 		return err
 	}
+	if _, err := s.fees.CrystallizeIfDue(ctx, &fund, strikeAt); err != nil {
+		return fmt.Errorf("费用结晶失败: %w", err)
+	}
+	return nil
+}
 
-	var totalValue decimal.Decimal
-	for _, pos := range positions {
-		totalValue = totalValue.Add(pos.Size.Mul(pos.CurrentPrice))
+// estimateFundNAV 盘中估算各基金净值
+func (s *Scheduler) estimateFundNAV(ctx context.Context) {
+	funds, err := s.repo.GetActiveFunds(ctx)
+	if err != nil {
+		s.logger.Error("获取基金列表失败", zap.Error(err))
+		return
 	}
 
-	// 更新基金AUM
-	fund.TotalAUM = totalValue
-	return s.repo.UpdateFund(ctx, &fund)
+	for i := range funds {
+		if _, err := s.navEngine.Estimate(ctx, &funds[i]); err != nil {
+			s.logger.Error("估算NAV失败",
+				zap.String("fund_id", funds[i].ID.String()),
+				zap.Error(err))
+		}
+	}
 }
 
-// processRedemptions 处理赎回
-func (s *Scheduler) processRedemptions(ctx context.Context) error {
-	// 实现赎回逻辑
+// processRedemptions 处理申赎：按各基金最新单位净值发行或注销份额
+// 仅处理 struck 中本次已完成净值结算的基金，避免按过期净值发行或注销份额
+func (s *Scheduler) processRedemptions(ctx context.Context, strikeAt time.Time, struck map[uuid.UUID]bool) error {
+	// 重新读取基金，使用本次结算后的净值与规模
+	funds, err := s.repo.GetActiveFunds(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range funds {
+		if !struck[funds[i].ID] {
+			continue
+		}
+		if _, err := s.ledger.Settle(ctx, &funds[i], strikeAt); err != nil {
+			s.logger.Error("基金申赎结算失败",
+				zap.String("fund_id", funds[i].ID.String()),
+				zap.Error(err))
+		}
+	}
 	return nil
 }
 
@@ -260,32 +542,39 @@ func (s *Scheduler) generateDailyReport(ctx context.Context) {
 func (s *Scheduler) aggregateData(ctx context.Context) {
 	s.logger.Debug("执行数据聚合")
 
-	// 1. 更新市场价格
+	// 1. 结算已公布结果的市场
+	if s.resolver != nil {
+		if settled, err := s.resolver.Run(ctx); err != nil {
+			s.logger.Error("检查市场结算失败", zap.Error(err))
+		} else if settled > 0 {
+			s.logger.Info("市场结算完成", zap.Int("markets", settled))
+		}
+	}
+
+	// 2. 更新市场价格
 	if err := s.updateMarketPrices(ctx); err != nil {
 		s.logger.Error("更新市场价格失败", zap.Error(err))
 	}
 
-	// 2. 更新持仓盈亏
+	// 3. 更新持仓盈亏
 	if err := s.updatePositionPnL(ctx); err != nil {
 		s.logger.Error("更新持仓盈亏失败", zap.Error(err))
 	}
-}
 
-// updateMarketPrices 更新市场价格
-func (s *Scheduler) updateMarketPrices(ctx context.Context) error {
-	// 获取所有活跃市场
-	markets, err := s.repo.GetActiveMarkets(ctx)
-	if err != nil {
-		return err
+	// 4. 记录价格采样并汇总K线
+	if s.prices != nil {
+		if err := s.prices.Sample(ctx, time.Now()); err != nil {
+			s.logger.Error("记录价格采样失败", zap.Error(err))
+		}
 	}
+}
 
-	for _, market := range markets {
-		// 从Polymarket获取最新价格
-		// 更新到数据库
-		_ = market
+// updateMarketPrices 刷新持仓与关注市场的行情
+func (s *Scheduler) updateMarketPrices(ctx context.Context) error {
+	if s.catalog == nil {
+		return nil
 	}
-
-	return nil
+	return s.catalog.Refresh(ctx)
 }
 
 // updatePositionPnL 更新持仓盈亏
@@ -295,21 +584,44 @@ func (s *Scheduler) updatePositionPnL(ctx context.Context) error {
 		return err
 	}
 
+	// 从市场目录缓存读取各结果代币的最新价格
+	tokenIDs := make([]string, 0, len(positions))
 	for _, pos := range positions {
-		// 获取当前市场价格
-		currentPrice := pos.CurrentPrice // 实际应从市场数据获取
+		if pos.ResolvedAt == nil {
+			tokenIDs = append(tokenIDs, pos.OutcomeID)
+		}
+	}
+	outcomes, err := s.repo.GetMarketOutcomes(ctx, tokenIDs)
+	if err != nil {
+		return err
+	}
+	prices := make(map[string]decimal.Decimal, len(outcomes))
+	for _, o := range outcomes {
+		prices[o.TokenID] = o.Price
+	}
+
+	for _, pos := range positions {
+		// 已结算持仓按结算价计入已实现盈亏
+		if pos.ResolvedAt != nil {
+			continue
+		}
+
+		// 获取当前市场价格，缓存中无价格时沿用上次价格
+		currentPrice := pos.CurrentPrice
+		if price, ok := prices[pos.OutcomeID]; ok && price.IsPositive() {
+			currentPrice = price
+		}
 
 		// 计算未实现盈亏
+		var unrealizedPnL decimal.Decimal
 		if pos.Size.GreaterThan(decimal.Zero) {
-			pos.UnrealizedPnL = currentPrice.Sub(pos.EntryPrice).Mul(pos.Size)
+			unrealizedPnL = currentPrice.Sub(pos.EntryPrice).Mul(pos.Size)
 		} else {
-			pos.UnrealizedPnL = pos.EntryPrice.Sub(currentPrice).Mul(pos.Size.Abs())
+			unrealizedPnL = pos.EntryPrice.Sub(currentPrice).Mul(pos.Size.Abs())
 		}
 
-		pos.CurrentPrice = currentPrice
-		pos.LastUpdated = time.Now()
-
-		if err := s.repo.SavePosition(ctx, &pos); err != nil {
+		// 仅更新行情字段，避免覆盖本轮期间写入的触发状态或结算结果
+		if err := s.repo.UpdatePositionMark(ctx, pos.ID, currentPrice, unrealizedPnL, time.Now()); err != nil {
 			s.logger.Error("更新持仓失败", zap.Error(err))
 		}
 	}
@@ -0,0 +1,245 @@
+// Package transparency 面向投资人的交易审计公开信息。
+// 意图终结后经过公开延迟才可查询，避免策略被抢跑；经理、复核人身份及自定义规则细节脱敏。
+package transparency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"polyagent-backend/internal/models"
+	"polyagent-backend/internal/repository"
+	"polyagent-backend/internal/risk"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultPublishDelay 默认公开延迟
+const DefaultPublishDelay = 24 * time.Hour
+
+// 脱敏占位
+const redacted = "[REDACTED]"
+
+// ErrNotPublished 意图不存在、不属于该基金或尚未公开
+var ErrNotPublished = errors.New("交易意图未公开")
+
+// publishedStatuses 可公开的终结状态
+var publishedStatuses = []models.IntentStatus{
+	models.IntentStatusCompleted,
+	models.IntentStatusRejected,
+	models.IntentStatusFailed,
+	models.IntentStatusCancelled,
+}
+
+// IntentAudit 公开的意图审计记录
+type IntentAudit struct {
+	ID             uuid.UUID              `json:"id"`
+	FundID         uuid.UUID              `json:"fund_id"`
+	MarketID       string                 `json:"market_id"`
+	OutcomeID      string                 `json:"outcome_id"`
+	Side           models.TradeSide       `json:"side"`
+	Size           decimal.Decimal        `json:"size"`
+	Price          decimal.Decimal        `json:"price"`
+	OrderType      string                 `json:"order_type"`
+	Status         models.IntentStatus    `json:"status"`
+	Passed         bool                   `json:"passed"`
+	Decision       risk.AuditDecision     `json:"decision,omitempty"`
+	TotalRiskScore int                    `json:"total_risk_score"`
+	Checks         []risk.RuleCheckResult `json:"checks"`
+	ManualReview   bool                   `json:"manual_review"` // 是否经过人工复核
+	RejectReason   string                 `json:"reject_reason,omitempty"`
+	ExecutedPrice  decimal.Decimal        `json:"executed_price"`
+	ExecutedTx     string                 `json:"executed_tx,omitempty"`
+	ExecutedAt     *time.Time             `json:"executed_at,omitempty"`
+	CreatedAt      time.Time              `json:"created_at"`
+	SettledAt      time.Time              `json:"settled_at"`
+	Logs           []AuditLogEntry        `json:"logs,omitempty"` // 仅详情返回
+}
+
+// AuditLogEntry 公开的审计日志，不含复核人及复核意见
+type AuditLogEntry struct {
+	ID          uuid.UUID           `json:"id"`
+	RuleType    models.RiskRuleType `json:"rule_type"`
+	RiskRuleID  *uuid.UUID          `json:"risk_rule_id,omitempty"`
+	RuleVersion int                 `json:"rule_version,omitempty"`
+	Result      string              `json:"result"`
+	Details     string              `json:"details"`
+	CheckedAt   time.Time           `json:"checked_at"`
+}
+
+// Cursor 分页游标：上一页最后一条意图的更新时间与ID，同一时间更新的意图按ID继续翻页
+type Cursor struct {
+	Before   time.Time
+	BeforeID uuid.UUID
+}
+
+// Page 分页结果，NextBefore 与 NextBeforeID 为下一页游标
+type Page struct {
+	Items        []IntentAudit `json:"items"`
+	NextBefore   *time.Time    `json:"next_before,omitempty"`
+	NextBeforeID *uuid.UUID    `json:"next_before_id,omitempty"`
+}
+
+// Service 审计公开服务
+type Service struct {
+	repo  repository.Repository
+	delay time.Duration
+}
+
+// NewService 创建审计公开服务，delay 不大于0时使用默认公开延迟
+func NewService(repo repository.Repository, delay time.Duration) *Service {
+	if delay <= 0 {
+		delay = DefaultPublishDelay
+	}
+	return &Service{
+		repo:  repo,
+		delay: delay,
+	}
+}
+
+// PublishDelay 公开延迟
+func (s *Service) PublishDelay() time.Duration {
+	return s.delay
+}
+
+// ListIntents 分页查询已公开的意图，cursor 为空时从最新一条开始
+func (s *Service) ListIntents(ctx context.Context, fundID uuid.UUID, cursor *Cursor, limit int) (*Page, error) {
+	before, beforeID := time.Now().Add(-s.delay), uuid.Nil
+	if cursor != nil && cursor.Before.Before(before) {
+		before, beforeID = cursor.Before, cursor.BeforeID
+	}
+
+	intents, err := s.repo.GetSettledFundIntents(ctx, fundID, publishedStatuses, before, beforeID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取公开意图失败: %w", err)
+	}
+
+	page := &Page{Items: make([]IntentAudit, 0, len(intents))}
+	for i := range intents {
+		page.Items = append(page.Items, publicIntent(&intents[i]))
+	}
+	if len(intents) == limit && limit > 0 {
+		last := intents[len(intents)-1]
+		page.NextBefore = &last.UpdatedAt
+		page.NextBeforeID = &last.ID
+	}
+	return page, nil
+}
+
+// GetIntent 查询单个已公开意图及其审计日志
+func (s *Service) GetIntent(ctx context.Context, fundID, intentID uuid.UUID) (*IntentAudit, error) {
+	intent, err := s.repo.GetTradeIntent(ctx, intentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易意图失败: %w", err)
+	}
+	if intent == nil || intent.FundID != fundID || !s.published(intent) {
+		return nil, ErrNotPublished
+	}
+
+	logs, err := s.repo.GetIntentAuditLogs(ctx, intentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取审计日志失败: %w", err)
+	}
+
+	audit := publicIntent(intent)
+	audit.Logs = make([]AuditLogEntry, 0, len(logs))
+	for _, log := range logs {
+		audit.Logs = append(audit.Logs, publicLog(log))
+	}
+	return &audit, nil
+}
+
+// ChainHead 最近一次导出的审计链头，供投资人核对哈希链
+func (s *Service) ChainHead(ctx context.Context, fundID uuid.UUID) (*models.ChainHeadSnapshot, error) {
+	snapshot, err := s.repo.GetLatestChainHeadSnapshot(ctx, fundID)
+	if err != nil {
+		return nil, fmt.Errorf("获取链头快照失败: %w", err)
+	}
+	return snapshot, nil
+}
+
+// published 意图已终结且超过公开延迟
+func (s *Service) published(intent *models.TradeIntent) bool {
+	if !intent.UpdatedAt.Before(time.Now().Add(-s.delay)) {
+		return false
+	}
+	for _, status := range publishedStatuses {
+		if intent.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// publicIntent 转换为公开记录，不含经理及复核人身份
+func publicIntent(intent *models.TradeIntent) IntentAudit {
+	audit := IntentAudit{
+		ID:            intent.ID,
+		FundID:        intent.FundID,
+		MarketID:      intent.MarketID,
+		OutcomeID:     intent.OutcomeID,
+		Side:          intent.Side,
+		Size:          intent.Size,
+		Price:         intent.Price,
+		OrderType:     intent.OrderType,
+		Status:        intent.Status,
+		Checks:        make([]risk.RuleCheckResult, 0),
+		ManualReview:  intent.ReviewedAt != nil,
+		RejectReason:  intent.RejectReason,
+		ExecutedPrice: intent.ExecutedPrice,
+		ExecutedTx:    intent.ExecutedTx,
+		ExecutedAt:    intent.ExecutedAt,
+		CreatedAt:     intent.CreatedAt,
+		SettledAt:     intent.UpdatedAt,
+	}
+
+	var result risk.AuditResult
+	if intent.AuditResult != "" && json.Unmarshal([]byte(intent.AuditResult), &result) == nil {
+		audit.Passed = result.Passed
+		audit.Decision = result.Decision
+		audit.TotalRiskScore = result.TotalRiskScore
+		for _, check := range result.Checks {
+			audit.Checks = append(audit.Checks, publicCheck(check))
+		}
+	}
+
+	// 人工复核意见及自定义规则的拒绝原因可能暴露策略细节
+	if intent.ReviewedBy != "" && intent.Status == models.IntentStatusRejected {
+		audit.RejectReason = "人工复核拒绝"
+	}
+	for _, check := range result.Checks {
+		if check.RuleType == models.RiskRuleTypeCustomExpr && !check.Passed {
+			audit.RejectReason = redacted
+			break
+		}
+	}
+	return audit
+}
+
+// publicCheck 自定义表达式规则仅公开是否通过与分数
+func publicCheck(check risk.RuleCheckResult) risk.RuleCheckResult {
+	if check.RuleType == models.RiskRuleTypeCustomExpr {
+		check.Message = redacted
+	}
+	return check
+}
+
+// publicLog 人工复核日志的复核意见不公开
+func publicLog(log models.AuditLog) AuditLogEntry {
+	entry := AuditLogEntry{
+		ID:          log.ID,
+		RuleType:    log.RuleType,
+		RiskRuleID:  log.RiskRuleID,
+		RuleVersion: log.RuleVersion,
+		Result:      log.Result,
+		Details:     log.Details,
+		CheckedAt:   log.CheckedAt,
+	}
+	if log.Reviewer != "" || log.RuleType == models.RiskRuleTypeCustomExpr {
+		entry.Details = redacted
+	}
+	return entry
+}